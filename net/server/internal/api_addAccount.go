@@ -62,13 +62,7 @@ func AddAccount(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// generate account key
-	privateKey, publicKey, keyType, err := GenerateRsaKeyPair()
-	if err != nil {
-		ErrResponse(w, http.StatusInternalServerError, err)
-		return
-	}
-	privatePem := ExportRsaPrivateKeyAsPemStr(privateKey)
-	publicPem, err := ExportRsaPublicKeyAsPemStr(publicKey)
+	privatePem, publicPem, keyType, err := GenerateKeyPair()
 	if err != nil {
 		ErrResponse(w, http.StatusInternalServerError, err)
 		return
@@ -4,12 +4,8 @@ import (
 	"databag/internal/store"
 	"encoding/hex"
 	"github.com/theckman/go-securerandom"
-  "github.com/pquerna/otp/totp"
-  "github.com/pquerna/otp"
 	"gorm.io/gorm"
 	"net/http"
-  "errors"
-  "time"
 )
 
 //AddAccountApp with access token, attach an app to an account generating agent token
@@ -21,50 +17,9 @@ func AddAccountApp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-  curTime := time.Now().Unix()
-  if account.MFAFailedTime + APPMFAFailPeriod > curTime && account.MFAFailedCount > APPMFAFailCount {
-    ErrResponse(w, http.StatusTooManyRequests, errors.New("temporarily locked"))
-    return;
-  }
-
-  if account.MFAEnabled && account.MFAConfirmed {
-    code := r.FormValue("code")
-    if code == "" {
-      ErrResponse(w, http.StatusMethodNotAllowed, errors.New("totp code required"))
-      return;
-    }
-
-    algorithm := otp.AlgorithmSHA256;
-    if account.MFAAlgorithm == APPMFASHA1 {
-      algorithm = otp.AlgorithmSHA1
-    }
-    opts := totp.ValidateOpts{Period: 30, Skew: 1, Digits: otp.DigitsSix, Algorithm: algorithm}
-    if valid, _ := totp.ValidateCustom(code, account.MFASecret, time.Now(), opts); !valid {
-      err := store.DB.Transaction(func(tx *gorm.DB) error {
-        if account.MFAFailedTime + APPMFAFailPeriod > curTime {
-          account.MFAFailedCount += 1;
-          if res := tx.Model(account).Update("mfa_failed_count", account.MFAFailedCount).Error; res != nil {
-            return res
-          }
-        } else {
-          account.MFAFailedTime = curTime
-          if res := tx.Model(account).Update("mfa_failed_time", account.MFAFailedTime).Error; res != nil {
-            return res
-          }
-          account.MFAFailedCount = 1
-          if res := tx.Model(account).Update("mfa_failed_count", account.MFAFailedCount).Error; res != nil {
-            return res
-          }
-        }
-        return nil
-      })
-      if err != nil {
-        LogMsg("failed to increment fail count");
-      }
-
-      ErrResponse(w, http.StatusForbidden, errors.New("invalid code"))
-      return
-    }
+  if code, err := AccountCode(r, account); err != nil {
+    ErrResponse(w, code, err)
+    return
   }
 
   // parse authentication token
@@ -0,0 +1,42 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+//AddAccountContactBlock ignores contact requests from a sender guid or node
+func AddAccountContactBlock(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var block ContactBlock
+	if err := ParseRequest(r, w, &block); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	node := getContactNode(block.Node)
+	if (block.GUID == "") == (node == "") {
+		ErrResponse(w, http.StatusBadRequest, errors.New("block requires either guid or node"))
+		return
+	}
+
+	entry := &store.ContactBlock{
+		BlockID:   uuid.New().String(),
+		AccountID: account.ID,
+		GUID:      block.GUID,
+		Node:      node,
+	}
+	if err := store.DB.Save(entry).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	WriteResponse(w, getContactBlockModel(entry))
+}
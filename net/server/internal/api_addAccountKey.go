@@ -0,0 +1,56 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/theckman/go-securerandom"
+	"net/http"
+)
+
+//AddAccountKey with login credentials, generates an api key for posting as a bot account
+func AddAccountKey(w http.ResponseWriter, r *http.Request) {
+
+	account, res := AccountLogin(r)
+	if res != nil {
+		ErrResponse(w, http.StatusUnauthorized, res)
+		return
+	}
+	if code, err := AccountCode(r, account); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+	if account.Disabled {
+		ErrResponse(w, http.StatusGone, errors.New("account is inactive"))
+		return
+	}
+	if !account.Bot {
+		ErrResponse(w, http.StatusForbidden, errors.New("api keys require bot account"))
+		return
+	}
+
+	// generate key token
+	data, err := securerandom.Bytes(APPTokenSize)
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	access := hex.EncodeToString(data)
+
+	key := &store.ApiKey{
+		ApiKeyID:  uuid.New().String(),
+		AccountID: account.GUID,
+		Name:      r.FormValue("name"),
+		Token:     getApiKeyHash(access),
+	}
+	if err := store.DB.Save(key).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// key is only returned on creation
+	model := getApiKeyModel(key)
+	model.Key = account.GUID + "." + access
+	WriteResponse(w, model)
+}
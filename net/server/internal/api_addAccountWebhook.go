@@ -0,0 +1,76 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/theckman/go-securerandom"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+//AddAccountWebhook subscribes a url to events of the account identified by agent query param
+func AddAccountWebhook(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var params WebhookParams
+	if err := ParseRequest(r, w, &params); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate endpoint
+	endpoint, err := url.Parse(params.URL)
+	if err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" {
+		ErrResponse(w, http.StatusBadRequest, errors.New("invalid webhook url"))
+		return
+	}
+	if err := checkWebhookURL(endpoint); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// generate secret if not provided
+	secret := params.Secret
+	if secret == "" {
+		data, res := securerandom.Bytes(APPTokenSize)
+		if res != nil {
+			ErrResponse(w, http.StatusInternalServerError, res)
+			return
+		}
+		secret = hex.EncodeToString(data)
+	}
+
+	var events []string
+	for _, event := range params.Events {
+		event = strings.TrimSpace(event)
+		if event != "" {
+			events = append(events, event)
+		}
+	}
+
+	webhook := &store.Webhook{
+		WebhookID: uuid.New().String(),
+		AccountID: account.ID,
+		URL:       endpoint.String(),
+		Secret:    secret,
+		Events:    strings.Join(events, ","),
+	}
+	if err := store.DB.Save(webhook).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	WriteResponse(w, getWebhookModel(webhook, true))
+}
@@ -74,6 +74,7 @@ func AddCard(w http.ResponseWriter, r *http.Request) {
 			Version:         identity.Version,
 			Node:            identity.Node,
       Seal:            identity.Seal,
+			Bot:             identity.Bot,
 			ProfileRevision: identity.Revision,
 			Status:          APPCardConfirmed,
       StatusUpdated:   time.Now().Unix(),
@@ -55,6 +55,7 @@ func AddChannel(w http.ResponseWriter, r *http.Request) {
       member.Card = *cardSlot.Card
       member.Channel = channel
       member.PushEnabled = true
+      member.Role = APPChannelPoster
 			if res := tx.Save(member).Error; res != nil {
 				return res
 			}
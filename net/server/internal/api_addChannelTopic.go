@@ -2,9 +2,11 @@ package databag
 
 import (
 	"databag/internal/store"
+	"errors"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"net/http"
+	"strconv"
   "time"
 )
 
@@ -12,6 +14,24 @@ import (
 func AddChannelTopic(w http.ResponseWriter, r *http.Request) {
 
 	confirm := r.FormValue("confirm")
+	parent := r.FormValue("parent")
+
+	// optional publish and expiry times
+	var schedule int64
+	var expires int64
+	var err error
+	if value := r.FormValue("schedule"); value != "" {
+		if schedule, err = strconv.ParseInt(value, 10, 64); err != nil {
+			ErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if value := r.FormValue("expires"); value != "" {
+		if expires, err = strconv.ParseInt(value, 10, 64); err != nil {
+			ErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
 
 	var subject Subject
 	if err := ParseRequest(r, w, &subject); err != nil {
@@ -19,19 +39,62 @@ func AddChannelTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	channelSlot, guid, code, err := getChannelSlot(r, true)
+	channelSlot, guid, code, err := getPosterChannelSlot(r, true)
 	if err != nil {
 		ErrResponse(w, code, err)
 		return
 	}
 	act := &channelSlot.Account
 
+	// read-only members cannot post
+	if !canPost(getMemberRole(guid, &channelSlot)) {
+		ErrResponse(w, http.StatusForbidden, errors.New("member is read-only"))
+		return
+	}
+
+	// channel timer applies from publish time unless topic expires sooner
+	publish := time.Now().Unix()
+	if schedule > publish {
+		publish = schedule
+	} else {
+		schedule = 0
+	}
+	if channelSlot.Channel.Expire > 0 && (expires == 0 || publish+channelSlot.Channel.Expire < expires) {
+		expires = publish + channelSlot.Channel.Expire
+	}
+	if expires != 0 && expires <= publish {
+		ErrResponse(w, http.StatusBadRequest, errors.New("topic expires before publish"))
+		return
+	}
+
+	// load thread parent
+	var parentSlot store.TopicSlot
+	if parent != "" {
+		if err := store.DB.Preload("Topic").Where("channel_id = ? AND topic_slot_id = ?", channelSlot.Channel.ID, parent).First(&parentSlot).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ErrResponse(w, http.StatusNotFound, err)
+			} else {
+				ErrResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+		if parentSlot.Topic == nil {
+			ErrResponse(w, http.StatusNotFound, errors.New("referenced empty parent topic"))
+			return
+		}
+		if parentSlot.ParentID != 0 {
+			ErrResponse(w, http.StatusBadRequest, errors.New("replies cannot be nested"))
+			return
+		}
+	}
+
 	topicSlot := &store.TopicSlot{}
 	err = store.DB.Transaction(func(tx *gorm.DB) error {
 
 		topicSlot.TopicSlotID = uuid.New().String()
 		topicSlot.AccountID = act.ID
 		topicSlot.ChannelID = channelSlot.Channel.ID
+		topicSlot.ParentID = parentSlot.ID
 		topicSlot.Revision = act.ChannelRevision + 1
 		if res := tx.Save(topicSlot).Error; res != nil {
 			return res
@@ -44,9 +107,14 @@ func AddChannelTopic(w http.ResponseWriter, r *http.Request) {
 		topic.Data = subject.Data
 		topic.DataType = subject.DataType
 		topic.GUID = guid
+		topic.ParentSlotID = parentSlot.TopicSlotID
 		topic.DetailRevision = act.ChannelRevision + 1
 		topic.TagRevision = act.ChannelRevision + 1
-		if confirm == "true" {
+		topic.Publish = schedule
+		topic.Expires = expires
+		if confirm == "true" && schedule != 0 {
+			topic.Status = APPTopicScheduled
+		} else if confirm == "true" {
 			topic.Status = APPTopicConfirmed
 		} else {
 			topic.Status = APPTopicUnconfirmed
@@ -58,6 +126,13 @@ func AddChannelTopic(w http.ResponseWriter, r *http.Request) {
 		topicSlot.Topic = topic
     revision := act.ChannelRevision + 1;
 
+		// update reply count of thread parent, scheduled replies counted once published
+		if isTopicPublished(topic) {
+			if res := updateReplyCount(tx, parentSlot.ID, 1, revision); res != nil {
+				return res
+			}
+		}
+
 		// update parent revision
 		if res := tx.Model(&store.Channel{}).Where("id = ?", channelSlot.Channel.ID).Update("topic_revision", revision).Error; res != nil {
 			return res
@@ -76,12 +151,12 @@ func AddChannelTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// determine affected contact list
+	// determine affected contact list, scheduled topics pushed when published
 	cards := make(map[string]store.Card)
 	notify := make(map[string]store.Card)
 	for _, member := range channelSlot.Channel.Members {
 		cards[member.Card.GUID] = member.Card
-    if member.PushEnabled && member.Card.GUID != guid {
+    if schedule == 0 && member.PushEnabled && member.Card.GUID != guid {
 		  notify[member.Card.GUID] = member.Card
     }
 	}
@@ -102,8 +177,10 @@ func AddChannelTopic(w http.ResponseWriter, r *http.Request) {
     for _, card := range notify {
       SetContactPushNotification(&card, "content.addChannelTopic." + channelSlot.Channel.DataType)
     }
-    if act.GUID != guid {
-      go SendPushEvent(*act, "content.addChannelTopic." + channelSlot.Channel.DataType)
+    if schedule == 0 && act.GUID != guid && !channelSlot.Channel.Muted {
+      if card, ok := cards[guid]; !ok || (!card.Muted && !card.Blocked) {
+        go SendPushEvent(*act, "content.addChannelTopic." + channelSlot.Channel.DataType)
+      }
     }
   }()
 }
@@ -28,7 +28,7 @@ func AddChannelTopicAsset(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	channelSlot, guid, code, err := getChannelSlot(r, true)
+	channelSlot, guid, code, err := getPosterChannelSlot(r, true)
 	if err != nil {
 		ErrResponse(w, code, err)
 		return
@@ -36,10 +36,7 @@ func AddChannelTopicAsset(w http.ResponseWriter, r *http.Request) {
 	act := &channelSlot.Account
 
 	// check storage
-	if full, err := isStorageFull(act); err != nil {
-		ErrResponse(w, http.StatusInternalServerError, err)
-		return
-	} else if full {
+	if isStorageFull(act) {
 		ErrResponse(w, http.StatusNotAcceptable, errors.New("storage limit reached"))
 		return
 	}
@@ -66,11 +63,11 @@ func AddChannelTopicAsset(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// avoid async cleanup of file before record is created
-	garbageSync.Lock()
-	defer garbageSync.Unlock()
+	id := uuid.New().String()
+	holdAsset(id)
+	defer releaseAsset(id)
 
 	// save new file
-	id := uuid.New().String()
 	path := getStrConfigValue(CNFAssetPath, APPDefaultPath) + "/" + channelSlot.Account.GUID + "/" + id
 	r.Body = http.MaxBytesReader(w, r.Body, APPBodyLimit)
 	if err := r.ParseMultipartForm(32 << 20); err != nil {
@@ -99,11 +96,31 @@ func AddChannelTopicAsset(w http.ResponseWriter, r *http.Request) {
 	asset.Status = APPAssetReady
 	asset.Size = size
 	asset.Crc = crc
+	if err := screenUpload(r.Context(), asset, channelSlot.Account.GUID, path); err != nil {
+		os.Remove(path)
+		ErrResponse(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if asset.Status == APPAssetReady {
+		if err := prepareUpload(asset, path); err != nil {
+			os.Remove(path)
+			if errors.Is(err, errMetadataFormat) {
+				ErrResponse(w, http.StatusBadRequest, err)
+			} else {
+				ErrResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+	}
+	source := asset
 	err = store.DB.Transaction(func(tx *gorm.DB) error {
 		if res := tx.Save(asset).Error; res != nil {
 			return res
 		}
-		assets = append(assets, Asset{AssetID: id, Status: APPAssetReady})
+		if res := adjustStorage(tx, asset.AccountID, asset.Size); res != nil {
+			return res
+		}
+		assets = append(assets, Asset{AssetID: id, Status: asset.Status, Error: asset.TransformError})
 		for _, transform := range transforms {
 			asset := &store.Asset{}
 			asset.AssetID = uuid.New().String()
@@ -112,6 +129,10 @@ func AddChannelTopicAsset(w http.ResponseWriter, r *http.Request) {
 			asset.TopicID = topicSlot.Topic.ID
 			asset.Status = APPAssetWaiting
 			asset.TransformID = id
+			if source.Status == APPAssetError {
+				asset.Status = APPAssetError
+				asset.TransformError = source.TransformError
+			}
 			t := strings.Split(transform, ";")
 			if len(t) > 0 {
 				asset.Transform = t[0]
@@ -125,7 +146,7 @@ func AddChannelTopicAsset(w http.ResponseWriter, r *http.Request) {
 			if res := tx.Save(asset).Error; res != nil {
 				return res
 			}
-			assets = append(assets, Asset{AssetID: asset.AssetID, Transform: transform, Status: APPAssetWaiting})
+			assets = append(assets, Asset{AssetID: asset.AssetID, Transform: transform, Status: asset.Status, Error: asset.TransformError})
 		}
 		if res := tx.Model(&topicSlot.Topic).Update("detail_revision", act.ChannelRevision+1).Error; res != nil {
 			return res
@@ -155,29 +176,6 @@ func AddChannelTopicAsset(w http.ResponseWriter, r *http.Request) {
 	WriteResponse(w, &assets)
 }
 
-func isStorageFull(act *store.Account) (full bool, err error) {
-
-	storage := getNumConfigValue(CNFStorage, 0)
-	if storage == 0 {
-		return
-	}
-
-	var assets []store.Asset
-	if err = store.DB.Where("account_id = ?", act.ID).Find(&assets).Error; err != nil {
-		return
-	}
-
-	var size int64
-	for _, asset := range assets {
-		size += asset.Size
-	}
-	if size >= storage {
-		full = true
-	}
-
-	return
-}
-
 func saveAsset(src io.Reader, path string) (crc uint32, size int64, err error) {
 
 	output, res := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0600)
@@ -7,6 +7,7 @@ import (
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 	"net/http"
+	"os"
 )
 
 //AddChannelTopicBlock adds a file block asset to a topic
@@ -17,7 +18,7 @@ func AddChannelTopicBlock(w http.ResponseWriter, r *http.Request) {
 	topicID := params["topicID"]
   body := r.FormValue("body")
 
-	channelSlot, guid, code, err := getChannelSlot(r, true)
+	channelSlot, guid, code, err := getPosterChannelSlot(r, true)
 	if err != nil {
 		ErrResponse(w, code, err)
 		return
@@ -25,10 +26,7 @@ func AddChannelTopicBlock(w http.ResponseWriter, r *http.Request) {
 	act := &channelSlot.Account
 
 	// check storage
-	if full, err := isStorageFull(act); err != nil {
-		ErrResponse(w, http.StatusInternalServerError, err)
-		return
-	} else if full {
+	if isStorageFull(act) {
 		ErrResponse(w, http.StatusNotAcceptable, errors.New("storage limit reached"))
 		return
 	}
@@ -55,13 +53,13 @@ func AddChannelTopicBlock(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// avoid async cleanup of file before record is created
-	garbageSync.Lock()
-	defer garbageSync.Unlock()
+	id := uuid.New().String()
+	holdAsset(id)
+	defer releaseAsset(id)
 
   // save new file
   var crc uint32
   var size int64
-  id := uuid.New().String()
   path := getStrConfigValue(CNFAssetPath, APPDefaultPath) + "/" + channelSlot.Account.GUID + "/" + id
   if body == "multipart" {
     if err := r.ParseMultipartForm(32 << 20); err != nil {
@@ -98,10 +96,29 @@ func AddChannelTopicBlock(w http.ResponseWriter, r *http.Request) {
   asset.TransformID = id
 	asset.Size = size
 	asset.Crc = crc
+	if err := screenUpload(r.Context(), asset, channelSlot.Account.GUID, path); err != nil {
+		os.Remove(path)
+		ErrResponse(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if asset.Status == APPAssetReady {
+		if err := prepareUpload(asset, path); err != nil {
+			os.Remove(path)
+			if errors.Is(err, errMetadataFormat) {
+				ErrResponse(w, http.StatusBadRequest, err)
+			} else {
+				ErrResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+	}
 	err = store.DB.Transaction(func(tx *gorm.DB) error {
 		if res := tx.Save(asset).Error; res != nil {
 			return res
 		}
+		if res := adjustStorage(tx, asset.AccountID, asset.Size); res != nil {
+			return res
+		}
 		if res := tx.Model(&topicSlot.Topic).Update("detail_revision", act.ChannelRevision+1).Error; res != nil {
 			return res
 		}
@@ -124,6 +141,6 @@ func AddChannelTopicBlock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-  WriteResponse(w, &Asset{AssetID: asset.AssetID, Transform: "_", Status: APPAssetReady})
+  WriteResponse(w, &Asset{AssetID: asset.AssetID, Transform: "_", Status: asset.Status, Error: asset.TransformError})
 }
 
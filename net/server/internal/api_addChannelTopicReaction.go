@@ -0,0 +1,125 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/http"
+)
+
+//AddChannelTopicReaction adds emoji reaction of invoker to topic
+func AddChannelTopicReaction(w http.ResponseWriter, r *http.Request) {
+
+	// scan parameters
+	params := mux.Vars(r)
+	topicID := params["topicID"]
+
+	var emoji string
+	if err := ParseRequest(r, w, &emoji); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if emoji == "" || len(emoji) > APPReactionMaxSize {
+		ErrResponse(w, http.StatusBadRequest, errors.New("invalid reaction"))
+		return
+	}
+
+	channelSlot, guid, code, err := getChannelSlot(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+	act := &channelSlot.Account
+
+	// read-only members cannot react
+	if !canPost(getMemberRole(guid, &channelSlot)) {
+		ErrResponse(w, http.StatusForbidden, errors.New("member is read-only"))
+		return
+	}
+
+	// load topic
+	var topicSlot store.TopicSlot
+	if err = store.DB.Preload("Topic").Where("channel_id = ? AND topic_slot_id = ?", channelSlot.Channel.ID, topicID).First(&topicSlot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrResponse(w, http.StatusNotFound, err)
+		} else {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if topicSlot.Topic == nil || isTopicHidden(topicSlot.Topic, guid) {
+		ErrResponse(w, http.StatusNotFound, errors.New("referenced empty topic"))
+		return
+	}
+
+	// one reaction per member per emoji
+	reaction := &store.Reaction{
+		AccountID: act.ID,
+		ChannelID: channelSlot.Channel.ID,
+		TopicID:   topicSlot.Topic.ID,
+		GUID:      guid,
+		Emoji:     emoji,
+	}
+	added := false
+	err = store.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return tx.Where("topic_id = ? AND guid = ? AND emoji = ?", reaction.TopicID, guid, emoji).First(reaction).Error
+		}
+		added = true
+		return setReactionRevision(tx, act, &channelSlot, &topicSlot)
+	})
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !added {
+		WriteResponse(w, getReactionModel(reaction))
+		return
+	}
+
+	// determine affected contact list
+	cards := make(map[string]store.Card)
+	for _, member := range channelSlot.Channel.Members {
+		cards[member.Card.GUID] = member.Card
+	}
+	for _, group := range channelSlot.Channel.Groups {
+		for _, card := range group.Cards {
+			cards[card.GUID] = card
+		}
+	}
+
+	// notify
+	SetStatus(act)
+	for _, card := range cards {
+		SetContactChannelNotification(act, &card)
+	}
+
+	WriteResponse(w, getReactionModel(reaction))
+}
+
+// setReactionRevision bumps detail and tag revisions so existing clients resync reactions with tags
+func setReactionRevision(tx *gorm.DB, act *store.Account, channelSlot *store.ChannelSlot, topicSlot *store.TopicSlot) error {
+	revision := act.ChannelRevision + 1
+	if res := tx.Model(topicSlot.Topic).Updates(map[string]interface{}{"detail_revision": revision, "tag_revision": revision}).Error; res != nil {
+		return res
+	}
+	if res := tx.Model(topicSlot).Update("revision", revision).Error; res != nil {
+		return res
+	}
+	if res := tx.Model(channelSlot.Channel).Update("topic_revision", revision).Error; res != nil {
+		return res
+	}
+	if res := tx.Model(channelSlot).Update("revision", revision).Error; res != nil {
+		return res
+	}
+	if res := tx.Model(act).Update("channel_revision", revision).Error; res != nil {
+		return res
+	}
+	return nil
+}
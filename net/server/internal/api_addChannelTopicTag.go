@@ -29,6 +29,12 @@ func AddChannelTopicTag(w http.ResponseWriter, r *http.Request) {
 	}
 	act := &channelSlot.Account
 
+	// read-only members cannot tag
+	if !canPost(getMemberRole(guid, &channelSlot)) {
+		ErrResponse(w, http.StatusForbidden, errors.New("member is read-only"))
+		return
+	}
+
 	// load topic
 	var topicSlot store.TopicSlot
 	if err = store.DB.Preload("Topic.Tags").Where("channel_id = ? AND topic_slot_id = ?", channelSlot.Channel.ID, topicID).First(&topicSlot).Error; err != nil {
@@ -0,0 +1,10 @@
+package databag
+
+import (
+	"net/http"
+)
+
+//AddFederationAllow add node pattern to federation allow list
+func AddFederationAllow(w http.ResponseWriter, r *http.Request) {
+	addNodeRule(w, r, APPNodeAllow)
+}
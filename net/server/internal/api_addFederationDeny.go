@@ -0,0 +1,10 @@
+package databag
+
+import (
+	"net/http"
+)
+
+//AddFederationDeny add node pattern to federation deny list
+func AddFederationDeny(w http.ResponseWriter, r *http.Request) {
+	addNodeRule(w, r, APPNodeDeny)
+}
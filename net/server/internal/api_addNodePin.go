@@ -0,0 +1,48 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"encoding/base64"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm/clause"
+	"net"
+	"net/http"
+)
+
+//AddNodePin pin certificate public key of federated node
+func AddNodePin(w http.ResponseWriter, r *http.Request) {
+
+	if code, err := ParamAdminToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	node := getNodeHost(mux.Vars(r)["node"])
+	if node == "" {
+		ErrResponse(w, http.StatusBadRequest, errors.New("node not set"))
+		return
+	}
+	if net.ParseIP(node) != nil {
+		ErrResponse(w, http.StatusBadRequest, errors.New("pinned node must be a hostname"))
+		return
+	}
+
+	// pin is base64 sha256 of certificate subject public key info
+	var pin string
+	if err := ParseRequest(r, w, &pin); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if sum, err := base64.StdEncoding.DecodeString(pin); err != nil || len(sum) != 32 {
+		ErrResponse(w, http.StatusBadRequest, errors.New("invalid certificate pin"))
+		return
+	}
+
+	if err := store.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&store.NodePin{Node: node, Pin: pin}).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	WriteResponse(w, nil)
+}
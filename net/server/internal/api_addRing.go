@@ -21,7 +21,7 @@ func AddRing(w http.ResponseWriter, r *http.Request) {
 
   // push event on first ring
   if ring.Index == 0 {
-    SendPushEvent(card.Account, "ring");
+    sendContactPushEvent(card, "ring");
   }
 
   SetRing(card, ring);
@@ -0,0 +1,33 @@
+package databag
+
+import (
+	"net/http"
+)
+
+//AddTranscodeLease leases next waiting asset transform to remote worker, null when none are waiting
+func AddTranscodeLease(w http.ResponseWriter, r *http.Request) {
+
+	worker, code, err := ParamWorkerToken(r)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	asset, err := leaseTranscodeAsset(worker)
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if asset == nil {
+		WriteResponse(w, nil)
+		return
+	}
+
+	WriteResponse(w, &TranscodeLease{
+		LeaseID:   asset.TransformLease,
+		Transform: asset.Transform,
+		Params:    asset.TransformParams,
+		Queue:     asset.TransformQueue,
+		Expires:   asset.TransformExpires,
+	})
+}
@@ -0,0 +1,56 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/theckman/go-securerandom"
+	"net/http"
+	"strings"
+)
+
+//AddTranscodeWorker registers a remote worker that may lease asset transforms
+func AddTranscodeWorker(w http.ResponseWriter, r *http.Request) {
+
+	if code, err := ParamAdminToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var params TranscodeWorkerParams
+	if err := ParseRequest(r, w, &params); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	for _, queue := range params.Queues {
+		if queue != APPQueueVideo && queue != APPQueueAudio && queue != APPQueuePhoto && queue != APPWorkerDefaultQueue {
+			ErrResponse(w, http.StatusBadRequest, errors.New("unknown transform queue"))
+			return
+		}
+	}
+
+	// generate worker token
+	data, err := securerandom.Bytes(APPTokenSize)
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	access := hex.EncodeToString(data)
+
+	worker := &store.TranscodeWorker{
+		WorkerID: uuid.New().String(),
+		Name:     params.Name,
+		Token:    access,
+		Queues:   strings.Join(params.Queues, ","),
+	}
+	if err := store.DB.Save(worker).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// token is only returned on creation
+	model := getTranscodeWorkerModel(worker)
+	model.Token = worker.WorkerID + "." + access
+	WriteResponse(w, model)
+}
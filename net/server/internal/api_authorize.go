@@ -23,7 +23,7 @@ func Authorize(w http.ResponseWriter, r *http.Request) {
 	claim := &Claim{Token: token}
 
 	msg, err := WriteDataMessage(detail.PrivateKey, detail.PublicKey, detail.KeyType,
-		APPSignPKCS1V15, account.GUID, APPMsgAuthenticate, &claim)
+		getSignType(detail.KeyType), account.GUID, APPMsgAuthenticate, &claim)
 	if err != nil {
 		ErrResponse(w, http.StatusInternalServerError, err)
 		return
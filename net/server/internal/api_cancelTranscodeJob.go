@@ -0,0 +1,47 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+//CancelTranscodeJob stop running transform of asset leaving it in error state
+func CancelTranscodeJob(w http.ResponseWriter, r *http.Request) {
+
+	if code, err := ParamAdminToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var asset store.Asset
+	if err := store.DB.Where("asset_id = ? AND status = ?", mux.Vars(r)["assetID"], APPAssetProcessing).First(&asset).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrResponse(w, http.StatusNotFound, err)
+		} else {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	// remote workers find their lease revoked on next report
+	if asset.TransformLease != "" {
+		leased := &store.Asset{}
+		if err := store.DB.Preload("Account").Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Preload("Channel.ChannelSlot").Preload("Topic.TopicSlot").Where("id = ?", asset.ID).First(leased).Error; err != nil {
+			ErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		leased.TransformError = "transform cancelled"
+		if err := endTranscodeLease(leased, APPAssetError, 0, 0); err != nil {
+			ErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+	} else if cancelTranscodeJobs(func(job *transcodeJob) bool { return job.id == asset.ID }) == 0 {
+		ErrResponse(w, http.StatusNotFound, errors.New("transform not running"))
+		return
+	}
+
+	WriteResponse(w, nil)
+}
@@ -26,6 +26,18 @@ type CleanupResponse struct {
 	AffectedAccounts int   `json:"affectedAccounts"`
 	ProcessingTime   int64 `json:"processingTime"`
 	IPBlocksDeleted  int64 `json:"ipBlocksDeleted"`
+
+	Accounts []CleanupAccount `json:"accounts,omitempty"`
+}
+
+// CleanupAccount retention applied and data removed for an account with deletions
+type CleanupAccount struct {
+	AccountID     uint  `json:"accountID"`
+	TopicDays     int64 `json:"topicDays"`
+	AssetDays     int64 `json:"assetDays"`
+	DeletedTopics int64 `json:"deletedTopics"`
+	DeletedAssets int64 `json:"deletedAssets"`
+	FreedBytes    int64 `json:"freedBytes"`
 }
 
 type CleanupStatus struct {
@@ -36,6 +48,14 @@ type CleanupStatus struct {
 	OldAssets       int64 `json:"oldAssets"`
 	LastCleanupTime int64 `json:"lastCleanupTime,omitempty"`
 	EstimatedSpace  int64 `json:"estimatedSpace"`
+
+	Fsck *FsckStatus `json:"fsck,omitempty"`
+}
+
+// FsckRequest reconciles asset records with files, repairing findings unless only reporting
+type FsckRequest struct {
+	Repair    bool  `json:"repair"`
+	AccountID *uint `json:"accountID,omitempty"`
 }
 
 func CleanupData(w http.ResponseWriter, r *http.Request) {
@@ -195,6 +215,12 @@ func runActualCleanup(req CleanupRequest, response *CleanupResponse) error {
 	response.AffectedAccounts = len(accounts)
 
 	for _, account := range accounts {
+		var revised []uint
+		var removed []uint
+		result := CleanupAccount{AccountID: account.ID, TopicDays: req.RetentionDays}
+		if req.IncludeAssets {
+			result.AssetDays = req.RetentionDays
+		}
 		for {
 			var topics []store.Topic
 			err := store.DB.Where("account_id = ? AND created < ?", account.ID, cutoffTime).
@@ -212,6 +238,7 @@ func runActualCleanup(req CleanupRequest, response *CleanupResponse) error {
 			var topicIDs []uint
 			for _, topic := range topics {
 				topicIDs = append(topicIDs, topic.ID)
+				removed = append(removed, topic.TopicSlotID)
 			}
 
 			err = store.DB.Transaction(func(tx *gorm.DB) error {
@@ -220,43 +247,48 @@ func runActualCleanup(req CleanupRequest, response *CleanupResponse) error {
 					if res := tx.Where("topic_id IN ?", topicIDs).Find(&assets).Error; res != nil {
 						return res
 					}
-					response.DeletedAssets += int64(len(assets))
+					result.DeletedAssets += int64(len(assets))
+					result.FreedBytes += calculateAssetSize(assets)
 
-					if res := tx.Where("topic_id IN ?", topicIDs).Delete(&store.Asset{}).Error; res != nil {
+					if res := removeAssets(tx, "topic_id IN ?", topicIDs); res != nil {
 						return res
 					}
 				}
 
-				if res := tx.Where("topic_id IN ?", topicIDs).Delete(&store.Tag{}).Error; res != nil {
-					return res
-				}
-
-				if res := tx.Where("topic_id IN ?", topicIDs).Delete(&store.TagSlot{}).Error; res != nil {
-					return res
-				}
-
-				if res := tx.Where("id IN ?", topicIDs).Delete(&store.Topic{}).Error; res != nil {
-					return res
-				}
-
-				if res := tx.Where("topic_slot_id IN ?", topicIDs).Delete(&store.TopicSlot{}).Error; res != nil {
+				parents, res := releaseTopicParents(tx, topicIDs)
+				if res != nil {
 					return res
 				}
+				revised = append(revised, parents...)
 
-				return nil
+				return removeTopics(tx, topicIDs)
 			})
 
 			if err != nil {
 				return err
 			}
 
-			response.DeletedTopics += int64(len(topics))
+			result.DeletedTopics += int64(len(topics))
 
 			if len(topics) < batchSize {
 				break
 			}
 		}
 
+		if err := setTopicRevisions(&account, revised); err != nil {
+			return err
+		}
+		if err := setRemovedTopicRevisions(&account, removed); err != nil {
+			return err
+		}
+
+		response.DeletedTopics += result.DeletedTopics
+		response.DeletedAssets += result.DeletedAssets
+		response.FreedBytes += result.FreedBytes
+		if result.DeletedTopics > 0 || result.DeletedAssets > 0 {
+			response.Accounts = append(response.Accounts, result)
+		}
+
 		err := store.DB.Transaction(func(tx *gorm.DB) error {
 			var emptyChannels []store.Channel
 			if res := tx.Where("account_id = ? AND created < ? AND topic_revision = 0", account.ID, cutoffTime).
@@ -294,8 +326,7 @@ func runActualCleanup(req CleanupRequest, response *CleanupResponse) error {
 
 func cleanupOrphanedAssets(cutoffTime int64) {
 	err := store.DB.Transaction(func(tx *gorm.DB) error {
-		if res := tx.Where("created < ? AND topic_id = 0", cutoffTime).
-			Delete(&store.Asset{}).Error; res != nil {
+		if res := removeAssets(tx, "created < ? AND topic_id = 0", cutoffTime); res != nil {
 			return res
 		}
 		return nil
@@ -355,9 +386,47 @@ func GetCleanupStatus(w http.ResponseWriter, r *http.Request) {
 		status.LastCleanupTime = lastCleanup
 	}
 
+	fsck := getFsckStatus()
+	if fsck.Started > 0 {
+		status.Fsck = fsck
+	}
+
 	WriteResponse(w, status)
 }
 
+// FsckAssets starts asset fsck in background, results reported by cleanup status
+func FsckAssets(w http.ResponseWriter, r *http.Request) {
+	if code, err := ParamSessionToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var req FsckRequest
+	if err := ParseRequest(r, w, &req); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.AccountID != nil {
+		if err := store.DB.First(&store.Account{}, *req.AccountID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ErrResponse(w, http.StatusNotFound, errors.New("account not found"))
+			} else {
+				ErrResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+	}
+
+	if !startAssetFsck(req.Repair, req.AccountID) {
+		ErrResponse(w, http.StatusConflict, errors.New("asset fsck already running"))
+		return
+	}
+
+	LogMsg(fmt.Sprintf("[Cleanup] 开始检查文件一致性 - 修复: %t, 客户端IP: %s", req.Repair, getClientIP(r)))
+	WriteResponse(w, nil)
+}
+
 func SetCleanupConfig(w http.ResponseWriter, r *http.Request) {
 	if code, err := ParamSessionToken(r); err != nil {
 		ErrResponse(w, code, err)
@@ -402,6 +471,34 @@ func SetCleanupConfig(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		if hours, ok := config["fsckIntervalHours"].(float64); ok {
+			if res := tx.Exec(`INSERT OR REPLACE INTO configs (config_id, num_value) VALUES (?, ?) ON CONFLICT(config_id) DO UPDATE SET num_value = ?`,
+				CNFFsckIntervalHours, int64(hours), int64(hours)); res.Error != nil {
+				return res.Error
+			}
+		}
+
+		if repair, ok := config["fsckRepair"].(bool); ok {
+			if res := tx.Exec(`INSERT OR REPLACE INTO configs (config_id, bool_value) VALUES (?, ?) ON CONFLICT(config_id) DO UPDATE SET bool_value = ?`,
+				CNFFsckRepair, repair, repair); res.Error != nil {
+				return res.Error
+			}
+		}
+
+		if days, ok := config["retentionMinDays"].(float64); ok {
+			if res := tx.Exec(`INSERT OR REPLACE INTO configs (config_id, num_value) VALUES (?, ?) ON CONFLICT(config_id) DO UPDATE SET num_value = ?`,
+				CNFRetentionMinDays, int64(days), int64(days)); res.Error != nil {
+				return res.Error
+			}
+		}
+
+		if days, ok := config["retentionMaxDays"].(float64); ok {
+			if res := tx.Exec(`INSERT OR REPLACE INTO configs (config_id, num_value) VALUES (?, ?) ON CONFLICT(config_id) DO UPDATE SET num_value = ?`,
+				CNFRetentionMaxDays, int64(days), int64(days)); res.Error != nil {
+				return res.Error
+			}
+		}
+
 		return nil
 	})
 
@@ -424,6 +521,10 @@ func GetCleanupConfig(w http.ResponseWriter, r *http.Request) {
 		"cleanupIntervalHours": getNumConfigValue(CNFCleanupIntervalHours, 24),
 		"messageRetentionDays": getNumConfigValue(CNFMessageRetentionDays, 90),
 		"assetRetentionDays":   getNumConfigValue(CNFAssetRetentionDays, 180),
+		"retentionMinDays":     getNumConfigValue(CNFRetentionMinDays, APPRetentionMinDays),
+		"retentionMaxDays":     getNumConfigValue(CNFRetentionMaxDays, APPRetentionMaxDays),
+		"fsckIntervalHours":    getNumConfigValue(CNFFsckIntervalHours, 0),
+		"fsckRepair":           getBoolConfigValue(CNFFsckRepair, false),
 	}
 
 	WriteResponse(w, config)
@@ -8,10 +8,10 @@ import (
 	"net/http"
 )
 
-//ClearChannelCard removes card from channel membership
+//ClearChannelCard removes card from channel membership for account holder or channel admin
 func ClearChannelCard(w http.ResponseWriter, r *http.Request) {
 
-	account, code, err := ParamAgentToken(r, false)
+	account, role, code, err := getManagedChannel(r)
 	if err != nil {
 		ErrResponse(w, code, err)
 		return
@@ -94,5 +94,5 @@ func ClearChannelCard(w http.ResponseWriter, r *http.Request) {
   audio := getBoolConfigValue(CNFEnableAudio, true);
   image := getBoolConfigValue(CNFEnableImage, true);
   binary := getBoolConfigValue(CNFEnableBinary, true);
-	WriteResponse(w, getChannelModel(&channelSlot, true, true, image, audio, video, binary))
+	WriteResponse(w, getChannelModel(&channelSlot, true, role == APPChannelOwner, image, audio, video, binary))
 }
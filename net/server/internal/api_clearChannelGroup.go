@@ -8,10 +8,10 @@ import (
 	"net/http"
 )
 
-//ClearChannelGroup removes sharing group from channel
+//ClearChannelGroup removes sharing group from channel for account holder or channel admin
 func ClearChannelGroup(w http.ResponseWriter, r *http.Request) {
 
-	account, code, err := ParamAgentToken(r, false)
+	account, role, code, err := getManagedChannel(r)
 	if err != nil {
 		ErrResponse(w, code, err)
 		return
@@ -88,5 +88,5 @@ func ClearChannelGroup(w http.ResponseWriter, r *http.Request) {
   audio := getBoolConfigValue(CNFEnableAudio, true);
   image := getBoolConfigValue(CNFEnableImage, true);
   binary := getBoolConfigValue(CNFEnableBinary, true);
-	WriteResponse(w, getChannelModel(&channelSlot, true, true, image, audio, video, binary))
+	WriteResponse(w, getChannelModel(&channelSlot, true, role == APPChannelOwner, image, audio, video, binary))
 }
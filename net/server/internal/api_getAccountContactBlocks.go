@@ -0,0 +1,29 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"net/http"
+)
+
+//GetAccountContactBlocks retrieves senders whose contact requests are ignored
+func GetAccountContactBlocks(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var blocks []store.ContactBlock
+	if err := store.DB.Where("account_id = ?", account.ID).Find(&blocks).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := []*ContactBlock{}
+	for _, block := range blocks {
+		response = append(response, getContactBlockModel(&block))
+	}
+
+	WriteResponse(w, response)
+}
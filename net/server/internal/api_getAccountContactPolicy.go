@@ -0,0 +1,17 @@
+package databag
+
+import (
+	"net/http"
+)
+
+//GetAccountContactPolicy retrieves which senders may request a connection
+func GetAccountContactPolicy(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	WriteResponse(w, getContactPolicyModel(account))
+}
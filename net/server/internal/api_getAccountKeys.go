@@ -0,0 +1,29 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"net/http"
+)
+
+//GetAccountKeys retrieves api keys of bot account
+func GetAccountKeys(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var keys []store.ApiKey
+	if err := store.DB.Where("account_id = ?", account.GUID).Find(&keys).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := []*ApiKey{}
+	for _, key := range keys {
+		response = append(response, getApiKeyModel(&key))
+	}
+
+	WriteResponse(w, response)
+}
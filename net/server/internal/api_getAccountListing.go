@@ -11,14 +11,14 @@ func GetAccountListing(w http.ResponseWriter, r *http.Request) {
   filter := r.FormValue("filter")
 	var accounts []store.Account
   if filter == "" {
-    if err := store.DB.Order("id desc").Limit(16).Preload("AccountDetail").Where("searchable = ? AND disabled = ?", true, false).Find(&accounts).Error; err != nil {
+    if err := store.DB.Order("id desc").Limit(16).Preload("AccountDetail").Where("searchable = ? AND disabled = ? AND bot = ?", true, false, false).Find(&accounts).Error; err != nil {
       ErrResponse(w, http.StatusInternalServerError, err)
       return
     }
   } else {
       username := "%" + filter + "%"
       PrintMsg(username);
-    if err := store.DB.Order("id desc").Limit(16).Preload("AccountDetail").Where("username LIKE ? AND searchable = ? AND disabled = ?", username, true, false).Find(&accounts).Error; err != nil {
+    if err := store.DB.Order("id desc").Limit(16).Preload("AccountDetail").Where("username LIKE ? AND searchable = ? AND disabled = ? AND bot = ?", username, true, false, false).Find(&accounts).Error; err != nil {
       ErrResponse(w, http.StatusInternalServerError, err)
       return
     }
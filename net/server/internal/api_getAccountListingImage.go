@@ -19,7 +19,7 @@ func GetAccountListingImage(w http.ResponseWriter, r *http.Request) {
 	guid := params["guid"]
 
 	var account store.Account
-	if err := store.DB.Preload("AccountDetail").Where("guid = ? AND searchable = ? AND disabled = ?", guid, true, false).First(&account).Error; err != nil {
+	if err := store.DB.Preload("AccountDetail").Where("guid = ? AND searchable = ? AND disabled = ? AND bot = ?", guid, true, false, false).First(&account).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			ErrResponse(w, http.StatusNotFound, err)
 		} else {
@@ -16,7 +16,7 @@ func GetAccountListingMessage(w http.ResponseWriter, r *http.Request) {
 	guid := params["guid"]
 
 	var account store.Account
-	if err := store.DB.Preload("AccountDetail").Where("guid = ? AND searchable = ? AND disabled = ?", guid, true, false).First(&account).Error; err != nil {
+	if err := store.DB.Preload("AccountDetail").Where("guid = ? AND searchable = ? AND disabled = ? AND bot = ?", guid, true, false, false).First(&account).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			ErrResponse(w, http.StatusNotFound, err)
 		} else {
@@ -35,11 +35,12 @@ func GetAccountListingMessage(w http.ResponseWriter, r *http.Request) {
 		Location:    detail.Location,
 		Image:       detail.Image,
     Seal:        detail.SealPublic,
+		Bot:         account.Bot,
 		Version:     APPVersion,
 		Node:        getStrConfigValue(CNFDomain, ""),
 	}
 	msg, res := WriteDataMessage(detail.PrivateKey, detail.PublicKey, detail.KeyType,
-		APPSignPKCS1V15, account.GUID, APPMsgIdentity, &identity)
+		getSignType(detail.KeyType), account.GUID, APPMsgIdentity, &identity)
 	if res != nil {
 		ErrResponse(w, http.StatusInternalServerError, res)
 		return
@@ -0,0 +1,17 @@
+package databag
+
+import (
+	"net/http"
+)
+
+//GetAccountRetention retrieves days topics and assets are kept with bounds set by admin
+func GetAccountRetention(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	WriteResponse(w, getAccountRetentionModel(account))
+}
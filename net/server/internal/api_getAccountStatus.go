@@ -1,7 +1,6 @@
 package databag
 
 import (
-	"databag/internal/store"
 	"net/http"
 )
 
@@ -15,12 +14,6 @@ func GetAccountStatus(w http.ResponseWriter, r *http.Request) {
 	}
   account := session.Account
 
-	var assets []store.Asset
-	if err = store.DB.Where("account_id = ?", account.ID).Find(&assets).Error; err != nil {
-		ErrResponse(w, http.StatusInternalServerError, err)
-		return
-	}
-
 	// construct response
   seal := &Seal{}
   seal.PasswordSalt = account.AccountDetail.SealSalt
@@ -28,13 +21,12 @@ func GetAccountStatus(w http.ResponseWriter, r *http.Request) {
   seal.PrivateKeyEncrypted = account.AccountDetail.SealPrivate
   seal.PublicKey = account.AccountDetail.SealPublic
 	status := &AccountStatus{}
-	status.StorageAvailable = getNumConfigValue(CNFStorage, 0)
-	for _, asset := range assets {
-		status.StorageUsed += asset.Size
-	}
+	status.StorageAvailable = getStorageQuota(&account)
+	status.StorageUsed = account.StorageUsed
 	status.Disabled = account.Disabled
 	status.ForwardingAddress = account.Forward
 	status.Searchable = account.Searchable
+	status.Bot = account.Bot
   status.MFAEnabled = account.MFAEnabled && account.MFAConfirmed
   status.Sealable = true
   status.EnableIce = getBoolConfigValue(CNFEnableIce, false)
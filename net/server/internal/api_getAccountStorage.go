@@ -0,0 +1,65 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// GetAccountStorage retrieves storage used by account by channel and asset type
+func GetAccountStorage(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	// usage by channel
+	var channels []struct {
+		ChannelSlotID string
+		Size          int64
+	}
+	if err := store.DB.Model(&store.Asset{}).Select("channel_slots.channel_slot_id, SUM(assets.size) AS size").
+		Joins("JOIN channel_slots ON channel_slots.channel_id = assets.channel_id AND channel_slots.account_id = assets.account_id").
+		Where("assets.account_id = ?", account.ID).Group("channel_slots.channel_slot_id").Order("size desc").Scan(&channels).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// usage by top level media type
+	var types []struct {
+		MimeType string
+		Size     int64
+	}
+	if err := store.DB.Model(&store.Asset{}).Select("mime_type, SUM(size) AS size").
+		Where("account_id = ?", account.ID).Group("mime_type").Scan(&types).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	sizes := make(map[string]int64)
+	for _, entry := range types {
+		kind, _, _ := strings.Cut(entry.MimeType, "/")
+		if kind == "" {
+			kind = APPStorageOtherType
+		}
+		sizes[kind] += entry.Size
+	}
+
+	storage := &AccountStorage{
+		Used:     account.StorageUsed,
+		Quota:    getStorageQuota(account),
+		Channels: []ChannelStorage{},
+		Types:    []AssetTypeStorage{},
+	}
+	for _, channel := range channels {
+		storage.Channels = append(storage.Channels, ChannelStorage{ChannelID: channel.ChannelSlotID, Size: channel.Size})
+	}
+	for kind, size := range sizes {
+		storage.Types = append(storage.Types, AssetTypeStorage{Type: kind, Size: size})
+	}
+	sort.Slice(storage.Types, func(i, j int) bool { return storage.Types[i].Size > storage.Types[j].Size })
+
+	WriteResponse(w, storage)
+}
@@ -0,0 +1,52 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+//GetAccountWebhookDeliveries retrieves delivery log of account webhook
+func GetAccountWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	// scan parameters
+	params := mux.Vars(r)
+	webhookID := params["webhookID"]
+
+	var webhook store.Webhook
+	if err := store.DB.Where("account_id = ? AND webhook_id = ?", account.ID, webhookID).First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrResponse(w, http.StatusNotFound, err)
+		} else {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	// filter by status if specified
+	query := store.DB.Where("webhook_id = ?", webhook.ID)
+	if status := r.FormValue("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []store.WebhookDelivery
+	if err := query.Order("created desc").Find(&deliveries).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := []*WebhookDelivery{}
+	for _, delivery := range deliveries {
+		response = append(response, getWebhookDeliveryModel(&delivery))
+	}
+
+	WriteResponse(w, response)
+}
@@ -0,0 +1,29 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"net/http"
+)
+
+//GetAccountWebhooks retrieves webhooks subscribed to account events
+func GetAccountWebhooks(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var webhooks []store.Webhook
+	if err := store.DB.Where("account_id = ?", account.ID).Find(&webhooks).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := []*Webhook{}
+	for _, webhook := range webhooks {
+		response = append(response, getWebhookModel(&webhook, false))
+	}
+
+	WriteResponse(w, response)
+}
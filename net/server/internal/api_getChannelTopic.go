@@ -15,7 +15,7 @@ func GetChannelTopic(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	topicID := params["topicID"]
 
-	channelSlot, _, code, err := getChannelSlot(r, false)
+	channelSlot, guid, code, err := getChannelSlot(r, false)
 	if err != nil {
 		ErrResponse(w, code, err)
 		return
@@ -23,7 +23,7 @@ func GetChannelTopic(w http.ResponseWriter, r *http.Request) {
 
 	// load topic
 	var topicSlot store.TopicSlot
-	if err = store.DB.Preload("Topic.Assets").Where("channel_id = ? AND topic_slot_id = ?", channelSlot.Channel.ID, topicID).First(&topicSlot).Error; err != nil {
+	if err = store.DB.Preload("Topic.Assets").Preload("Topic.Reactions").Where("channel_id = ? AND topic_slot_id = ?", channelSlot.Channel.ID, topicID).First(&topicSlot).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			code = http.StatusNotFound
 		} else {
@@ -32,6 +32,9 @@ func GetChannelTopic(w http.ResponseWriter, r *http.Request) {
 		ErrResponse(w, code, err)
 		return
 	}
+	if topicSlot.Topic != nil && isTopicHidden(topicSlot.Topic, guid) {
+		topicSlot.Topic = nil
+	}
 
 	WriteResponse(w, getTopicModel(&topicSlot))
 }
@@ -45,6 +48,30 @@ func isMember(guid string, members []store.Member) bool {
 	return false
 }
 
+// getMemberRole determines role of guid in channel, group viewers hold the default poster role and others none
+func getMemberRole(guid string, slot *store.ChannelSlot) string {
+	if guid == slot.Account.GUID {
+		return APPChannelOwner
+	}
+	for _, member := range slot.Channel.Members {
+		if guid == member.Card.GUID {
+			if member.Role == "" {
+				return APPChannelPoster
+			}
+			return member.Role
+		}
+	}
+	if isViewer(guid, slot.Channel.Groups) {
+		return APPChannelPoster
+	}
+	return ""
+}
+
+// canPost whether role allows adding content to channel
+func canPost(role string) bool {
+	return role == APPChannelOwner || role == APPChannelAdmin || role == APPChannelPoster
+}
+
 func isViewer(guid string, groups []store.Group) bool {
 	for _, group := range groups {
 		for _, card := range group.Cards {
@@ -56,7 +83,33 @@ func isViewer(guid string, groups []store.Group) bool {
 	return false
 }
 
+// getManagedChannel retrieves account hosting channel for its owner or an admin member
+func getManagedChannel(r *http.Request) (account *store.Account, role string, code int, err error) {
+	var slot store.ChannelSlot
+	var guid string
+	if slot, guid, code, err = getChannelSlot(r, true); err != nil {
+		return
+	}
+	role = getMemberRole(guid, &slot)
+	if role != APPChannelOwner && role != APPChannelAdmin {
+		err = errors.New("not channel owner or admin")
+		code = http.StatusForbidden
+		return
+	}
+	account = &slot.Account
+	return
+}
+
 func getChannelSlot(r *http.Request, member bool) (slot store.ChannelSlot, guid string, code int, err error) {
+	return loadChannelSlot(r, member, false)
+}
+
+// getPosterChannelSlot retrieves channel for posting, where bot api keys are accepted in place of sessions
+func getPosterChannelSlot(r *http.Request, member bool) (slot store.ChannelSlot, guid string, code int, err error) {
+	return loadChannelSlot(r, member, true)
+}
+
+func loadChannelSlot(r *http.Request, member bool, poster bool) (slot store.ChannelSlot, guid string, code int, err error) {
 
 	// scan parameters
 	params := mux.Vars(r)
@@ -66,11 +119,22 @@ func getChannelSlot(r *http.Request, member bool) (slot store.ChannelSlot, guid
 	var account *store.Account
 	tokenType := ParamTokenType(r)
 	if tokenType == APPTokenAgent {
-		account, code, err = ParamAgentToken(r, false)
+		account, code, err = paramAgentToken(r, false, poster)
 		if err != nil {
 			return
 		}
 		guid = account.GUID
+
+		// posting to a contact's channel through the account's card
+		if cardID := r.FormValue("card"); poster && cardID != "" {
+			var card *store.Card
+			card, code, err = ParamCardContact(account, cardID, true)
+			if err != nil {
+				return
+			}
+			account = &card.Account
+			tokenType = APPTokenContact
+		}
 	} else if tokenType == APPTokenContact {
 		var card *store.Card
 		card, code, err = ParamContactToken(r, true)
@@ -18,7 +18,7 @@ func GetChannelTopicAsset(w http.ResponseWriter, r *http.Request) {
 	topicID := params["topicID"]
 	assetID := params["assetID"]
 
-	channelSlot, _, code, err := getChannelSlot(r, true)
+	channelSlot, guid, code, err := getChannelSlot(r, true)
 	if err != nil {
 		ErrResponse(w, code, err)
 		return
@@ -35,7 +35,7 @@ func GetChannelTopicAsset(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	if asset.Topic.TopicSlot.TopicSlotID != topicID {
+	if asset.Topic.TopicSlot.TopicSlotID != topicID || isTopicHidden(asset.Topic, guid) {
 		ErrResponse(w, http.StatusNotFound, errors.New("invalid topic asset"))
 		return
 	}
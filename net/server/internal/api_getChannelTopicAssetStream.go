@@ -0,0 +1,108 @@
+package databag
+
+import (
+	"bytes"
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var streamFile = regexp.MustCompile(`^[a-zA-Z0-9_]+\.(m3u8|ts)$`)
+var streamURI = regexp.MustCompile(`URI="([^"]*)"`)
+
+// GetChannelTopicAssetStream retrieves playlist or segment of stream asset added to specified topic
+func GetChannelTopicAssetStream(w http.ResponseWriter, r *http.Request) {
+
+	// scan parameters
+	params := mux.Vars(r)
+	topicID := params["topicID"]
+	assetID := params["assetID"]
+	file := params["file"]
+
+	if !streamFile.MatchString(file) {
+		ErrResponse(w, http.StatusNotFound, errors.New("invalid stream file"))
+		return
+	}
+
+	channelSlot, guid, code, err := getChannelSlot(r, true)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+	act := &channelSlot.Account
+
+	// load asset
+	var asset store.Asset
+	if err = store.DB.Preload("Topic.TopicSlot").Where("channel_id = ? AND asset_id = ?", channelSlot.Channel.ID, assetID).First(&asset).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrResponse(w, http.StatusNotFound, err)
+		} else {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if asset.Topic.TopicSlot.TopicSlotID != topicID || isTopicHidden(asset.Topic, guid) {
+		ErrResponse(w, http.StatusNotFound, errors.New("invalid topic asset"))
+		return
+	}
+	if asset.Transform != APPStreamTransform || asset.Status != APPAssetReady {
+		ErrResponse(w, http.StatusNotFound, errors.New("stream not available"))
+		return
+	}
+
+	// construct file path with path traversal protection
+	basePath := getStrConfigValue(CNFAssetPath, APPDefaultPath)
+	expectedPath := filepath.Clean(basePath + "/" + act.GUID + "/" + asset.AssetID + APPStreamSuffix + "/" + file)
+	if !strings.HasPrefix(expectedPath, filepath.Clean(basePath)) {
+		ErrResponse(w, http.StatusForbidden, errors.New("invalid path"))
+		return
+	}
+
+	if strings.HasSuffix(file, ".ts") {
+		w.Header().Set("Content-Type", "video/mp2t")
+		http.ServeFile(w, r, expectedPath)
+		return
+	}
+
+	// playlists reference files relatively, so carry query token along to each
+	data, err := os.ReadFile(expectedPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			ErrResponse(w, http.StatusNotFound, err)
+		} else {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	http.ServeContent(w, r, file, time.Unix(asset.Updated, 0), bytes.NewReader(setStreamQuery(data, r.URL.RawQuery)))
+}
+
+// setStreamQuery appends query to each uri referenced by playlist
+func setStreamQuery(playlist []byte, query string) []byte {
+	if query == "" {
+		return playlist
+	}
+	lines := bytes.Split(playlist, []byte("\n"))
+	for i, line := range lines {
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) == 0 {
+			continue
+		}
+		if trimmed[0] != '#' {
+			lines[i] = append(append(trimmed, '?'), query...)
+		} else {
+			lines[i] = streamURI.ReplaceAllFunc(line, func(uri []byte) []byte {
+				return append(append(uri[:len(uri)-1:len(uri)-1], '?'), query+`"`...)
+			})
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
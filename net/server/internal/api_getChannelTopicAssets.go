@@ -31,7 +31,7 @@ func GetChannelTopicAssets(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	if topicSlot.Topic == nil {
+	if topicSlot.Topic == nil || isTopicHidden(topicSlot.Topic, guid) {
 		ErrResponse(w, http.StatusNotFound, errors.New("referenced empty topic"))
 		return
 	}
@@ -45,7 +45,16 @@ func GetChannelTopicAssets(w http.ResponseWriter, r *http.Request) {
 	// return list of assets
 	assets := []Asset{}
 	for _, asset := range topicSlot.Topic.Assets {
-		assets = append(assets, Asset{AssetID: asset.AssetID, Status: asset.Status})
+		assets = append(assets, Asset{
+			AssetID:  asset.AssetID,
+			Status:   asset.Status,
+			Preview:  asset.Preview,
+			MimeType: asset.MimeType,
+			Width:    asset.Width,
+			Height:   asset.Height,
+			Duration: asset.Duration,
+			Error:    asset.TransformError,
+		})
 	}
 	WriteResponse(w, &assets)
 }
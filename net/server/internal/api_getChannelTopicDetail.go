@@ -16,12 +16,6 @@ func GetChannelTopicDetail(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	topicID := params["topicID"]
 
-	var subject Subject
-	if err := ParseRequest(r, w, &subject); err != nil {
-		ErrResponse(w, http.StatusBadRequest, err)
-		return
-	}
-
 	channelSlot, _, code, err := getChannelSlot(r, false)
 	if err != nil {
 		ErrResponse(w, code, err)
@@ -30,7 +24,7 @@ func GetChannelTopicDetail(w http.ResponseWriter, r *http.Request) {
 
 	// load topic
 	var topicSlot store.TopicSlot
-	if err = store.DB.Where("channel_id = ? AND topic_slot_id = ?", channelSlot.Channel.ID, topicID).First(&topicSlot).Error; err != nil {
+	if err = store.DB.Preload("Topic.Assets").Preload("Topic.Reactions").Where("channel_id = ? AND topic_slot_id = ?", channelSlot.Channel.ID, topicID).First(&topicSlot).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			code = http.StatusNotFound
 		} else {
@@ -47,34 +41,17 @@ func GetChannelTopicDetail(w http.ResponseWriter, r *http.Request) {
 
 func getTopicDetailModelWithReadStatus(slot *store.TopicSlot, account *store.Account) *TopicDetail {
 
-	if slot.Topic == nil {
+	detail := getTopicDetailModel(slot)
+	if detail == nil {
 		return nil
 	}
 
-	transform := APPTransformComplete
-	for _, asset := range slot.Topic.Assets {
-		if asset.Status == APPAssetError {
-			transform = APPTransformError
-		} else if asset.Status == APPAssetWaiting && transform == APPTransformComplete {
-			transform = APPTransformIncomplete
-		}
-	}
-
 	// query if current user has read this topic
 	var topicRead store.TopicRead
 	err := store.DB.Where("topic_id = ? AND account_id = ?", slot.Topic.ID, account.ID).First(&topicRead).Error
-	readByMe := (err == nil && topicRead.ReadTime > 0)
+	detail.ReadByMe = (err == nil && topicRead.ReadTime > 0)
 
-	LogMsg(fmt.Sprintf("[ReadReceipt] topicId=%s, accountId=%d, readByMe=%v, err=%v", slot.Topic.TopicSlotID, account.ID, readByMe, err))
+	LogMsg(fmt.Sprintf("[ReadReceipt] topicId=%s, accountId=%d, readByMe=%v, err=%v", slot.Topic.TopicSlotID, account.ID, detail.ReadByMe, err))
 
-	return &TopicDetail{
-		GUID:      slot.Topic.GUID,
-		DataType:  slot.Topic.DataType,
-		Data:      slot.Topic.Data,
-		Created:   slot.Topic.Created,
-		Updated:   slot.Topic.Updated,
-		Status:    slot.Topic.Status,
-		Transform: transform,
-		ReadByMe:  readByMe,
-	}
+	return detail
 }
@@ -0,0 +1,45 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+//GetChannelTopicReactions retrieves reactions of contacts to topic
+func GetChannelTopicReactions(w http.ResponseWriter, r *http.Request) {
+
+	// scan parameters
+	params := mux.Vars(r)
+	topicID := params["topicID"]
+
+	channelSlot, guid, code, err := getChannelSlot(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	// load topic
+	var topicSlot store.TopicSlot
+	if err = store.DB.Preload("Topic.Reactions").Where("channel_id = ? AND topic_slot_id = ?", channelSlot.Channel.ID, topicID).First(&topicSlot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrResponse(w, http.StatusNotFound, err)
+		} else {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if topicSlot.Topic == nil || isTopicHidden(topicSlot.Topic, guid) {
+		ErrResponse(w, http.StatusNotFound, errors.New("referenced empty topic"))
+		return
+	}
+
+	response := []*Reaction{}
+	for _, reaction := range topicSlot.Topic.Reactions {
+		response = append(response, getReactionModel(&reaction))
+	}
+
+	WriteResponse(w, response)
+}
@@ -0,0 +1,85 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+//GetChannelTopicReplies retrieves replies threaded under topic
+func GetChannelTopicReplies(w http.ResponseWriter, r *http.Request) {
+	var revisionSet bool
+	var revision int64
+
+	channelSlot, guid, code, err := getChannelSlot(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	// scan parameters
+	params := mux.Vars(r)
+	topicID := params["topicID"]
+
+	rev := r.FormValue("revision")
+	if rev != "" {
+		revisionSet = true
+		if revision, err = strconv.ParseInt(rev, 10, 64); err != nil {
+			ErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	// load thread parent
+	var parentSlot store.TopicSlot
+	if err = store.DB.Preload("Topic").Where("channel_id = ? AND topic_slot_id = ?", channelSlot.Channel.ID, topicID).First(&parentSlot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrResponse(w, http.StatusNotFound, err)
+		} else {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if parentSlot.ParentID != 0 {
+		ErrResponse(w, http.StatusBadRequest, errors.New("topic is a reply"))
+		return
+	}
+	if parentSlot.Topic != nil && isTopicHidden(parentSlot.Topic, guid) {
+		ErrResponse(w, http.StatusNotFound, errors.New("referenced empty topic"))
+		return
+	}
+
+	response := []*Topic{}
+	if revisionSet {
+		var slots []store.TopicSlot
+		if err := store.DB.Preload("Topic").Where("channel_id = ? AND parent_id = ? AND revision > ?", channelSlot.Channel.ID, parentSlot.ID, revision).Find(&slots).Error; err != nil {
+			ErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		for _, slot := range slots {
+			// scheduled replies are not yet known to other members
+			if slot.Topic != nil && isTopicHidden(slot.Topic, guid) {
+				continue
+			}
+			response = append(response, getTopicRevisionModel(&slot))
+		}
+	} else {
+		var slots []store.TopicSlot
+		if err := store.DB.Preload("Topic.Assets").Preload("Topic.Reactions").Where("channel_id = ? AND parent_id = ?", channelSlot.Channel.ID, parentSlot.ID).Order("id").Find(&slots).Error; err != nil {
+			ErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		for _, slot := range slots {
+			if slot.Topic != nil && !isTopicHidden(slot.Topic, guid) {
+				response = append(response, getTopicModel(&slot))
+			}
+		}
+	}
+
+	w.Header().Set("topic-revision", strconv.FormatInt(channelSlot.Revision, 10))
+	w.Header().Set("Access-Control-Expose-Headers", "*")
+	WriteResponse(w, response)
+}
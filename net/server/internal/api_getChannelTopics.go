@@ -2,6 +2,7 @@ package databag
 
 import (
 	"databag/internal/store"
+	"gorm.io/gorm"
 	"net/http"
 	"strconv"
 )
@@ -14,7 +15,7 @@ func reverseTopics(input []store.TopicSlot) []store.TopicSlot {
 	return output
 }
 
-//GetChannelTopics retrieves topics associated with channel
+//GetChannelTopics retrieves topics associated with channel, optionally only thread roots
 func GetChannelTopics(w http.ResponseWriter, r *http.Request) {
 	var revisionSet bool
 	var revision int64
@@ -25,12 +26,21 @@ func GetChannelTopics(w http.ResponseWriter, r *http.Request) {
 	var countSet bool
 	var count int
 
-	channelSlot, _, code, err := getChannelSlot(r, false)
+	channelSlot, guid, code, err := getChannelSlot(r, false)
 	if err != nil {
 		ErrResponse(w, code, err)
 		return
 	}
 
+	// thread aware clients may exclude replies from channel listing
+	root := r.FormValue("root") == "true"
+	threadScope := func(db *gorm.DB) *gorm.DB {
+		if root {
+			return db.Where("parent_id = ?", 0)
+		}
+		return db
+	}
+
 	rev := r.FormValue("revision")
 	if rev != "" {
 		revisionSet = true
@@ -71,67 +81,70 @@ func GetChannelTopics(w http.ResponseWriter, r *http.Request) {
 	if revisionSet {
 		var slots []store.TopicSlot
 		if beginSet && !endSet {
-			if err := store.DB.Preload("Topic").Where("channel_id = ? AND revision > ? AND id >= ?", channelSlot.Channel.ID, revision, begin).Find(&slots).Error; err != nil {
+			if err := store.DB.Scopes(threadScope).Preload("Topic").Where("channel_id = ? AND revision > ? AND id >= ?", channelSlot.Channel.ID, revision, begin).Find(&slots).Error; err != nil {
 				ErrResponse(w, http.StatusInternalServerError, err)
 				return
 			}
 		} else if !beginSet && endSet {
-			if err := store.DB.Preload("Topic").Where("channel_id = ? AND revision > ? AND id < ?", channelSlot.Channel.ID, revision, end).Find(&slots).Error; err != nil {
+			if err := store.DB.Scopes(threadScope).Preload("Topic").Where("channel_id = ? AND revision > ? AND id < ?", channelSlot.Channel.ID, revision, end).Find(&slots).Error; err != nil {
 				ErrResponse(w, http.StatusInternalServerError, err)
 				return
 			}
 		} else if beginSet && endSet {
-			if err := store.DB.Preload("Topic").Where("channel_id = ? AND revision > ? AND id >= ? AND id < ?", channelSlot.Channel.ID, revision, begin, end).Find(&slots).Error; err != nil {
+			if err := store.DB.Scopes(threadScope).Preload("Topic").Where("channel_id = ? AND revision > ? AND id >= ? AND id < ?", channelSlot.Channel.ID, revision, begin, end).Find(&slots).Error; err != nil {
 				ErrResponse(w, http.StatusInternalServerError, err)
 				return
 			}
 		} else {
-			if err := store.DB.Preload("Topic").Where("channel_id = ? AND revision > ?", channelSlot.Channel.ID, revision).Find(&slots).Error; err != nil {
+			if err := store.DB.Scopes(threadScope).Preload("Topic").Where("channel_id = ? AND revision > ?", channelSlot.Channel.ID, revision).Find(&slots).Error; err != nil {
 				ErrResponse(w, http.StatusInternalServerError, err)
 				return
 			}
 		}
 		for _, slot := range slots {
+			if slot.Topic != nil && isTopicHidden(slot.Topic, guid) {
+				slot.Topic = nil
+			}
 			response = append(response, getTopicRevisionModel(&slot))
 		}
 	} else {
 		var slots []store.TopicSlot
 		if countSet {
 			if !endSet {
-				if err := store.DB.Preload("Topic.Assets").Where("channel_id = ?", channelSlot.Channel.ID).Order("id desc").Limit(count).Find(&slots).Error; err != nil {
+				if err := store.DB.Scopes(threadScope).Preload("Topic.Assets").Preload("Topic.Reactions").Where("channel_id = ?", channelSlot.Channel.ID).Order("id desc").Limit(count).Find(&slots).Error; err != nil {
 					ErrResponse(w, http.StatusInternalServerError, err)
 					return
 				}
 			} else {
-				if err := store.DB.Preload("Topic.Assets").Where("channel_id = ? AND id < ?", channelSlot.Channel.ID, end).Order("id desc").Limit(count).Find(&slots).Error; err != nil {
+				if err := store.DB.Scopes(threadScope).Preload("Topic.Assets").Preload("Topic.Reactions").Where("channel_id = ? AND id < ?", channelSlot.Channel.ID, end).Order("id desc").Limit(count).Find(&slots).Error; err != nil {
 					ErrResponse(w, http.StatusInternalServerError, err)
 					return
 				}
 			}
 			slots = reverseTopics(slots)
 		} else if beginSet && !endSet {
-			if err := store.DB.Preload("Topic.Assets").Where("channel_id = ? AND id >= ?", channelSlot.Channel.ID, begin).Find(&slots).Error; err != nil {
+			if err := store.DB.Scopes(threadScope).Preload("Topic.Assets").Preload("Topic.Reactions").Where("channel_id = ? AND id >= ?", channelSlot.Channel.ID, begin).Find(&slots).Error; err != nil {
 				ErrResponse(w, http.StatusInternalServerError, err)
 				return
 			}
 		} else if !beginSet && endSet {
-			if err := store.DB.Preload("Topic.Assets").Where("channel_id = ? AND id < ?", channelSlot.Channel.ID, end).Find(&slots).Error; err != nil {
+			if err := store.DB.Scopes(threadScope).Preload("Topic.Assets").Preload("Topic.Reactions").Where("channel_id = ? AND id < ?", channelSlot.Channel.ID, end).Find(&slots).Error; err != nil {
 				ErrResponse(w, http.StatusInternalServerError, err)
 				return
 			}
 		} else if beginSet && endSet {
-			if err := store.DB.Preload("Topic.Assets").Where("channel_id = ? AND id >= ? AND id < ?", channelSlot.Channel.ID, begin, end).Find(&slots).Error; err != nil {
+			if err := store.DB.Scopes(threadScope).Preload("Topic.Assets").Preload("Topic.Reactions").Where("channel_id = ? AND id >= ? AND id < ?", channelSlot.Channel.ID, begin, end).Find(&slots).Error; err != nil {
 				ErrResponse(w, http.StatusInternalServerError, err)
 				return
 			}
 		} else {
-			if err := store.DB.Preload("Topic.Assets").Where("channel_id = ?", channelSlot.Channel.ID).Find(&slots).Error; err != nil {
+			if err := store.DB.Scopes(threadScope).Preload("Topic.Assets").Preload("Topic.Reactions").Where("channel_id = ?", channelSlot.Channel.ID).Find(&slots).Error; err != nil {
 				ErrResponse(w, http.StatusInternalServerError, err)
 				return
 			}
 		}
 		for _, slot := range slots {
-			if slot.Topic != nil {
+			if slot.Topic != nil && !isTopicHidden(slot.Topic, guid) {
 				if countSet {
 					w.Header().Set("topic-marker", strconv.FormatUint(uint64(slot.ID), 10))
 					countSet = false
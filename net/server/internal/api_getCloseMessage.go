@@ -43,7 +43,7 @@ func GetCloseMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	msg, err := WriteDataMessage(detail.PrivateKey, detail.PublicKey, detail.KeyType,
-		APPSignPKCS1V15, account.GUID, APPMsgDisconnect, &disconnect)
+		getSignType(detail.KeyType), account.GUID, APPMsgDisconnect, &disconnect)
 	if err != nil {
 		ErrResponse(w, http.StatusInternalServerError, err)
 		return
@@ -0,0 +1,10 @@
+package databag
+
+import (
+	"net/http"
+)
+
+//GetFederationAllow retrieve node patterns in federation allow list
+func GetFederationAllow(w http.ResponseWriter, r *http.Request) {
+	getNodeRules(w, r, APPNodeAllow)
+}
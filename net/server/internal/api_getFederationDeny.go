@@ -0,0 +1,10 @@
+package databag
+
+import (
+	"net/http"
+)
+
+//GetFederationDeny retrieve node patterns in federation deny list
+func GetFederationDeny(w http.ResponseWriter, r *http.Request) {
+	getNodeRules(w, r, APPNodeDeny)
+}
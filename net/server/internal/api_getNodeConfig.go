@@ -32,6 +32,17 @@ func GetNodeConfig(w http.ResponseWriter, r *http.Request) {
   config.EnableOpenAccess = getBoolConfigValue(CNFEnableOpenAccess, false);
   config.OpenAccessLimit = getNumConfigValue(CNFOpenAccessLimit, 0);
   config.TransformSupported = getStrConfigValue(CNFScriptPath, "") != "";
+  config.APIHost = getStrConfigValue(CNFAPIHost, "");
+  config.ScriptTransform = getBoolConfigValue(CNFScriptTransform, false);
+  config.VideoWorkers = getNumConfigValue(CNFVideoWorkers, APPTranscodeVideoWorkers);
+  config.AudioWorkers = getNumConfigValue(CNFAudioWorkers, APPTranscodeAudioWorkers);
+  config.PhotoWorkers = getNumConfigValue(CNFPhotoWorkers, APPTranscodePhotoWorkers);
+  config.DefaultWorkers = getNumConfigValue(CNFDefaultWorkers, APPTranscodeDefaultWorkers);
+  config.RemoteTranscode = getBoolConfigValue(CNFRemoteTranscode, false);
+  config.ScrubMetadata = getBoolConfigValue(CNFScrubMetadata, false);
+  config.ScanMode = getStrConfigValue(CNFScanMode, "");
+  config.ScanAddress = getStrConfigValue(CNFScanAddress, "");
+  config.WebhookPrivate = getBoolConfigValue(CNFWebhookPrivate, false);
 
 	WriteResponse(w, config)
 }
@@ -0,0 +1,27 @@
+package databag
+
+import (
+	"net/http"
+)
+
+//GetNodeDiscovery retrieve protocol version and capabilities advertised to federated nodes
+func GetNodeDiscovery(w http.ResponseWriter, r *http.Request) {
+
+	discovery := NodeDiscovery{
+		Version:      APPVersion,
+		KeyTypes:     []string{APPRSA2048, APPRSA4096, APPED25519, APPP256},
+		Capabilities: []string{APPCapabilitySuccession},
+		API:          getStrConfigValue(CNFAPIHost, ""),
+	}
+	if getBoolConfigValue(CNFEnableIce, false) {
+		discovery.Capabilities = append(discovery.Capabilities, APPCapabilityCalls)
+	}
+	if getStrConfigValue(CNFScriptPath, "") != "" {
+		discovery.Capabilities = append(discovery.Capabilities, APPCapabilityTransforms)
+	}
+	if IsReadReceiptsEnabled() {
+		discovery.Capabilities = append(discovery.Capabilities, APPCapabilityReadReceipts)
+	}
+
+	WriteResponse(w, discovery)
+}
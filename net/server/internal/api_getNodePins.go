@@ -0,0 +1,27 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"net/http"
+)
+
+//GetNodePins retrieve certificate pins of federated nodes
+func GetNodePins(w http.ResponseWriter, r *http.Request) {
+
+	if code, err := ParamAdminToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var pins []store.NodePin
+	if err := store.DB.Order("node, created").Find(&pins).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := []NodePin{}
+	for _, pin := range pins {
+		response = append(response, NodePin{Node: pin.Node, Pin: pin.Pin, Created: pin.Created})
+	}
+	WriteResponse(w, response)
+}
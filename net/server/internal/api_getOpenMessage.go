@@ -49,6 +49,7 @@ func GetOpenMessage(w http.ResponseWriter, r *http.Request) {
 		Name:            detail.Name,
 		Description:     detail.Description,
     Seal:            detail.SealPublic,
+		Bot:             account.Bot,
 		Location:        detail.Location,
 		Image:           detail.Image,
 		Version:         APPVersion,
@@ -56,7 +57,7 @@ func GetOpenMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	msg, err := WriteDataMessage(detail.PrivateKey, detail.PublicKey, detail.KeyType,
-		APPSignPKCS1V15, account.GUID, APPMsgConnect, &connect)
+		getSignType(detail.KeyType), account.GUID, APPMsgConnect, &connect)
 	if err != nil {
 		ErrResponse(w, http.StatusInternalServerError, err)
 		return
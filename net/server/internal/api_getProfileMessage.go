@@ -21,7 +21,7 @@ func GetProfileMessage(w http.ResponseWriter, r *http.Request) {
 		}
 	} else if tokenType == APPTokenContact {
 		var card *store.Card
-		if card, code, err = ParamContactToken(r, true); err != nil {
+		if card, code, err = paramContactToken(r, true, false); err != nil {
 			ErrResponse(w, code, err)
 			return
 		}
@@ -43,9 +43,10 @@ func GetProfileMessage(w http.ResponseWriter, r *http.Request) {
 		Version:     APPVersion,
 		Node:        getStrConfigValue(CNFDomain, ""),
     Seal:        detail.SealPublic,
+		Bot:         account.Bot,
 	}
 	msg, res := WriteDataMessage(detail.PrivateKey, detail.PublicKey, detail.KeyType,
-		APPSignPKCS1V15, account.GUID, APPMsgIdentity, &identity)
+		getSignType(detail.KeyType), account.GUID, APPMsgIdentity, &identity)
 	if res != nil {
 		ErrResponse(w, http.StatusInternalServerError, res)
 		return
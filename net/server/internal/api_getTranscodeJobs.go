@@ -0,0 +1,39 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"net/http"
+)
+
+//GetTranscodeJobs retrieve running and failed asset transforms
+func GetTranscodeJobs(w http.ResponseWriter, r *http.Request) {
+
+	if code, err := ParamAdminToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var assets []store.Asset
+	if err := store.DB.Preload("Account").Where("transform != '' AND status IN ?", []string{APPAssetProcessing, APPAssetError}).
+		Order("updated desc").Limit(APPTranscodeJobLimit).Find(&assets).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := []TranscodeJob{}
+	for _, asset := range assets {
+		response = append(response, TranscodeJob{
+			AssetID:   asset.AssetID,
+			GUID:      asset.Account.GUID,
+			Transform: asset.Transform,
+			Queue:     asset.TransformQueue,
+			Status:    asset.Status,
+			Progress:  asset.TransformProgress,
+			Started:   asset.TransformStarted,
+			Error:     asset.TransformError,
+			Worker:    asset.TransformWorker,
+			Updated:   asset.Updated,
+		})
+	}
+	WriteResponse(w, response)
+}
@@ -0,0 +1,25 @@
+package databag
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+//GetTranscodeLeaseSource downloads source asset of leased transform
+func GetTranscodeLeaseSource(w http.ResponseWriter, r *http.Request) {
+
+	worker, code, err := ParamWorkerToken(r)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	asset, code, err := getLeasedAsset(worker, mux.Vars(r)["leaseID"])
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	path := getStrConfigValue(CNFAssetPath, APPDefaultPath) + "/" + asset.Account.GUID + "/" + asset.TransformID
+	http.ServeFile(w, r, path)
+}
@@ -0,0 +1,27 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"net/http"
+)
+
+//GetTranscodeWorkers retrieve registered remote transcode workers
+func GetTranscodeWorkers(w http.ResponseWriter, r *http.Request) {
+
+	if code, err := ParamAdminToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var workers []store.TranscodeWorker
+	if err := store.DB.Order("created").Find(&workers).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := []TranscodeWorker{}
+	for _, worker := range workers {
+		response = append(response, *getTranscodeWorkerModel(&worker))
+	}
+	WriteResponse(w, response)
+}
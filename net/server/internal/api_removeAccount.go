@@ -20,6 +20,9 @@ func RemoveAccount(w http.ResponseWriter, r *http.Request) {
 		if res := tx.Where("account_id = ?", account.ID).Delete(&store.Tag{}).Error; res != nil {
 			return res
 		}
+		if res := tx.Where("account_id = ?", account.ID).Delete(&store.Reaction{}).Error; res != nil {
+			return res
+		}
 		if res := tx.Where("account_id = ?", account.ID).Delete(&store.TagSlot{}).Error; res != nil {
 			return res
 		}
@@ -65,6 +68,21 @@ func RemoveAccount(w http.ResponseWriter, r *http.Request) {
 		if res := tx.Where("account_id = ?", account.ID).Delete(&store.AccountToken{}).Error; res != nil {
 			return res
 		}
+		if res := tx.Where("account_id = ?", account.ID).Delete(&store.WebhookDelivery{}).Error; res != nil {
+			return res
+		}
+		if res := tx.Where("account_id = ?", account.ID).Delete(&store.Webhook{}).Error; res != nil {
+			return res
+		}
+		if res := tx.Where("account_id = ?", account.GUID).Delete(&store.ApiKey{}).Error; res != nil {
+			return res
+		}
+		if res := tx.Where("account_id = ?", account.ID).Delete(&store.Succession{}).Error; res != nil {
+			return res
+		}
+		if res := tx.Where("account_id = ?", account.ID).Delete(&store.ContactBlock{}).Error; res != nil {
+			return res
+		}
 		if res := tx.Delete(&store.AccountDetail{}, account.AccountDetailID).Error; res != nil {
 			return res
 		}
@@ -0,0 +1,40 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+//RemoveAccountContactBlock accepts contact requests from a blocked sender again
+func RemoveAccountContactBlock(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	// scan parameters
+	params := mux.Vars(r)
+	blockID := params["blockID"]
+
+	var block store.ContactBlock
+	if err := store.DB.Where("account_id = ? AND block_id = ?", account.ID, blockID).First(&block).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrResponse(w, http.StatusNotFound, err)
+		} else {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if err := store.DB.Delete(&block).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	WriteResponse(w, nil)
+}
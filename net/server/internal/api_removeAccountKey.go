@@ -0,0 +1,40 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+//RemoveAccountKey revokes api key of bot account
+func RemoveAccountKey(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	// scan parameters
+	params := mux.Vars(r)
+	keyID := params["keyID"]
+
+	var key store.ApiKey
+	if err := store.DB.Where("account_id = ? AND api_key_id = ?", account.GUID, keyID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrResponse(w, http.StatusNotFound, err)
+		} else {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if err := store.DB.Delete(&key).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	WriteResponse(w, nil)
+}
@@ -0,0 +1,49 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+//RemoveAccountWebhook removes webhook and its delivery log from account
+func RemoveAccountWebhook(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	// scan parameters
+	params := mux.Vars(r)
+	webhookID := params["webhookID"]
+
+	var webhook store.Webhook
+	if err := store.DB.Where("account_id = ? AND webhook_id = ?", account.ID, webhookID).First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrResponse(w, http.StatusNotFound, err)
+		} else {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	err = store.DB.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Where("webhook_id = ?", webhook.ID).Delete(&store.WebhookDelivery{}).Error; res != nil {
+			return res
+		}
+		if res := tx.Delete(&webhook).Error; res != nil {
+			return res
+		}
+		return nil
+	})
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	WriteResponse(w, nil)
+}
@@ -68,6 +68,7 @@ func RemoveChannel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if contact == nil {
+		channelID := slot.Channel.ID
 		err = store.DB.Transaction(func(tx *gorm.DB) error {
 			if res := tx.Model(&slot.Channel).Association("Groups").Clear(); res != nil {
 				return res
@@ -80,10 +81,13 @@ func RemoveChannel(w http.ResponseWriter, r *http.Request) {
 			if res := tx.Where("channel_id = ?", slot.Channel.ID).Delete(&store.Tag{}).Error; res != nil {
 				return res
 			}
+			if res := tx.Where("channel_id = ?", slot.Channel.ID).Delete(&store.Reaction{}).Error; res != nil {
+				return res
+			}
 			if res := tx.Where("channel_id = ?", slot.Channel.ID).Delete(&store.TagSlot{}).Error; res != nil {
 				return res
 			}
-			if res := tx.Where("channel_id = ?", slot.Channel.ID).Delete(&store.Asset{}).Error; res != nil {
+			if res := removeAssets(tx, "channel_id = ?", slot.Channel.ID); res != nil {
 				return res
 			}
 			if res := tx.Where("channel_id = ?", slot.Channel.ID).Delete(&store.Topic{}).Error; res != nil {
@@ -113,7 +117,8 @@ func RemoveChannel(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// cleanup file assets
+		// stop pending transforms and cleanup file assets
+		cancelChannelTranscode(channelID)
 		go garbageCollect(account)
 	} else {
 		if _, member := cards[contact.GUID]; !member {
@@ -42,39 +42,18 @@ func RemoveChannelTopic(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check permission
-	if act.GUID != guid && topicSlot.Topic.GUID != guid {
-		ErrResponse(w, http.StatusUnauthorized, errors.New("not creator of topic or host"))
+	role := getMemberRole(guid, &channelSlot)
+	if role == APPChannelReader {
+		ErrResponse(w, http.StatusForbidden, errors.New("member is read-only"))
+		return
+	}
+	if topicSlot.Topic.GUID != guid && role != APPChannelOwner && role != APPChannelAdmin {
+		ErrResponse(w, http.StatusUnauthorized, errors.New("not creator of topic or moderator"))
 		return
 	}
 
 	err = store.DB.Transaction(func(tx *gorm.DB) error {
-
-		if res := tx.Where("topic_id = ?", topicSlot.Topic.ID).Delete(&store.Tag{}).Error; res != nil {
-			return res
-		}
-		if res := tx.Where("topic_id = ?", topicSlot.Topic.ID).Delete(&store.TagSlot{}).Error; res != nil {
-			return res
-		}
-		if res := tx.Where("topic_id = ?", topicSlot.Topic.ID).Delete(&store.Asset{}).Error; res != nil {
-			return res
-		}
-		if res := tx.Delete(&topicSlot.Topic).Error; res != nil {
-			return res
-		}
-		topicSlot.Topic = nil
-		if res := tx.Model(&topicSlot).Update("revision", act.ChannelRevision+1).Error; res != nil {
-			return res
-		}
-		if res := tx.Model(&channelSlot.Channel).Update("topic_revision", act.ChannelRevision+1).Error; res != nil {
-			return res
-		}
-		if res := tx.Model(&channelSlot).Update("revision", act.ChannelRevision+1).Error; res != nil {
-			return res
-		}
-		if res := tx.Model(act).Update("channel_revision", act.ChannelRevision+1).Error; res != nil {
-			return res
-		}
-		return nil
+		return removeTopicSlot(tx, act, &channelSlot, &topicSlot)
 	})
 	if err != nil {
 		ErrResponse(w, http.StatusInternalServerError, err)
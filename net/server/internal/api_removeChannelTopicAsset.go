@@ -46,7 +46,7 @@ func RemoveChannelTopicAsset(w http.ResponseWriter, r *http.Request) {
 
 	// delete asset record
 	err = store.DB.Transaction(func(tx *gorm.DB) error {
-		if res := tx.Delete(&asset).Error; res != nil {
+		if res := removeAssets(tx, "id = ?", asset.ID); res != nil {
 			return res
 		}
 		if res := tx.Model(&asset.Topic).Update("detail_revision", act.ChannelRevision+1).Error; res != nil {
@@ -71,7 +71,8 @@ func RemoveChannelTopicAsset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// cleanup files from deleted record
+	// stop pending transform and cleanup files from deleted record
+	cancelTranscodeJobs(func(job *transcodeJob) bool { return job.id == asset.ID })
 	go garbageCollect(act)
 
 	// determine affected contact list
@@ -0,0 +1,81 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+//RemoveChannelTopicReaction removes emoji reaction of invoker from topic
+func RemoveChannelTopicReaction(w http.ResponseWriter, r *http.Request) {
+
+	// scan parameters
+	params := mux.Vars(r)
+	topicID := params["topicID"]
+	emoji := params["emoji"]
+
+	channelSlot, guid, code, err := getChannelSlot(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+	act := &channelSlot.Account
+
+	// load topic
+	var topicSlot store.TopicSlot
+	if err = store.DB.Preload("Topic").Where("channel_id = ? AND topic_slot_id = ?", channelSlot.Channel.ID, topicID).First(&topicSlot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrResponse(w, http.StatusNotFound, err)
+		} else {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if topicSlot.Topic == nil {
+		ErrResponse(w, http.StatusNotFound, errors.New("referenced empty topic"))
+		return
+	}
+
+	// load reaction of invoker
+	var reaction store.Reaction
+	if err = store.DB.Where("topic_id = ? AND guid = ? AND emoji = ?", topicSlot.Topic.ID, guid, emoji).First(&reaction).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrResponse(w, http.StatusNotFound, err)
+		} else {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	err = store.DB.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Delete(&reaction).Error; res != nil {
+			return res
+		}
+		return setReactionRevision(tx, act, &channelSlot, &topicSlot)
+	})
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// determine affected contact list
+	cards := make(map[string]store.Card)
+	for _, member := range channelSlot.Channel.Members {
+		cards[member.Card.GUID] = member.Card
+	}
+	for _, group := range channelSlot.Channel.Groups {
+		for _, card := range group.Cards {
+			cards[card.GUID] = card
+		}
+	}
+
+	// notify
+	SetStatus(act)
+	for _, card := range cards {
+		SetContactChannelNotification(act, &card)
+	}
+
+	WriteResponse(w, nil)
+}
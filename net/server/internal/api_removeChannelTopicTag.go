@@ -61,8 +61,13 @@ func RemoveChannelTopicTag(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check permission
-	if act.GUID != guid && topicSlot.Topic.GUID != guid {
-		ErrResponse(w, http.StatusUnauthorized, errors.New("not creator of topic or host"))
+	role := getMemberRole(guid, &channelSlot)
+	if role == APPChannelReader {
+		ErrResponse(w, http.StatusForbidden, errors.New("member is read-only"))
+		return
+	}
+	if topicSlot.Topic.GUID != guid && role != APPChannelOwner && role != APPChannelAdmin {
+		ErrResponse(w, http.StatusUnauthorized, errors.New("not creator of topic or moderator"))
 		return
 	}
 
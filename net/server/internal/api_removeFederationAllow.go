@@ -0,0 +1,10 @@
+package databag
+
+import (
+	"net/http"
+)
+
+//RemoveFederationAllow remove node pattern from federation allow list
+func RemoveFederationAllow(w http.ResponseWriter, r *http.Request) {
+	removeNodeRule(w, r, APPNodeAllow)
+}
@@ -0,0 +1,10 @@
+package databag
+
+import (
+	"net/http"
+)
+
+//RemoveFederationDeny remove node pattern from federation deny list
+func RemoveFederationDeny(w http.ResponseWriter, r *http.Request) {
+	removeNodeRule(w, r, APPNodeDeny)
+}
@@ -40,6 +40,9 @@ func RemoveNodeAccount(w http.ResponseWriter, r *http.Request) {
 		if res := tx.Where("account_id = ?", account.ID).Delete(&store.Tag{}).Error; res != nil {
 			return res
 		}
+		if res := tx.Where("account_id = ?", account.ID).Delete(&store.Reaction{}).Error; res != nil {
+			return res
+		}
 		if res := tx.Where("account_id = ?", account.ID).Delete(&store.TagSlot{}).Error; res != nil {
 			return res
 		}
@@ -85,6 +88,21 @@ func RemoveNodeAccount(w http.ResponseWriter, r *http.Request) {
 		if res := tx.Where("account_id = ?", account.ID).Delete(&store.AccountToken{}).Error; res != nil {
 			return res
 		}
+		if res := tx.Where("account_id = ?", account.ID).Delete(&store.WebhookDelivery{}).Error; res != nil {
+			return res
+		}
+		if res := tx.Where("account_id = ?", account.ID).Delete(&store.Webhook{}).Error; res != nil {
+			return res
+		}
+		if res := tx.Where("account_id = ?", account.GUID).Delete(&store.ApiKey{}).Error; res != nil {
+			return res
+		}
+		if res := tx.Where("account_id = ?", account.ID).Delete(&store.Succession{}).Error; res != nil {
+			return res
+		}
+		if res := tx.Where("account_id = ?", account.ID).Delete(&store.ContactBlock{}).Error; res != nil {
+			return res
+		}
 		if res := tx.Delete(&store.AccountDetail{}, account.AccountDetailID).Error; res != nil {
 			return res
 		}
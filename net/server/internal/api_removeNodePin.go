@@ -0,0 +1,27 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+//RemoveNodePin remove specified or all certificate pins of federated node
+func RemoveNodePin(w http.ResponseWriter, r *http.Request) {
+
+	if code, err := ParamAdminToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	query := store.DB.Where("node = ?", getNodeHost(mux.Vars(r)["node"]))
+	if pin := r.FormValue("pin"); pin != "" {
+		query = query.Where("pin = ?", pin)
+	}
+	if err := query.Delete(&store.NodePin{}).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	WriteResponse(w, nil)
+}
@@ -20,6 +20,9 @@ func RemoveProfile(w http.ResponseWriter, r *http.Request) {
 		if res := tx.Where("account_id = ?", account.ID).Delete(&store.Tag{}).Error; res != nil {
 			return res
 		}
+		if res := tx.Where("account_id = ?", account.ID).Delete(&store.Reaction{}).Error; res != nil {
+			return res
+		}
 		if res := tx.Where("account_id = ?", account.ID).Delete(&store.TagSlot{}).Error; res != nil {
 			return res
 		}
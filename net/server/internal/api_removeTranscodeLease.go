@@ -0,0 +1,33 @@
+package databag
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+//RemoveTranscodeLease reports failure of leased transform leaving asset in error state
+func RemoveTranscodeLease(w http.ResponseWriter, r *http.Request) {
+
+	worker, code, err := ParamWorkerToken(r)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	asset, code, err := getLeasedAsset(worker, mux.Vars(r)["leaseID"])
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	asset.TransformError = r.FormValue("error")
+	if asset.TransformError == "" {
+		asset.TransformError = "transform failed"
+	}
+	if err := endTranscodeLease(asset, APPAssetError, 0, 0); err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	WriteResponse(w, nil)
+}
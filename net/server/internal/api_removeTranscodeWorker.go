@@ -0,0 +1,41 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+//RemoveTranscodeWorker revokes remote worker and requeues its leased transforms
+func RemoveTranscodeWorker(w http.ResponseWriter, r *http.Request) {
+
+	if code, err := ParamAdminToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	workerID := mux.Vars(r)["workerID"]
+	res := store.DB.Where("worker_id = ?", workerID).Delete(&store.TranscodeWorker{})
+	if res.Error != nil {
+		ErrResponse(w, http.StatusInternalServerError, res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		ErrResponse(w, http.StatusNotFound, errors.New("worker not found"))
+		return
+	}
+
+	var assets []store.Asset
+	if err := store.DB.Preload("Account").Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Preload("Channel.ChannelSlot").Preload("Topic.TopicSlot").
+		Where("status = ? AND transform_worker = ? AND transform_lease != ?", APPAssetProcessing, workerID, "").Find(&assets).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	for i := range assets {
+		requeueTranscodeAsset(&assets[i], "transform worker removed")
+	}
+	transcode()
+
+	WriteResponse(w, nil)
+}
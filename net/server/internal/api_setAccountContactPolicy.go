@@ -0,0 +1,56 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+)
+
+//SetAccountContactPolicy sets which senders may request a connection
+func SetAccountContactPolicy(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var policy ContactPolicy
+	if err := ParseRequest(r, w, &policy); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !AppContactPolicy(policy.Policy) {
+		ErrResponse(w, http.StatusBadRequest, errors.New("unknown contact policy"))
+		return
+	}
+
+	var nodes []string
+	for _, node := range policy.Nodes {
+		node = getContactNode(node)
+		if node == "" || strings.Contains(node, ",") {
+			ErrResponse(w, http.StatusBadRequest, errors.New("invalid node"))
+			return
+		}
+		nodes = append(nodes, node)
+	}
+
+	err = store.DB.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Model(account).Updates(map[string]interface{}{"contact_policy": policy.Policy, "contact_nodes": strings.Join(nodes, ",")}).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(account).Update("account_revision", account.AccountRevision+1).Error; res != nil {
+			return res
+		}
+		return nil
+	})
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatus(account)
+	WriteResponse(w, nil)
+}
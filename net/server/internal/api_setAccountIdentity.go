@@ -0,0 +1,184 @@
+package databag
+
+import (
+	"crypto/sha256"
+	"databag/internal/store"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/theckman/go-securerandom"
+	"gorm.io/gorm"
+	"net/http"
+	"os"
+)
+
+//SetAccountIdentity with login credentials, replaces the account key and notifies contacts of the successor
+func SetAccountIdentity(w http.ResponseWriter, r *http.Request) {
+
+	login, res := AccountLogin(r)
+	if res != nil {
+		ErrResponse(w, http.StatusUnauthorized, res)
+		return
+	}
+
+	if code, err := AccountCode(r, login); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	account := &store.Account{}
+	if err := store.DB.Preload("AccountDetail").Where("id = ?", login.ID).First(account).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if account.Disabled {
+		ErrResponse(w, http.StatusGone, errors.New("account is inactive"))
+		return
+	}
+	detail := &account.AccountDetail
+	prior := account.GUID
+
+	// generate successor key
+	privatePem, publicPem, keyType, err := GenerateKeyPair()
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	hash := sha256.Sum256([]byte(publicPem))
+	guid := hex.EncodeToString(hash[:])
+
+	// select connected cards
+	var cards []store.Card
+	if err := store.DB.Where("account_id = ? AND status = ?", prior, APPCardConnected).Find(&cards).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// contacts must receive prior succession before key is replaced again
+	var pending int64
+	if err := store.DB.Model(&store.Notification{}).Where("module = ? AND token IN (?)", APPNotifySuccession,
+		store.DB.Model(&store.Card{}).Select("out_token").Where("account_id = ?", prior)).Count(&pending).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if pending != 0 {
+		ErrResponse(w, http.StatusConflict, errors.New("prior succession not yet delivered"))
+		return
+	}
+
+	// prior key signs succession with a fresh token for each contact
+	revision := account.ProfileRevision + 1
+	accountRevision := account.AccountRevision + 1
+	var notifications []*store.Notification
+	for i := range cards {
+		data, err := securerandom.Bytes(APPTokenSize)
+		if err != nil {
+			ErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		token := hex.EncodeToString(data)
+
+		succession := &Succession{
+			GUID:      guid,
+			PublicKey: publicPem,
+			KeyType:   keyType,
+			Token:     token,
+			Revision:  revision,
+		}
+		msg, err := WriteDataMessage(detail.PrivateKey, detail.PublicKey, detail.KeyType,
+			getSignType(detail.KeyType), prior, APPMsgSuccession, succession)
+		if err != nil {
+			ErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		event, err := json.Marshal(msg)
+		if err != nil {
+			ErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		cards[i].PriorInToken = cards[i].InToken
+		cards[i].InToken = token
+		notifications = append(notifications, &store.Notification{
+			Node:   cards[i].Node,
+			Module: APPNotifySuccession,
+			GUID:   cards[i].GUID,
+			Token:  cards[i].OutToken,
+			Event:  string(event),
+		})
+	}
+
+	err = store.DB.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Model(detail).Updates(map[string]interface{}{"private_key": privatePem, "public_key": publicPem, "key_type": keyType}).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(account).Updates(map[string]interface{}{"guid": guid, "profile_revision": revision, "account_revision": accountRevision}).Error; res != nil {
+			return res
+		}
+
+		// records keyed by account guid
+		if res := tx.Model(&store.Session{}).Where("account_id = ?", prior).Update("account_id", guid).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(&store.App{}).Where("account_id = ?", prior).Update("account_id", guid).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(&store.ApiKey{}).Where("account_id = ?", prior).Update("account_id", guid).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(&store.Card{}).Where("account_id = ?", prior).Update("account_id", guid).Error; res != nil {
+			return res
+		}
+		for _, card := range cards {
+			if res := tx.Model(&store.Card{}).Where("id = ?", card.ID).Updates(map[string]interface{}{"in_token": card.InToken, "prior_in_token": card.PriorInToken}).Error; res != nil {
+				return res
+			}
+		}
+
+		// content authored by account in its own channels
+		if res := tx.Model(&store.Topic{}).Where("account_id = ? AND guid = ?", account.ID, prior).Update("guid", guid).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(&store.Tag{}).Where("account_id = ? AND guid = ?", account.ID, prior).Update("guid", guid).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(&store.Reaction{}).Where("account_id = ? AND guid = ?", account.ID, prior).Update("guid", guid).Error; res != nil {
+			return res
+		}
+
+		// tokens issued under any prior key resolve to successor
+		if res := tx.Model(&store.Succession{}).Where("account_id = ?", account.ID).Update("guid", guid).Error; res != nil {
+			return res
+		}
+		if res := tx.Save(&store.Succession{AccountID: account.ID, PriorGUID: prior, GUID: guid}).Error; res != nil {
+			return res
+		}
+
+		for _, notification := range notifications {
+			if res := tx.Save(notification).Error; res != nil {
+				return res
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// asset directory follows account guid once committed
+	path := getStrConfigValue(CNFAssetPath, APPDefaultPath)
+	if err := os.Rename(path+"/"+prior, path+"/"+guid); err != nil && !os.IsNotExist(err) {
+		ErrMsg(err)
+	}
+
+	for _, notification := range notifications {
+		notify <- notification
+	}
+
+	account.GUID = guid
+	account.ProfileRevision = revision
+	account.AccountRevision = accountRevision
+	SetStatus(account)
+	WriteResponse(w, getProfileModel(account))
+}
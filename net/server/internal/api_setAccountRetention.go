@@ -0,0 +1,48 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+//SetAccountRetention sets days topics and assets are kept within bounds set by admin
+func SetAccountRetention(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var retention AccountRetention
+	if err := ParseRequest(r, w, &retention); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	minDays, maxDays := getRetentionBounds()
+	for _, days := range []int64{retention.TopicDays, retention.AssetDays} {
+		if days != 0 && (days < minDays || days > maxDays) {
+			ErrResponse(w, http.StatusBadRequest, errors.New("retention outside of node bounds"))
+			return
+		}
+	}
+
+	err = store.DB.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Model(account).Updates(map[string]interface{}{"topic_retention": retention.TopicDays, "asset_retention": retention.AssetDays}).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(account).Update("account_revision", account.AccountRevision+1).Error; res != nil {
+			return res
+		}
+		return nil
+	})
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatus(account)
+	WriteResponse(w, nil)
+}
@@ -76,6 +76,7 @@ func SetCardProfile(w http.ResponseWriter, r *http.Request) {
 	card.Node = identity.Node
 	card.ProfileRevision = identity.Revision
   card.Seal = identity.Seal;
+	card.Bot = identity.Bot
 
 	err = store.DB.Transaction(func(tx *gorm.DB) error {
 
@@ -8,10 +8,10 @@ import (
 	"net/http"
 )
 
-//SetChannelCard adds contact to channel membership
+//SetChannelCard adds contact to channel membership for account holder or channel admin
 func SetChannelCard(w http.ResponseWriter, r *http.Request) {
 
-	account, code, err := ParamAgentToken(r, false)
+	account, role, code, err := getManagedChannel(r)
 	if err != nil {
 		ErrResponse(w, code, err)
 		return
@@ -72,6 +72,7 @@ func SetChannelCard(w http.ResponseWriter, r *http.Request) {
     member.Channel = channelSlot.Channel
     member.Card = *cardSlot.Card
     member.PushEnabled = true
+    member.Role = APPChannelPoster
 		if res := tx.Save(member).Error; res != nil {
 			return res
 		}
@@ -102,5 +103,5 @@ func SetChannelCard(w http.ResponseWriter, r *http.Request) {
   audio := getBoolConfigValue(CNFEnableAudio, true);
   image := getBoolConfigValue(CNFEnableImage, true);
   binary := getBoolConfigValue(CNFEnableBinary, true);
-	WriteResponse(w, getChannelModel(&channelSlot, true, true, image, audio, video, binary))
+	WriteResponse(w, getChannelModel(&channelSlot, true, role == APPChannelOwner, image, audio, video, binary))
 }
@@ -0,0 +1,68 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+//SetChannelMuted disables push notifications of hosted channel while it continues to sync
+func SetChannelMuted(w http.ResponseWriter, r *http.Request) {
+
+	account, code, err := ParamAgentToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	// scan parameters
+	params := mux.Vars(r)
+	channelID := params["channelID"]
+
+	var flag bool
+	if err := ParseRequest(r, w, &flag); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// load referenced channel
+	var slot store.ChannelSlot
+	if err := store.DB.Preload("Channel").Where("account_id = ? AND channel_slot_id = ?", account.ID, channelID).First(&slot).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrResponse(w, http.StatusInternalServerError, err)
+		} else {
+			ErrResponse(w, http.StatusNotFound, err)
+		}
+		return
+	}
+	if slot.Channel == nil {
+		ErrResponse(w, http.StatusNotFound, errors.New("channel has been deleted"))
+		return
+	}
+
+	// muted state only visible to host so members are not notified
+	err = store.DB.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Model(slot.Channel).Update("muted", flag).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(slot.Channel).Update("detail_revision", account.ChannelRevision+1).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(&slot).Update("revision", account.ChannelRevision+1).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(account).Update("channel_revision", account.ChannelRevision+1).Error; res != nil {
+			return res
+		}
+		return nil
+	})
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatus(account)
+	WriteResponse(w, nil)
+}
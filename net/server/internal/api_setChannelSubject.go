@@ -3,42 +3,30 @@ package databag
 import (
 	"databag/internal/store"
 	"errors"
-	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 	"net/http"
 )
 
-//SetChannelSubject updates channel subject for account holder
+//SetChannelSubject updates channel subject for account holder or channel admin
 func SetChannelSubject(w http.ResponseWriter, r *http.Request) {
 
-	account, code, err := ParamAgentToken(r, false)
-	if err != nil {
-		ErrResponse(w, code, err)
-		return
-	}
-
-	// scan parameters
-	params := mux.Vars(r)
-	channelID := params["channelID"]
-
 	var subject Subject
 	if err := ParseRequest(r, w, &subject); err != nil {
 		ErrResponse(w, http.StatusBadRequest, err)
 		return
 	}
 
-	// load referenced channel
-	var slot store.ChannelSlot
-	if err := store.DB.Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Where("account_id = ? AND channel_slot_id = ?", account.ID, channelID).First(&slot).Error; err != nil {
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
-			ErrResponse(w, http.StatusInternalServerError, err)
-		} else {
-			ErrResponse(w, http.StatusNotFound, err)
-		}
+	slot, guid, code, err := getChannelSlot(r, true)
+	if err != nil {
+		ErrResponse(w, code, err)
 		return
 	}
-	if slot.Channel == nil {
-		ErrResponse(w, http.StatusNotFound, errors.New("channel has been deleted"))
+	account := &slot.Account
+
+	// only host and admins can change subject
+	role := getMemberRole(guid, &slot)
+	if role != APPChannelOwner && role != APPChannelAdmin {
+		ErrResponse(w, http.StatusForbidden, errors.New("not channel owner or admin"))
 		return
 	}
 
@@ -67,7 +55,7 @@ func SetChannelSubject(w http.ResponseWriter, r *http.Request) {
 		if res := tx.Model(&slot).Update("revision", account.ChannelRevision+1).Error; res != nil {
 			return res
 		}
-		if res := tx.Model(&account).Update("channel_revision", account.ChannelRevision+1).Error; res != nil {
+		if res := tx.Model(account).Update("channel_revision", account.ChannelRevision+1).Error; res != nil {
 			return res
 		}
 		return nil
@@ -87,5 +75,5 @@ func SetChannelSubject(w http.ResponseWriter, r *http.Request) {
   audio := getBoolConfigValue(CNFEnableAudio, true);
   image := getBoolConfigValue(CNFEnableImage, true);
   binary := getBoolConfigValue(CNFEnableBinary, true);
-	WriteResponse(w, getChannelModel(&slot, true, true, image, audio, video, binary))
+	WriteResponse(w, getChannelModel(&slot, true, role == APPChannelOwner, image, audio, video, binary))
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 	"net/http"
+	"time"
 )
 
 //SetChannelTopicConfirmed sets confirmation status of topic
@@ -25,7 +26,7 @@ func SetChannelTopicConfirmed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	channelSlot, _, code, err := getChannelSlot(r, true)
+	channelSlot, guid, code, err := getPosterChannelSlot(r, true)
 	if err != nil {
 		ErrResponse(w, code, err)
 		return
@@ -47,10 +48,39 @@ func SetChannelTopicConfirmed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// scheduled topics are hidden from and so cannot be published by other members
+	if isTopicHidden(topicSlot.Topic, guid) {
+		ErrResponse(w, http.StatusNotFound, errors.New("referenced empty slot"))
+		return
+	}
+
+	// confirmed topics with future publish time wait to be published
+	if status == APPTopicConfirmed && topicSlot.Topic.Publish > time.Now().Unix() {
+		status = APPTopicScheduled
+	}
+	if status == APPTopicScheduled && topicSlot.Topic.Publish <= time.Now().Unix() {
+		ErrResponse(w, http.StatusBadRequest, errors.New("topic publish time not set"))
+		return
+	}
+
+	// reply count of thread parent follows visibility of reply
+	var replies int64
+	published := isTopicPublished(&store.Topic{Publish: topicSlot.Topic.Publish, Status: status})
+	if published && !isTopicPublished(topicSlot.Topic) {
+		replies = 1
+	} else if !published && isTopicPublished(topicSlot.Topic) {
+		replies = -1
+	}
+
 	err = store.DB.Transaction(func(tx *gorm.DB) error {
 		if res := tx.Model(&topicSlot.Topic).Update("status", status).Error; res != nil {
 			return res
 		}
+		if replies != 0 {
+			if res := updateReplyCount(tx, topicSlot.ParentID, replies, act.ChannelRevision+1); res != nil {
+				return res
+			}
+		}
 		if res := tx.Model(&topicSlot.Topic).Update("detail_revision", act.ChannelRevision+1).Error; res != nil {
 			return res
 		}
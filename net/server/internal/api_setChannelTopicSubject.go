@@ -22,7 +22,7 @@ func SetChannelTopicSubject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	channelSlot, guid, code, err := getChannelSlot(r, true)
+	channelSlot, guid, code, err := getPosterChannelSlot(r, true)
 	if err != nil {
 		ErrResponse(w, code, err)
 		return
@@ -40,8 +40,13 @@ func SetChannelTopicSubject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// can only update subject if creator
-	if topicSlot.Topic.GUID != guid {
+	// can only update subject if creator or moderator
+	role := getMemberRole(guid, &channelSlot)
+	if role == APPChannelReader {
+		ErrResponse(w, http.StatusForbidden, errors.New("member is read-only"))
+		return
+	}
+	if topicSlot.Topic.GUID != guid && role != APPChannelOwner && role != APPChannelAdmin {
 		ErrResponse(w, http.StatusUnauthorized, errors.New("topic not created by you"))
 		return
 	}
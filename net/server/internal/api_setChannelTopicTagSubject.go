@@ -52,6 +52,10 @@ func SetChannelTopicTagSubject(w http.ResponseWriter, r *http.Request) {
 		ErrResponse(w, http.StatusNotFound, errors.New("topic tag not found"))
 		return
 	}
+	if !canPost(getMemberRole(guid, &channelSlot)) {
+		ErrResponse(w, http.StatusForbidden, errors.New("member is read-only"))
+		return
+	}
 	if tagSlot.Tag.GUID != guid {
 		ErrResponse(w, http.StatusUnauthorized, errors.New("not creator of tag"))
 		return
@@ -0,0 +1,64 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+// SetNodeAccountBot sets bot status of account, clearing api keys when unset
+func SetNodeAccountBot(w http.ResponseWriter, r *http.Request) {
+
+	params := mux.Vars(r)
+	accountID, res := strconv.ParseUint(params["accountID"], 10, 32)
+	if res != nil {
+		ErrResponse(w, http.StatusBadRequest, res)
+		return
+	}
+
+	if code, err := ParamSessionToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var flag bool
+	if err := ParseRequest(r, w, &flag); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var account store.Account
+	if err := store.DB.Where("id = ?", accountID).First(&account).Error; err != nil {
+		ErrResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if account.Bot == flag {
+		WriteResponse(w, nil)
+		return
+	}
+
+	// bump profile revision so contacts pick up the badge
+	account.Bot = flag
+	account.ProfileRevision += 1
+	err := store.DB.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Model(&account).Updates(map[string]interface{}{"bot": account.Bot, "profile_revision": account.ProfileRevision}).Error; res != nil {
+			return res
+		}
+		if !flag {
+			if res := tx.Where("account_id = ?", account.GUID).Delete(&store.ApiKey{}).Error; res != nil {
+				return res
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetProfileNotification(&account)
+	SetStatus(&account)
+	WriteResponse(w, nil)
+}
@@ -0,0 +1,48 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// SetNodeAccountStorage overrides storage quota of account, 0 restores node default and -1 removes limit
+func SetNodeAccountStorage(w http.ResponseWriter, r *http.Request) {
+
+	params := mux.Vars(r)
+	accountID, res := strconv.ParseUint(params["accountID"], 10, 32)
+	if res != nil {
+		ErrResponse(w, http.StatusBadRequest, res)
+		return
+	}
+
+	if code, err := ParamSessionToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var quota int64
+	if err := ParseRequest(r, w, &quota); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if quota < -1 {
+		ErrResponse(w, http.StatusBadRequest, errors.New("invalid storage quota"))
+		return
+	}
+
+	var account store.Account
+	if err := store.DB.Where("id = ?", accountID).First(&account).Error; err != nil {
+		ErrResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err := store.DB.Model(&account).Update("storage_quota", quota).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatus(&account)
+	WriteResponse(w, nil)
+}
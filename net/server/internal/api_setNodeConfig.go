@@ -2,9 +2,11 @@ package databag
 
 import (
 	"databag/internal/store"
+	"errors"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"net/http"
+	"strings"
 )
 
 //SetNodeConfig sets node configuration
@@ -25,6 +27,23 @@ func SetNodeConfig(w http.ResponseWriter, r *http.Request) {
 		ErrResponse(w, http.StatusBadRequest, err)
 		return
 	}
+	if config.KeyType != "" && !AppKeyType(config.KeyType) {
+		ErrResponse(w, http.StatusBadRequest, errors.New("unsupported key type"))
+		return
+	}
+	if strings.Contains(config.APIHost, "://") {
+		ErrResponse(w, http.StatusBadRequest, errors.New("api host must not include scheme"))
+		return
+	}
+	config.APIHost = strings.TrimSuffix(strings.TrimSpace(config.APIHost), "/")
+	if config.ScanMode != "" && config.ScanMode != APPScanClamd && config.ScanMode != APPScanScript {
+		ErrResponse(w, http.StatusBadRequest, errors.New("unsupported scan mode"))
+		return
+	}
+	if config.ScanMode == APPScanClamd && config.ScanAddress == "" {
+		ErrResponse(w, http.StatusBadRequest, errors.New("clamd scan requires address"))
+		return
+	}
 
 	// store credentials
 	err := store.DB.Transaction(func(tx *gorm.DB) error {
@@ -141,6 +160,81 @@ func SetNodeConfig(w http.ResponseWriter, r *http.Request) {
 			return res
 		}
 
+		// upsert delegated api host
+		if res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"str_value"}),
+		}).Create(&store.Config{ConfigID: CNFAPIHost, StrValue: config.APIHost}).Error; res != nil {
+			return res
+		}
+
+		// upsert script transform preference
+		if res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"bool_value"}),
+		}).Create(&store.Config{ConfigID: CNFScriptTransform, BoolValue: config.ScriptTransform}).Error; res != nil {
+			return res
+		}
+
+		// upsert remote transcode preference
+		if res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"bool_value"}),
+		}).Create(&store.Config{ConfigID: CNFRemoteTranscode, BoolValue: config.RemoteTranscode}).Error; res != nil {
+			return res
+		}
+
+		// upsert upload metadata scrubbing
+		if res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"bool_value"}),
+		}).Create(&store.Config{ConfigID: CNFScrubMetadata, BoolValue: config.ScrubMetadata}).Error; res != nil {
+			return res
+		}
+
+		// upsert upload scan mode
+		if res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"str_value"}),
+		}).Create(&store.Config{ConfigID: CNFScanMode, StrValue: config.ScanMode}).Error; res != nil {
+			return res
+		}
+
+		// upsert clamd address
+		if res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"str_value"}),
+		}).Create(&store.Config{ConfigID: CNFScanAddress, StrValue: config.ScanAddress}).Error; res != nil {
+			return res
+		}
+
+		// upsert webhook private network access
+		if res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"bool_value"}),
+		}).Create(&store.Config{ConfigID: CNFWebhookPrivate, BoolValue: config.WebhookPrivate}).Error; res != nil {
+			return res
+		}
+
+		// upsert transform concurrency of each queue, unset keeps default
+		workers := map[string]int64{
+			CNFVideoWorkers:   config.VideoWorkers,
+			CNFAudioWorkers:   config.AudioWorkers,
+			CNFPhotoWorkers:   config.PhotoWorkers,
+			CNFDefaultWorkers: config.DefaultWorkers,
+		}
+		for configID, count := range workers {
+			if count <= 0 {
+				continue
+			}
+			if res := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "config_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"num_value"}),
+			}).Create(&store.Config{ConfigID: configID, NumValue: count}).Error; res != nil {
+				return res
+			}
+		}
+
     if updateAccess {
       // upsert enable open access
       if res := tx.Clauses(clause.OnConflict{
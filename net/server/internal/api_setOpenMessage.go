@@ -31,6 +31,35 @@ func SetOpenMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// reject nodes excluded by admin
+	federated, err := isNodeFederated(connect.Node)
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !federated {
+		ErrResponse(w, http.StatusForbidden, errors.New("contact node not federated"))
+		return
+	}
+
+	// sender must be shown to be on the node it claims while rules are set
+	verified := false
+	rules, err := loadNodeRules()
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(rules) != 0 {
+		if verified, err = isVerifiedNode(guid, &connect); err != nil {
+			ErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !verified {
+			ErrResponse(w, http.StatusForbidden, errors.New("contact node not verified"))
+			return
+		}
+	}
+
 	// load referenced account
 	var account store.Account
 	if err := store.DB.Where("guid = ?", connect.Contact).First(&account).Error; err != nil {
@@ -42,6 +71,36 @@ func SetOpenMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// ignore blocked senders, and senders not shown to be on the node they claim when node rules apply
+	blocked, err := isContactBlocked(&account, guid, connect.Node)
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !blocked && !verified {
+		nodeRules, err := usesContactNodes(&account)
+		if err != nil {
+			ErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		if nodeRules {
+			if verified, err = isVerifiedNode(guid, &connect); err != nil {
+				ErrResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+			blocked = !verified
+		}
+	}
+	if blocked {
+		data, res := securerandom.Bytes(APPTokenSize)
+		if res != nil {
+			ErrResponse(w, http.StatusInternalServerError, res)
+			return
+		}
+		WriteResponse(w, &ContactStatus{Token: hex.EncodeToString(data), Status: APPCardPending})
+		return
+	}
+
 	// see if card exists
 	slot := &store.CardSlot{}
 	card := &store.Card{}
@@ -51,6 +110,12 @@ func SetOpenMessage(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// new requests subject to account policy
+		if code, res := checkContactRequest(&account, guid, connect.Node); res != nil {
+			ErrResponse(w, code, res)
+			return
+		}
+
 		// create new card
 		data, res := securerandom.Bytes(APPTokenSize)
 		if res != nil {
@@ -63,9 +128,13 @@ func SetOpenMessage(w http.ResponseWriter, r *http.Request) {
 		card.Description = connect.Description
 		card.Location = connect.Location
     card.Seal = connect.Seal
+		card.Bot = connect.Bot
 		card.Image = connect.Image
 		card.Version = connect.Version
 		card.Node = connect.Node
+		if verified {
+			card.VerifiedNode = connect.Node
+		}
 		card.ProfileRevision = connect.ProfileRevision
 		card.Status = APPCardPending
     card.StatusUpdated = time.Now().Unix()
@@ -111,6 +180,7 @@ func SetOpenMessage(w http.ResponseWriter, r *http.Request) {
 			card.Name = connect.Name
 			card.Description = connect.Description
       card.Seal = connect.Seal
+			card.Bot = connect.Bot
 			card.Location = connect.Location
 			card.Image = connect.Image
 			card.Version = connect.Version
@@ -137,6 +207,9 @@ func SetOpenMessage(w http.ResponseWriter, r *http.Request) {
 			card.Status = APPCardConnected
       card.StatusUpdated = time.Now().Unix()
 		}
+		if verified && card.Node == connect.Node {
+			card.VerifiedNode = card.Node
+		}
 		card.OutToken = connect.Token
 		card.DetailRevision = account.CardRevision + 1
 
@@ -35,13 +35,16 @@ func SetPushEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	SendPushEvent(card.Account, event)
+	sendContactPushEvent(card, event)
 	WriteResponse(w, nil)
 }
 
 // SendPushEvent delivers notification to clients
 func SendPushEvent(account store.Account, event string) {
 
+	// forward event to subscribed webhooks
+	SetWebhookEvent(&account, APPWebhookPush+event, nil)
+
 	// check if server supports push
 	if getBoolConfigValue(CNFPushSupported, true) != true {
 		return
@@ -81,13 +84,12 @@ func SendPushEvent(account store.Account, event string) {
 					ErrMsg(err)
 					continue
 				}
-				client := &http.Client{}
-				resp, err := client.Do(req)
+				resp, err := getFederationClient().Do(req)
 				if err != nil {
 					ErrMsg(err)
 					continue
 				}
-				defer resp.Body.Close()
+				resp.Body.Close()
 				if resp.StatusCode != 200 {
 					ErrMsg(errors.New("failed to push notification"))
 				}
@@ -112,13 +114,14 @@ func SendPushEvent(account store.Account, event string) {
 					VAPIDPublicKey:  getStrConfigValue(CNFWebPublicKey, ""),
 					VAPIDPrivateKey: getStrConfigValue(CNFWebPrivateKey, ""),
 					TTL:             30,
+					HTTPClient:      getFederationClient(),
 				}
 				resp, err := webpush.SendNotification(msg, subscription, options)
-				defer resp.Body.Close()
 				if err != nil {
 					ErrMsg(err)
 					continue
 				}
+				resp.Body.Close()
 			} else {
 				if pushToken == "" || pushToken == "null" {
 					continue
@@ -137,13 +140,12 @@ func SendPushEvent(account store.Account, event string) {
 					continue
 				}
 				req.Header.Set("Content-Type", "application/json; charset=utf-8")
-				client := &http.Client{}
-				resp, err := client.Do(req)
+				resp, err := getFederationClient().Do(req)
 				if err != nil {
 					ErrMsg(err)
 					continue
 				}
-				defer resp.Body.Close()
+				resp.Body.Close()
 				if resp.StatusCode != 200 {
 					ErrMsg(errors.New("failed to push notification"))
 				}
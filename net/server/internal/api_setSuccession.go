@@ -0,0 +1,104 @@
+package databag
+
+import (
+	"crypto/sha256"
+	"databag/internal/store"
+	"encoding/hex"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+//SetSuccession updates contact of replaced identity key
+func SetSuccession(w http.ResponseWriter, r *http.Request) {
+
+	card, code, err := ParamContactToken(r, false)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var message DataMessage
+	if err := ParseRequest(r, w, &message); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := NotifySuccession(card, &message); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	WriteResponse(w, nil)
+}
+
+//NotifySuccession verifies succession was signed by the prior key and remaps the card
+func NotifySuccession(card *store.Card, message *DataMessage) error {
+
+	var succession Succession
+	guid, messageType, _, err := ReadDataMessage(message, &succession)
+	if err != nil {
+		return err
+	}
+	if messageType != APPMsgSuccession {
+		return errors.New("invalid message type")
+	}
+	if guid != card.GUID {
+		return errors.New("succession not signed by contact")
+	}
+
+	// successor guid must be derived from successor key
+	if !AppKeyType(succession.KeyType) {
+		return errors.New("unsupported successor key type")
+	}
+	if _, err := ParsePublicKeyFromPemStr(succession.PublicKey); err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(succession.PublicKey))
+	if succession.GUID != hex.EncodeToString(hash[:]) {
+		return errors.New("invalid successor guid")
+	}
+	if succession.Token == "" {
+		return errors.New("missing successor token")
+	}
+
+	act := &card.Account
+	var count int64
+	if err := store.DB.Model(&store.Card{}).Where("account_id = ? AND guid = ?", act.GUID, succession.GUID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count != 0 {
+		return errors.New("successor already a contact")
+	}
+
+	err = store.DB.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Model(card).Where("id = ?", card.ID).Updates(map[string]interface{}{"guid": succession.GUID, "out_token": succession.Token,
+			"notified_profile": succession.Revision, "detail_revision": card.DetailRevision + 1}).Error; res != nil {
+			return res
+		}
+
+		// content authored by contact in account channels
+		if res := tx.Model(&store.Topic{}).Where("account_id = ? AND guid = ?", act.ID, guid).Update("guid", succession.GUID).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(&store.Tag{}).Where("account_id = ? AND guid = ?", act.ID, guid).Update("guid", succession.GUID).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(&store.Reaction{}).Where("account_id = ? AND guid = ?", act.ID, guid).Update("guid", succession.GUID).Error; res != nil {
+			return res
+		}
+
+		if res := tx.Model(&card.CardSlot).Where("id = ?", card.CardSlot.ID).Update("revision", act.CardRevision+1).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(act).Where("id = ?", act.ID).Update("card_revision", act.CardRevision+1).Error; res != nil {
+			return res
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	SetStatus(act)
+	return nil
+}
@@ -0,0 +1,75 @@
+package databag
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+	"os"
+)
+
+//SetTranscodeLeaseOutput uploads result of leased transform completing the asset
+func SetTranscodeLeaseOutput(w http.ResponseWriter, r *http.Request) {
+
+	worker, code, err := ParamWorkerToken(r)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	leaseID := mux.Vars(r)["leaseID"]
+	asset, code, err := getLeasedAsset(worker, leaseID)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	// upload to held temporary file so output only replaces asset while lease is still valid
+	dir := getStrConfigValue(CNFAssetPath, APPDefaultPath) + "/" + asset.Account.GUID
+	path := dir + "/" + asset.AssetID
+	upload := uuid.New().String()
+	holdAsset(upload)
+	defer releaseAsset(upload)
+	temp := dir + "/" + upload
+
+	// output may use remaining storage plus space held by this asset
+	if available := getStorageAvailable(&asset.Account); available >= 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, available+asset.Size)
+	}
+	crc, size, err := saveAsset(r.Body, temp)
+	if err != nil {
+		os.Remove(temp)
+	}
+	var limit *http.MaxBytesError
+	if errors.As(err, &limit) {
+		asset.TransformError = "storage quota exceeded"
+		if err := endTranscodeLease(asset, APPAssetError, 0, 0); err != nil {
+			ErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		ErrResponse(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	if err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// lease may have been revoked while uploading
+	if asset, code, err = getLeasedAsset(worker, leaseID); err != nil {
+		os.Remove(temp)
+		ErrResponse(w, code, err)
+		return
+	}
+	if err := os.Rename(temp, path); err != nil {
+		os.Remove(temp)
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := endTranscodeLease(asset, APPAssetReady, crc, size); err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	WriteResponse(w, nil)
+}
@@ -0,0 +1,46 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"github.com/gorilla/mux"
+	"net/http"
+	"time"
+)
+
+//SetTranscodeLeaseProgress records percent complete of leased transform and renews the lease
+func SetTranscodeLeaseProgress(w http.ResponseWriter, r *http.Request) {
+
+	worker, code, err := ParamWorkerToken(r)
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var progress int
+	if err := ParseRequest(r, w, &progress); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if progress < 0 {
+		progress = 0
+	} else if progress > 99 {
+		progress = 99
+	}
+
+	asset, code, err := getLeasedAsset(worker, mux.Vars(r)["leaseID"])
+	if err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	expires := time.Now().Unix() + APPTranscodeLeaseTimeout
+	if err := store.DB.Model(&store.Asset{}).Where("id = ?", asset.ID).Updates(map[string]interface{}{
+		"transform_progress": progress,
+		"transform_expires":  expires,
+	}).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	WriteResponse(w, &expires)
+}
@@ -165,6 +165,15 @@ func SetRing(card *store.Card, ring Ring) {
 	phone.IceUsername = ring.IceUsername
 	phone.IcePassword = ring.IcePassword
 	phone.CardID = card.CardSlot.CardSlotID
+
+	// ice credentials stay with the account's own clients
+	hook := phone
+	hook.IcePassword = ""
+	hook.Ice = nil
+	for _, ice := range phone.Ice {
+		hook.Ice = append(hook.Ice, IceURL{URLs: ice.URLs, Username: ice.Username})
+	}
+	SetWebhookEvent(&card.Account, APPWebhookRing, &hook)
 	var a Activity
 	a.Phone = &phone
 	msg, err := json.Marshal(a)
@@ -191,6 +200,7 @@ func SetStatus(account *store.Account) {
 
 	// get revisions for the account
 	rev := getRevision(account)
+	SetWebhookEvent(account, APPWebhookRevision, rev.Revision)
 	full, errFull := json.Marshal(rev)
 	if errFull != nil {
 		ErrMsg(errFull)
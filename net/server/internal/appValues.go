@@ -18,6 +18,36 @@ const APPBodyLimit = 20971520
 // APPVersion config for current version of api
 const APPVersion = "0.1.0"
 
+// APPDiscoveryPath config for path of node discovery document
+const APPDiscoveryPath = "/.well-known/databag"
+
+// APPDiscoveryExpire config for seconds a fetched discovery document is cached
+const APPDiscoveryExpire = 3600
+
+// APPDiscoveryRetry config for seconds a node without discovery document is cached
+const APPDiscoveryRetry = 300
+
+// APPDiscoveryTimeout config for seconds to wait for a discovery document
+const APPDiscoveryTimeout = 10
+
+// APPFederationTimeout config for seconds to wait for a federated node to respond
+const APPFederationTimeout = 10
+
+// APPFederationIdleConns config for idle connections kept open per federated node
+const APPFederationIdleConns = 8
+
+// APPCapabilityCalls config for capability name of webrtc calls
+const APPCapabilityCalls = "calls"
+
+// APPCapabilityTransforms config for capability name of asset transforms
+const APPCapabilityTransforms = "transforms"
+
+// APPCapabilityReadReceipts config for capability name of topic read receipts
+const APPCapabilityReadReceipts = "read_receipts"
+
+// APPCapabilitySuccession config for capability name of identity key succession
+const APPCapabilitySuccession = "succession"
+
 // APPCreateExpire config for valid duration of create token
 const APPCreateExpire = 86400
 
@@ -33,6 +63,18 @@ const APPResetSize = 6
 // APPConnectExpire config for valid duration of connection message
 const APPConnectExpire = 30
 
+// APPMessageSkew config for allowed clock skew of signed message timestamps
+const APPMessageSkew = 30
+
+// APPNonceExpire config for duration nonces of signed messages are tracked
+const APPNonceExpire = 86400
+
+// APPNonceSweepInterval config for seconds between removal of expired nonces
+const APPNonceSweepInterval = 3600
+
+// APPNonceSize config for size of signed message nonce
+const APPNonceSize = 16
+
 // APPKeySize config for default key size
 const APPKeySize = 4096
 
@@ -42,12 +84,24 @@ const APPRSA4096 = "RSA4096"
 // APPRSA2048 config for rsa 2048 alg name
 const APPRSA2048 = "RSA2048"
 
+// APPED25519 config for ed25519 alg name
+const APPED25519 = "ED25519"
+
+// APPP256 config for ecdsa p-256 alg name
+const APPP256 = "P256"
+
 // APPSignPKCS1V15 config for pkcsv15 alg name
 const APPSignPKCS1V15 = "PKCS1v15"
 
 // APPSignPSS config for pss alg name
 const APPSignPSS = "PSS"
 
+// APPSignEd25519 config for ed25519 signature alg name
+const APPSignEd25519 = "Ed25519"
+
+// APPSignECDSA config for ecdsa sha256 signature alg name
+const APPSignECDSA = "ECDSA"
+
 // APPMsgAuthenticate config for authorize message name
 const APPMsgAuthenticate = "authenticate"
 
@@ -60,6 +114,9 @@ const APPMsgConnect = "connect"
 // APPMsgDisconnect config for disconnect message name
 const APPMsgDisconnect = "disconnect"
 
+// APPMsgSuccession config for key succession message name
+const APPMsgSuccession = "succession"
+
 // APPCardPending config for pending status name
 const APPCardPending = "pending"
 
@@ -93,6 +150,39 @@ const APPNotifyView = "view"
 // APPNotifyTopicRead config for notification name for topic read receipts
 const APPNotifyTopicRead = "topic_read"
 
+// APPNotifySuccession config for notification name for key succession
+const APPNotifySuccession = "succession"
+
+// APPContactAnyone config for accepting contact requests from any sender
+const APPContactAnyone = "anyone"
+
+// APPContactNodes config for accepting contact requests from listed nodes
+const APPContactNodes = "nodes"
+
+// APPContactMutual config for accepting contact requests from contacts of contacts hosted on this node
+const APPContactMutual = "contacts"
+
+// APPContactNobody config for rejecting all contact requests
+const APPContactNobody = "nobody"
+
+// APPNodeAllow config for rule mode of nodes allowed to federate
+const APPNodeAllow = "allow"
+
+// APPNodeDeny config for rule mode of nodes denied federation
+const APPNodeDeny = "deny"
+
+// APPRetentionMinDays config for default shortest retention an account may choose
+const APPRetentionMinDays = 1
+
+// APPRetentionMaxDays config for default longest retention an account may choose
+const APPRetentionMaxDays = 7300
+
+// APPContactRequestLimit config for default new contact requests per sender in period
+const APPContactRequestLimit = 10
+
+// APPContactRequestPeriod config for default period in seconds of contact request limit
+const APPContactRequestPeriod = 3600
+
 // CNFEnableReadReceipts config name for read receipts feature
 const CNFEnableReadReceipts = "enable_read_receipts"
 
@@ -138,6 +228,12 @@ const APPTokenReset = "reset"
 // APPNotifyBuffer config for size of channel reciving notifications
 const APPNotifyBuffer = 4096
 
+// APPSuccessionRetry config for seconds before first retry of key succession delivery, doubling each attempt
+const APPSuccessionRetry = 30
+
+// APPSuccessionAttempts config for number of key succession delivery retries
+const APPSuccessionAttempts = 12
+
 // APPUsernameWait seconds to delay response
 const APPUsernameWait = 1
 
@@ -147,6 +243,27 @@ const APPTopicUnconfirmed = "unconfirmed"
 // APPTopicConfirmed config for status name for confirmed
 const APPTopicConfirmed = "confirmed"
 
+// APPTopicScheduled config for status name for confirmed but not yet published
+const APPTopicScheduled = "scheduled"
+
+// APPTopicSweepInterval config for seconds between scans for scheduled and expired topics
+const APPTopicSweepInterval = 10
+
+// APPChannelOwner config for role name of channel host
+const APPChannelOwner = "owner"
+
+// APPChannelAdmin config for role name of member moderating channel
+const APPChannelAdmin = "admin"
+
+// APPChannelPoster config for role name of member posting to channel
+const APPChannelPoster = "poster"
+
+// APPChannelReader config for role name of read-only member
+const APPChannelReader = "reader"
+
+// APPReactionMaxSize config for max size of reaction emoji
+const APPReactionMaxSize = 64
+
 // APPAssetReady config for status name for ready
 const APPAssetReady = "ready"
 
@@ -180,9 +297,120 @@ const APPQueuePhoto = "photo"
 // APPQueueDefault config for queue name for other assets
 const APPQueueDefault = ""
 
+// APPWorkerDefaultQueue config for name remote workers use for the default queue
+const APPWorkerDefaultQueue = "default"
+
+// APPStreamTransform config for transform producing segmented stream
+const APPStreamTransform = "vhls"
+
+// APPStreamSuffix config for suffix of directory holding stream segments of asset
+const APPStreamSuffix = ".hls"
+
+// APPScanClamd config for scan mode streaming uploads to clamd
+const APPScanClamd = "clamd"
+
+// APPScanScript config for scan mode running scan script on uploads
+const APPScanScript = "script"
+
+// APPScanTimeout config for seconds an upload scan may take
+const APPScanTimeout = 120
+
+// APPScanChunkSize config for size of chunks streamed to clamd
+const APPScanChunkSize = 64 * 1024
+
+// APPQuarantinePath config for directory under asset path holding infected uploads
+const APPQuarantinePath = "quarantine"
+
+// APPStorageOtherType config for storage breakdown of assets without known media type
+const APPStorageOtherType = "other"
+
+// APPFsckMissing config for fsck finding of ready asset without file
+const APPFsckMissing = "missing"
+
+// APPFsckChecksum config for fsck finding of asset file not matching recorded crc or size
+const APPFsckChecksum = "checksum"
+
+// APPFsckOrphan config for fsck finding of file without asset record
+const APPFsckOrphan = "orphan"
+
+// APPFsckAccount config for fsck finding of asset directory without account
+const APPFsckAccount = "account"
+
+// APPFsckGracePeriod config for seconds before unreferenced files are considered orphaned
+const APPFsckGracePeriod = 3600
+
+// APPFsckCheckInterval config for seconds between checks whether scheduled fsck is due
+const APPFsckCheckInterval = 600
+
+// APPFsckMaxFindings config for max findings kept for fsck status
+const APPFsckMaxFindings = 1000
+
 // APPDefaultPath config for default path to store assets
 const APPDefaultPath = "/tmp/databag/assets"
 
+// APPTranscodeVideoTimeout config for seconds a video transform may run
+const APPTranscodeVideoTimeout = 3600
+
+// APPTranscodeAudioTimeout config for seconds an audio transform may run
+const APPTranscodeAudioTimeout = 900
+
+// APPTranscodePhotoTimeout config for seconds a photo transform may run
+const APPTranscodePhotoTimeout = 120
+
+// APPTranscodeDefaultTimeout config for seconds other transforms may run
+const APPTranscodeDefaultTimeout = 600
+
+// APPTranscodeVideoWorkers config for default concurrent video transforms
+const APPTranscodeVideoWorkers = 1
+
+// APPTranscodeAudioWorkers config for default concurrent audio transforms
+const APPTranscodeAudioWorkers = 1
+
+// APPTranscodePhotoWorkers config for default concurrent photo transforms
+const APPTranscodePhotoWorkers = 2
+
+// APPTranscodeDefaultWorkers config for default concurrent other transforms
+const APPTranscodeDefaultWorkers = 1
+
+// APPTranscodePollInterval config for seconds between scans for waiting assets
+const APPTranscodePollInterval = 30
+
+// APPTranscodeAgeLimit config for seconds an asset waits before it runs ahead of smaller assets
+const APPTranscodeAgeLimit = 300
+
+// APPTranscodeMaxAttempts config for restarts a transform may be interrupted by before failing
+const APPTranscodeMaxAttempts = 3
+
+// APPTranscodeLeaseTimeout config for seconds a remote lease lasts without progress
+const APPTranscodeLeaseTimeout = 120
+
+// APPTranscodeCheckInterval config for seconds between checks that a running job asset still exists
+const APPTranscodeCheckInterval = 5
+
+// APPTranscodeProgressInterval config for minimum seconds between stored progress updates
+const APPTranscodeProgressInterval = 1
+
+// APPTranscodeJobLimit config for max jobs listed in admin view
+const APPTranscodeJobLimit = 256
+
+// APPImageQuality config for jpeg quality of native image transforms
+const APPImageQuality = 90
+
+// APPImageMaxPixels config for largest image decoded by native image transforms
+const APPImageMaxPixels = 100000000
+
+// APPImageThumbSize config for bounding size of image thumbnails
+const APPImageThumbSize = 192
+
+// APPPreviewSize config for bounding size image is reduced to before computing blurhash
+const APPPreviewSize = 32
+
+// APPPreviewComponents config for blurhash components along longer side of image
+const APPPreviewComponents = 4
+
+// APPImageLargeSize config for bounding size of large images
+const APPImageLargeSize = 1024
+
 // APPMFAIssuer name servive
 const APPMFAIssuer = "Databag"
 
@@ -234,6 +462,45 @@ const APPIPBlockThreshold = 5
 // APPIPBlockMaxDuration maximum IP block duration in hours
 const APPIPBlockMaxDuration = 720
 
+// APPWebhookBuffer config for size of channel receiving webhook deliveries
+const APPWebhookBuffer = 4096
+
+// APPWebhookTimeout seconds to wait for a webhook endpoint to respond
+const APPWebhookTimeout = 10
+
+// APPWebhookMaxAttempts limit of delivery attempts before a webhook delivery fails
+const APPWebhookMaxAttempts = 6
+
+// APPWebhookRetryInterval seconds between scans for webhook deliveries to retry
+const APPWebhookRetryInterval = 30
+
+// APPWebhookRetryBase seconds to wait before first retry, doubled on each attempt
+const APPWebhookRetryBase = 30
+
+// APPWebhookLogExpire seconds to keep webhook delivery records
+const APPWebhookLogExpire = 604800
+
+// APPWebhookPending config for status name for queued webhook delivery
+const APPWebhookPending = "pending"
+
+// APPWebhookDelivered config for status name for successful webhook delivery
+const APPWebhookDelivered = "delivered"
+
+// APPWebhookFailed config for status name for abandoned webhook delivery
+const APPWebhookFailed = "failed"
+
+// APPWebhookRevision config for webhook event name when account revisions change
+const APPWebhookRevision = "status.revision"
+
+// APPWebhookRing config for webhook event name when a contact rings
+const APPWebhookRing = "status.ring"
+
+// APPWebhookPush config for webhook event prefix of push events
+const APPWebhookPush = "push."
+
+// APPWebhookWorkers config for number of concurrent webhook deliveries
+const APPWebhookWorkers = 8
+
 // AppCardStatus compares cards status with string
 func AppCardStatus(status string) bool {
 	if status == APPCardPending {
@@ -254,6 +521,31 @@ func AppCardStatus(status string) bool {
 	return false
 }
 
+// AppChannelRole compares assignable member role with string
+func AppChannelRole(role string) bool {
+	if role == APPChannelAdmin {
+		return true
+	}
+	if role == APPChannelPoster {
+		return true
+	}
+	if role == APPChannelReader {
+		return true
+	}
+	return false
+}
+
+// AppKeyType compares supported identity key types with string
+func AppKeyType(keyType string) bool {
+	if keyType == APPRSA2048 || keyType == APPRSA4096 {
+		return true
+	}
+	if keyType == APPED25519 || keyType == APPP256 {
+		return true
+	}
+	return false
+}
+
 // AppTopicStatus compares topic status with string
 func AppTopicStatus(status string) bool {
 	if status == APPTopicConfirmed {
@@ -262,5 +554,19 @@ func AppTopicStatus(status string) bool {
 	if status == APPTopicUnconfirmed {
 		return true
 	}
+	if status == APPTopicScheduled {
+		return true
+	}
+	return false
+}
+
+// AppContactPolicy compares contact request policy with string
+func AppContactPolicy(policy string) bool {
+	if policy == APPContactAnyone || policy == APPContactNodes {
+		return true
+	}
+	if policy == APPContactMutual || policy == APPContactNobody {
+		return true
+	}
 	return false
 }
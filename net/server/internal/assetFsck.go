@@ -0,0 +1,302 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// fsckAccountDir matches directory names of account guids, only these are removed on repair
+var fsckAccountDir = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// FsckFinding inconsistency between asset records and asset files
+type FsckFinding struct {
+	Kind      string `json:"kind"`
+	AccountID uint   `json:"accountID,omitempty"`
+	GUID      string `json:"guid"`
+	AssetID   string `json:"assetID,omitempty"`
+	File      string `json:"file,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	Repaired  bool   `json:"repaired"`
+}
+
+// FsckStatus progress and findings of latest asset fsck
+type FsckStatus struct {
+	Running  bool          `json:"running"`
+	Repair   bool          `json:"repair"`
+	Started  int64         `json:"started,omitempty"`
+	Finished int64         `json:"finished,omitempty"`
+	Checked  int64         `json:"checked"`
+	Issues   int64         `json:"issues"`
+	Repaired int64         `json:"repaired"`
+	Error    string        `json:"error,omitempty"`
+	Findings []FsckFinding `json:"findings,omitempty"`
+}
+
+var fsckSync sync.Mutex
+var fsckStatus FsckStatus
+
+// getFsckStatus copies state of latest fsck
+func getFsckStatus() *FsckStatus {
+	fsckSync.Lock()
+	defer fsckSync.Unlock()
+	status := fsckStatus
+	status.Findings = append([]FsckFinding{}, fsckStatus.Findings...)
+	return &status
+}
+
+// startAssetFsck begins fsck in background, limited to account if specified, false if one is running
+func startAssetFsck(repair bool, accountID *uint) bool {
+	fsckSync.Lock()
+	defer fsckSync.Unlock()
+	if fsckStatus.Running {
+		return false
+	}
+	fsckStatus = FsckStatus{Running: true, Repair: repair, Started: time.Now().Unix()}
+	go runAssetFsck(repair, accountID)
+	return true
+}
+
+// runAssetFsck verifies each ready asset against its file and looks for files no record references
+func runAssetFsck(repair bool, accountID *uint) {
+	err := checkAssets(repair, accountID)
+	if err != nil {
+		ErrMsg(err)
+	}
+
+	finished := time.Now().Unix()
+	if res := store.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "config_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"num_value"}),
+	}).Create(&store.Config{ConfigID: CNFFsckLastRun, NumValue: finished}).Error; res != nil {
+		ErrMsg(res)
+	}
+
+	fsckSync.Lock()
+	defer fsckSync.Unlock()
+	fsckStatus.Running = false
+	fsckStatus.Finished = finished
+	if err != nil {
+		fsckStatus.Error = err.Error()
+	}
+}
+
+func checkAssets(repair bool, accountID *uint) error {
+	path := getStrConfigValue(CNFAssetPath, APPDefaultPath)
+
+	query := store.DB
+	if accountID != nil {
+		query = query.Where("id = ?", *accountID)
+	}
+	var accounts []store.Account
+	if err := query.Find(&accounts).Error; err != nil {
+		return err
+	}
+	if accountID != nil && len(accounts) == 0 {
+		return errors.New("account not found")
+	}
+
+	known := make(map[string]bool)
+	for _, account := range accounts {
+		known[account.GUID] = true
+		if err := checkAccountAssets(path, &account, repair); err != nil {
+			return err
+		}
+	}
+	if accountID != nil {
+		return nil
+	}
+
+	// directories left behind by removed accounts
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == APPQuarantinePath || known[entry.Name()] || !isFsckAged(entry) {
+			continue
+		}
+
+		// account may have been added or renamed since listed
+		var count int64
+		if err := store.DB.Model(&store.Account{}).Where("guid = ?", entry.Name()).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		finding := FsckFinding{Kind: APPFsckAccount, GUID: entry.Name()}
+		if !fsckAccountDir.MatchString(entry.Name()) {
+			finding.Detail = "not an account directory"
+		} else if repair {
+			LogMsg("removing asset directory " + entry.Name())
+			if err := os.RemoveAll(path + "/" + entry.Name()); err != nil {
+				ErrMsg(err)
+			} else {
+				finding.Repaired = true
+			}
+		}
+		addFsckFinding(finding)
+	}
+	return nil
+}
+
+func checkAccountAssets(path string, account *store.Account, repair bool) error {
+
+	dir := path + "/" + account.GUID
+	var assets []store.Asset
+	if err := store.DB.Preload("Topic").Where("account_id = ?", account.ID).Find(&assets).Error; err != nil {
+		return err
+	}
+
+	for _, asset := range assets {
+		if asset.Status != APPAssetReady {
+			continue
+		}
+
+		// compare file with recorded crc, streams also hold segments in size
+		var detail string
+		kind := APPFsckChecksum
+		crc, size, err := scanAsset(dir + "/" + asset.AssetID)
+		if os.IsNotExist(err) {
+			kind, detail = APPFsckMissing, "asset file missing"
+		} else if err != nil {
+			ErrMsg(err)
+			continue
+		} else if asset.Transform == APPStreamTransform {
+			if _, err := os.Stat(dir + "/" + asset.AssetID + APPStreamSuffix); os.IsNotExist(err) {
+				kind, detail = APPFsckMissing, "stream segments missing"
+			} else if crc != asset.Crc {
+				detail = "checksum mismatch"
+			}
+		} else if crc != asset.Crc || size != asset.Size {
+			detail = "checksum mismatch"
+		}
+		addFsckChecked()
+		if detail == "" {
+			continue
+		}
+
+		// asset may have been removed or replaced while scanning
+		var count int64
+		if err := store.DB.Model(&store.Asset{}).Where("id = ? AND status = ? AND crc = ? AND size = ?", asset.ID, APPAssetReady, asset.Crc, asset.Size).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			continue
+		}
+
+		finding := FsckFinding{Kind: kind, AccountID: account.ID, GUID: account.GUID, AssetID: asset.AssetID, Detail: detail}
+		if repair {
+			if err := failAsset(&asset, detail); err != nil {
+				ErrMsg(err)
+			} else {
+				finding.Repaired = true
+			}
+		}
+		addFsckFinding(finding)
+	}
+	return checkAccountOrphans(dir, account, repair)
+}
+
+// checkAccountOrphans reports files no record references, locking out cleanup and uploads while records are compared
+func checkAccountOrphans(dir string, account *store.Account, repair bool) error {
+	garbageSync.Lock()
+	defer garbageSync.Unlock()
+
+	var assetIDs []string
+	if err := store.DB.Model(&store.Asset{}).Where("account_id = ?", account.ID).Pluck("asset_id", &assetIDs).Error; err != nil {
+		return err
+	}
+	referenced := make(map[string]bool)
+	for _, assetID := range assetIDs {
+		referenced[assetID] = true
+		referenced[assetID+APPStreamSuffix] = true
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, file := range files {
+		if referenced[file.Name()] || garbageHeld[file.Name()] || !isFsckAged(file) {
+			continue
+		}
+		finding := FsckFinding{Kind: APPFsckOrphan, AccountID: account.ID, GUID: account.GUID, File: file.Name()}
+		if repair {
+			LogMsg("removing file asset " + account.GUID + "/" + file.Name())
+			if err := os.RemoveAll(dir + "/" + file.Name()); err != nil {
+				ErrMsg(err)
+			} else {
+				finding.Repaired = true
+			}
+		}
+		addFsckFinding(finding)
+	}
+	return nil
+}
+
+// failAsset marks asset as failed so clients stop fetching it, releasing its storage
+func failAsset(asset *store.Asset, detail string) error {
+	asset.TransformError = detail
+	if asset.Topic != nil {
+		return updateAsset(asset, APPAssetError, 0, 0)
+	}
+	return store.DB.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Model(&store.Asset{}).Where("id = ?", asset.ID).Updates(map[string]interface{}{"status": APPAssetError, "transform_error": detail, "crc": 0, "size": 0}).Error; res != nil {
+			return res
+		}
+		return adjustStorage(tx, asset.AccountID, -asset.Size)
+	})
+}
+
+// isFsckAged skips entries recent enough to belong to an upload or transform in progress
+func isFsckAged(entry os.DirEntry) bool {
+	info, err := entry.Info()
+	if err != nil {
+		return false
+	}
+	return time.Since(info.ModTime()) > APPFsckGracePeriod*time.Second
+}
+
+func addFsckChecked() {
+	fsckSync.Lock()
+	defer fsckSync.Unlock()
+	fsckStatus.Checked++
+}
+
+func addFsckFinding(finding FsckFinding) {
+	fsckSync.Lock()
+	defer fsckSync.Unlock()
+	fsckStatus.Issues++
+	if finding.Repaired {
+		fsckStatus.Repaired++
+	}
+	if len(fsckStatus.Findings) < APPFsckMaxFindings {
+		fsckStatus.Findings = append(fsckStatus.Findings, finding)
+	}
+}
+
+// SweepAssets runs asset fsck when the configured interval has passed
+func SweepAssets() {
+	ticker := time.NewTicker(APPFsckCheckInterval * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			interval := getNumConfigValue(CNFFsckIntervalHours, 0)
+			if interval > 0 && time.Now().Unix()-getNumConfigValue(CNFFsckLastRun, 0) >= interval*3600 {
+				startAssetFsck(getBoolConfigValue(CNFFsckRepair, false), nil)
+			}
+		}
+	}
+}
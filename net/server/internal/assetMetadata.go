@@ -0,0 +1,658 @@
+package databag
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"databag/internal/store"
+	"encoding/binary"
+	"errors"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+var errMetadataFormat = errors.New("unrecognized media format")
+
+// officeTypes mime types of office documents by their main part
+var officeTypes = map[string]string{
+	"word/document.xml":    "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"xl/workbook.xml":      "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"ppt/presentation.xml": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// assetInfo media properties of stored file
+type assetInfo struct {
+	mimeType string
+	width    int
+	height   int
+	duration float64
+}
+
+// prepareUpload scrubs metadata of upload when enabled, refusing formats it cannot parse, and records its media info
+func prepareUpload(asset *store.Asset, path string) error {
+	if getBoolConfigValue(CNFScrubMetadata, false) {
+		scrubbed, err := scrubAsset(path)
+		if err != nil {
+			return err
+		}
+		if scrubbed {
+			crc, size, err := scanAsset(path)
+			if err != nil {
+				return err
+			}
+			asset.Crc = crc
+			asset.Size = size
+		}
+	}
+	setAssetInfo(asset, path)
+	return nil
+}
+
+// setAssetInfo records mime type, dimensions and duration of file on asset
+func setAssetInfo(asset *store.Asset, path string) {
+	info, err := analyzeAsset(path)
+	if err != nil {
+		ErrMsg(err)
+		return
+	}
+	asset.MimeType = info.mimeType
+	asset.Width = info.width
+	asset.Height = info.height
+	asset.Duration = info.duration
+}
+
+// analyzeAsset sniffs type of file and reads dimensions and duration of known formats
+func analyzeAsset(path string) (*assetInfo, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	info := &assetInfo{mimeType: http.DetectContentType(head)}
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		info.mimeType = getMediaType(string(head[8:12]))
+		readMediaInfo(file, 0, stat.Size(), info)
+	} else if len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE" {
+		info.mimeType = "audio/wav"
+		readWaveInfo(file, stat.Size(), info)
+	} else if strings.HasPrefix(info.mimeType, "image/") {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if config, format, err := image.DecodeConfig(bufio.NewReader(file)); err == nil {
+			info.mimeType = "image/" + format
+			info.width = config.Width
+			info.height = config.Height
+			if format == "jpeg" && getImageOrientation(readJPEGHeader(file)) >= 5 {
+				info.width, info.height = info.height, info.width
+			}
+		}
+	} else if info.mimeType == "application/zip" {
+		if archive, err := zip.NewReader(file, stat.Size()); err == nil {
+			for _, entry := range archive.File {
+				if mimeType, ok := officeTypes[entry.Name]; ok {
+					info.mimeType = mimeType
+				}
+			}
+		}
+	}
+	return info, nil
+}
+
+// readJPEGHeader returns leading bytes of jpeg holding its metadata segments
+func readJPEGHeader(file *os.File) []byte {
+	header := make([]byte, 128*1024)
+	n, _ := file.ReadAt(header, 0)
+	return header[:n]
+}
+
+func getMediaType(brand string) string {
+	switch brand {
+	case "qt  ":
+		return "video/quicktime"
+	case "M4A ", "M4B ":
+		return "audio/mp4"
+	case "heic", "heix", "mif1":
+		return "image/heic"
+	case "avif":
+		return "image/avif"
+	default:
+		return "video/mp4"
+	}
+}
+
+// readMediaInfo walks iso media boxes for movie duration and largest track dimensions
+func readMediaInfo(file *os.File, offset int64, end int64, info *assetInfo) {
+	for offset+8 <= end {
+		size, header, kind, err := readMediaBox(file, offset, end)
+		if err != nil {
+			return
+		}
+		switch kind {
+		case "moov", "trak":
+			readMediaInfo(file, offset+header, offset+size, info)
+		case "mvhd":
+			data := make([]byte, 32)
+			if _, err := file.ReadAt(data, offset+header); err != nil {
+				return
+			}
+			var scale uint32
+			var duration uint64
+			if data[0] == 1 {
+				scale = binary.BigEndian.Uint32(data[20:])
+				duration = binary.BigEndian.Uint64(data[24:])
+			} else {
+				scale = binary.BigEndian.Uint32(data[12:])
+				duration = uint64(binary.BigEndian.Uint32(data[16:]))
+			}
+			if scale > 0 {
+				info.duration = float64(duration) / float64(scale)
+			}
+		case "tkhd":
+			data := make([]byte, 96)
+			if _, err := file.ReadAt(data, offset+header); err != nil {
+				return
+			}
+			position := 76
+			if data[0] == 1 {
+				position = 88
+			}
+			width := int(binary.BigEndian.Uint32(data[position:]) >> 16)
+			height := int(binary.BigEndian.Uint32(data[position+4:]) >> 16)
+			if width*height > info.width*info.height {
+				info.width = width
+				info.height = height
+			}
+		}
+		offset += size
+	}
+}
+
+// readMediaBox reads size, header length and type of iso media box at offset
+func readMediaBox(file *os.File, offset int64, end int64) (int64, int64, string, error) {
+	data := make([]byte, 16)
+	if _, err := file.ReadAt(data[:8], offset); err != nil {
+		return 0, 0, "", err
+	}
+	size := int64(binary.BigEndian.Uint32(data))
+	header := int64(8)
+	if size == 1 {
+		if _, err := file.ReadAt(data[8:], offset+8); err != nil {
+			return 0, 0, "", err
+		}
+		size = int64(binary.BigEndian.Uint64(data[8:]))
+		header = 16
+	} else if size == 0 {
+		size = end - offset
+	}
+	if size < header || offset+size > end {
+		return 0, 0, "", errMetadataFormat
+	}
+	return size, header, string(data[4:8]), nil
+}
+
+// readWaveInfo computes duration of wave audio from its format and data chunks
+func readWaveInfo(file *os.File, end int64, info *assetInfo) {
+	var rate uint32
+	offset := int64(12)
+	for offset+8 <= end {
+		data := make([]byte, 16)
+		if _, err := file.ReadAt(data[:8], offset); err != nil {
+			return
+		}
+		size := int64(binary.LittleEndian.Uint32(data[4:]))
+		switch string(data[:4]) {
+		case "fmt ":
+			if _, err := file.ReadAt(data, offset+8); err != nil {
+				return
+			}
+			rate = binary.LittleEndian.Uint32(data[8:])
+		case "data":
+			if rate > 0 {
+				info.duration = float64(size) / float64(rate)
+			}
+			return
+		}
+		offset += 8 + size + size%2
+	}
+}
+
+// scrubAsset removes location and identifying metadata of known formats, reports if file changed
+func scrubAsset(path string) (bool, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	head := make([]byte, 12)
+	n, _ := io.ReadFull(file, head)
+	file.Close()
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8}):
+		return rewriteAsset(path, scrubJPEG)
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return rewriteAsset(path, scrubPNG)
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return scrubMedia(path)
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return scrubOffice(path)
+	}
+	return false, nil
+}
+
+// rewriteAsset replaces file with scrubbed copy, malformed files are reported rather than kept with their metadata
+func rewriteAsset(path string, scrub func(data []byte) ([]byte, error)) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	scrubbed, err := scrub(data)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(scrubbed, data) {
+		return false, nil
+	}
+	if err := os.WriteFile(path+".scrub", scrubbed, 0600); err != nil {
+		return false, err
+	}
+	if err := os.Rename(path+".scrub", path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// scrubJPEG drops exif, xmp, iptc and comment segments, keeping only orientation
+func scrubJPEG(data []byte) ([]byte, error) {
+	var scrubbed bytes.Buffer
+	scrubbed.Write(data[:2])
+	if orientation := getImageOrientation(data); orientation > 1 {
+		exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00")
+		exif[25] = byte(orientation)
+		scrubbed.Write([]byte{0xFF, 0xE1, byte((len(exif) + 2) >> 8), byte(len(exif) + 2)})
+		scrubbed.Write(exif)
+	}
+
+	offset := 2
+	for {
+		if offset+4 > len(data) || data[offset] != 0xFF {
+			return nil, errMetadataFormat
+		}
+		marker := data[offset+1]
+		if marker == 0xFF {
+			offset++
+			continue
+		}
+		if marker == 0xDA {
+			scrubbed.Write(data[offset:])
+			return scrubbed.Bytes(), nil
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if length < 2 || offset+2+length > len(data) {
+			return nil, errMetadataFormat
+		}
+		if marker != 0xE1 && marker != 0xED && marker != 0xFE {
+			scrubbed.Write(data[offset : offset+2+length])
+		}
+		offset += 2 + length
+	}
+}
+
+// scrubPNG drops exif, text and timestamp chunks
+func scrubPNG(data []byte) ([]byte, error) {
+	var scrubbed bytes.Buffer
+	scrubbed.Write(data[:8])
+	offset := 8
+	for offset < len(data) {
+		if offset+12 > len(data) {
+			return nil, errMetadataFormat
+		}
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		if offset+12+length > len(data) {
+			return nil, errMetadataFormat
+		}
+		switch string(data[offset+4 : offset+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			scrubbed.Write(data[offset : offset+12+length])
+		}
+		offset += 12 + length
+	}
+	return scrubbed.Bytes(), nil
+}
+
+// scrubMedia blanks user data and metadata boxes of movie and tracks in place so sample offsets are kept
+func scrubMedia(path string) (bool, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	return blankMediaBoxes(file, 0, stat.Size(), false)
+}
+
+func blankMediaBoxes(file *os.File, offset int64, end int64, movie bool) (bool, error) {
+	blanked := false
+	for offset+8 <= end {
+		size, header, kind, err := readMediaBox(file, offset, end)
+		if err != nil {
+			return blanked, nil
+		}
+		if kind == "moov" || kind == "trak" {
+			res, err := blankMediaBoxes(file, offset+header, offset+size, true)
+			if err != nil {
+				return false, err
+			}
+			blanked = blanked || res
+		} else if movie && (kind == "udta" || kind == "meta") {
+			if _, err := file.WriteAt([]byte("free"), offset+header-4); err != nil {
+				return false, err
+			}
+			if err := blankMediaRange(file, offset+header, offset+size); err != nil {
+				return false, err
+			}
+			blanked = true
+		} else if !movie && kind == "meta" {
+			// heif image items share the meta box, so only exif and xmp items are blanked
+			res, err := blankMediaItems(file, offset, size, header)
+			if err != nil {
+				return false, err
+			}
+			blanked = blanked || res
+		}
+		offset += size
+	}
+	return blanked, nil
+}
+
+// blankMediaRange zeroes bytes of file between start and end
+func blankMediaRange(file *os.File, start int64, end int64) error {
+	zero := make([]byte, 32*1024)
+	for position := start; position < end; position += int64(len(zero)) {
+		count := end - position
+		if count > int64(len(zero)) {
+			count = int64(len(zero))
+		}
+		if _, err := file.WriteAt(zero[:count], position); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blankMediaItems zeroes data of exif and xmp items located by top level meta box of heif images
+func blankMediaItems(file *os.File, offset int64, size int64, header int64) (bool, error) {
+	if size-header < 4 || size-header > APPBodyLimit {
+		return false, errMetadataFormat
+	}
+	data := make([]byte, size-header)
+	if _, err := file.ReadAt(data, offset+header); err != nil {
+		return false, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	// collect metadata items and their locations
+	items := map[uint32]bool{}
+	var iloc []byte
+	idat := int64(-1)
+	for position := 4; position+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[position:]))
+		if length < 8 || position+length > len(data) {
+			return false, errMetadataFormat
+		}
+		switch string(data[position+4 : position+8]) {
+		case "iinf":
+			if err := readMediaItemTypes(data[position+8:position+length], items); err != nil {
+				return false, err
+			}
+		case "iloc":
+			iloc = data[position+8 : position+length]
+		case "idat":
+			idat = offset + header + int64(position) + 8
+		}
+		position += length
+	}
+	if len(items) == 0 {
+		return false, nil
+	}
+	if iloc == nil {
+		return false, errMetadataFormat
+	}
+	extents, err := readMediaItemExtents(iloc, items, idat)
+	if err != nil {
+		return false, err
+	}
+	for _, extent := range extents {
+		if extent[0] < 0 || extent[1] <= extent[0] || extent[1] > stat.Size() {
+			return false, errMetadataFormat
+		}
+	}
+	for _, extent := range extents {
+		if err := blankMediaRange(file, extent[0], extent[1]); err != nil {
+			return false, err
+		}
+	}
+	return len(extents) > 0, nil
+}
+
+// readMediaItemTypes adds ids of exif and xmp items listed in item info box
+func readMediaItemTypes(data []byte, items map[uint32]bool) error {
+	if len(data) < 6 {
+		return errMetadataFormat
+	}
+	position := 6
+	if data[0] != 0 {
+		position = 8
+	}
+	for position+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[position:]))
+		if length < 8 || position+length > len(data) {
+			return errMetadataFormat
+		}
+		entry := data[position+8 : position+length]
+		position += length
+		if len(entry) < 12 || entry[0] < 2 {
+			continue
+		}
+		var id uint32
+		kind := 8
+		if entry[0] == 2 {
+			id = uint32(binary.BigEndian.Uint16(entry[4:]))
+		} else if len(entry) >= 14 {
+			id = binary.BigEndian.Uint32(entry[4:])
+			kind = 10
+		} else {
+			return errMetadataFormat
+		}
+		switch string(entry[kind : kind+4]) {
+		case "Exif":
+			items[id] = true
+		case "mime":
+			fields := bytes.Split(entry[kind+4:], []byte{0})
+			if len(fields) > 1 && string(fields[1]) == "application/rdf+xml" {
+				items[id] = true
+			}
+		}
+	}
+	return nil
+}
+
+// readMediaItemExtents returns file ranges of selected items from item location box
+func readMediaItemExtents(data []byte, items map[uint32]bool, idat int64) ([][2]int64, error) {
+	position := 0
+	read := func(size int) (int64, error) {
+		if position+size > len(data) {
+			return 0, errMetadataFormat
+		}
+		var value uint64
+		switch size {
+		case 0:
+		case 2:
+			value = uint64(binary.BigEndian.Uint16(data[position:]))
+		case 4:
+			value = uint64(binary.BigEndian.Uint32(data[position:]))
+		case 8:
+			value = binary.BigEndian.Uint64(data[position:])
+		default:
+			return 0, errMetadataFormat
+		}
+		position += size
+		return int64(value), nil
+	}
+	if len(data) < 6 {
+		return nil, errMetadataFormat
+	}
+	version := data[0]
+	offsetSize := int(data[4] >> 4)
+	lengthSize := int(data[4] & 0x0F)
+	baseSize := int(data[5] >> 4)
+	indexSize := 0
+	if version == 1 || version == 2 {
+		indexSize = int(data[5] & 0x0F)
+	}
+	position = 6
+	countSize := 2
+	if version == 2 {
+		countSize = 4
+	}
+	count, err := read(countSize)
+	if err != nil {
+		return nil, err
+	}
+
+	extents := [][2]int64{}
+	for i := int64(0); i < count; i++ {
+		id, err := read(countSize)
+		if err != nil {
+			return nil, err
+		}
+		method := int64(0)
+		if version == 1 || version == 2 {
+			if method, err = read(2); err != nil {
+				return nil, err
+			}
+			method &= 0x0F
+		}
+		if _, err := read(2); err != nil {
+			return nil, err
+		}
+		base, err := read(baseSize)
+		if err != nil {
+			return nil, err
+		}
+		extentCount, err := read(2)
+		if err != nil {
+			return nil, err
+		}
+		for j := int64(0); j < extentCount; j++ {
+			if _, err := read(indexSize); err != nil {
+				return nil, err
+			}
+			extentOffset, err := read(offsetSize)
+			if err != nil {
+				return nil, err
+			}
+			extentLength, err := read(lengthSize)
+			if err != nil {
+				return nil, err
+			}
+			if !items[uint32(id)] {
+				continue
+			}
+			start := base + extentOffset
+			if method == 1 && idat >= 0 {
+				start += idat
+			} else if method != 0 || extentLength == 0 {
+				return nil, errMetadataFormat
+			}
+			extents = append(extents, [2]int64{start, start + extentLength})
+		}
+	}
+	return extents, nil
+}
+
+// scrubOffice clears author and revision properties of office documents
+func scrubOffice(path string) (bool, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		ErrMsg(err)
+		return false, errMetadataFormat
+	}
+	defer archive.Close()
+
+	core := false
+	for _, entry := range archive.File {
+		if entry.Name == "docProps/core.xml" {
+			core = true
+		}
+	}
+	if !core {
+		return false, nil
+	}
+
+	output, err := os.OpenFile(path+".scrub", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return false, err
+	}
+	writer := zip.NewWriter(output)
+	for _, entry := range archive.File {
+		if entry.Name == "docProps/core.xml" {
+			part, err := writer.CreateHeader(&zip.FileHeader{Name: entry.Name, Method: zip.Deflate})
+			if err == nil {
+				_, err = part.Write([]byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+					`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties"/>`))
+			}
+			if err != nil {
+				output.Close()
+				return false, err
+			}
+			continue
+		}
+		raw, err := entry.OpenRaw()
+		if err == nil {
+			var part io.Writer
+			if part, err = writer.CreateRaw(&entry.FileHeader); err == nil {
+				_, err = io.Copy(part, raw)
+			}
+		}
+		if err != nil {
+			output.Close()
+			return false, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		output.Close()
+		return false, err
+	}
+	if err := output.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Rename(path+".scrub", path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
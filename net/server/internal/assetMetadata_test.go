@@ -0,0 +1,217 @@
+package databag
+
+import (
+	"archive/zip"
+	"bytes"
+	"databag/internal/store"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/clause"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAssetMetadata(t *testing.T) {
+
+	dir := t.TempDir()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			img.Set(x, y, color.RGBA{0, 128, 255, 255})
+		}
+	}
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, data, 0600))
+		return path
+	}
+	read := func(path string) []byte {
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		return data
+	}
+
+	// jpeg keeps orientation but loses location and comment
+	var encoded bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&encoded, img, nil))
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x02\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x88\x25\x00\x04\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00GPS+37.7749-122.4194")
+	segment := append([]byte{0xFF, 0xE1, byte((len(exif) + 2) >> 8), byte(len(exif) + 2)}, exif...)
+	comment := []byte("author: jane")
+	segment = append(segment, 0xFF, 0xFE, 0, byte(len(comment)+2))
+	segment = append(segment, comment...)
+	photo := write("photo", append(append(append([]byte{}, encoded.Bytes()[:2]...), segment...), encoded.Bytes()[2:]...))
+	info, err := analyzeAsset(photo)
+	assert.NoError(t, err)
+	assert.Equal(t, &assetInfo{mimeType: "image/jpeg", width: 20, height: 40}, info)
+	scrubbed, err := scrubAsset(photo)
+	assert.NoError(t, err)
+	assert.True(t, scrubbed)
+	data := read(photo)
+	assert.False(t, bytes.Contains(data, []byte("GPS")))
+	assert.False(t, bytes.Contains(data, []byte("jane")))
+	assert.Equal(t, 6, getImageOrientation(data))
+	_, err = jpeg.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	scrubbed, err = scrubAsset(photo)
+	assert.NoError(t, err)
+	assert.False(t, scrubbed)
+	truncated := write("truncated", append([]byte{0xFF, 0xD8}, segment[:8]...))
+	_, err = scrubAsset(truncated)
+	assert.ErrorIs(t, err, errMetadataFormat)
+
+	// png loses text chunks
+	encoded.Reset()
+	assert.NoError(t, png.Encode(&encoded, img))
+	text := append([]byte("Author\x00jane"), 0, 0, 0, 0)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)-4))
+	chunk = append(append(chunk, "tEXt"...), text...)
+	graphic := write("graphic", append(append(append([]byte{}, encoded.Bytes()[:33]...), chunk...), encoded.Bytes()[33:]...))
+	scrubbed, err = scrubAsset(graphic)
+	assert.NoError(t, err)
+	assert.True(t, scrubbed)
+	assert.Equal(t, encoded.Bytes(), read(graphic))
+
+	// movie reports duration and size, user data blanked in place
+	box := func(kind string, payload ...[]byte) []byte {
+		data := bytes.Join(payload, nil)
+		return append(append(binary.BigEndian.AppendUint32(nil, uint32(len(data)+8)), kind...), data...)
+	}
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], 5500)
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], 640<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], 360<<16)
+	movie := bytes.Join([][]byte{
+		box("ftyp", []byte("isom\x00\x00\x02\x00isommp41")),
+		box("moov", box("mvhd", mvhd), box("trak", box("tkhd", tkhd)), box("udta", box("\xa9xyz", []byte("+37.7749-122.4194/")))),
+		box("mdat", []byte("samples")),
+	}, nil)
+	video := write("video", movie)
+	info, err = analyzeAsset(video)
+	assert.NoError(t, err)
+	assert.Equal(t, &assetInfo{mimeType: "video/mp4", width: 640, height: 360, duration: 5.5}, info)
+	scrubbed, err = scrubAsset(video)
+	assert.NoError(t, err)
+	assert.True(t, scrubbed)
+	data = read(video)
+	assert.Equal(t, len(movie), len(data))
+	assert.False(t, bytes.Contains(data, []byte("+37.7749")))
+	assert.True(t, bytes.HasSuffix(data, []byte("samples")))
+
+	// heif image loses exif item but keeps image item
+	heifExif := append([]byte("\x00\x00\x00\x06Exif\x00\x00MM\x00\x2a"), "GPS+37.7749-122.4194"...)
+	infe := func(id byte, kind string) []byte {
+		return box("infe", []byte{2, 0, 0, 0, 0, id, 0, 0}, []byte(kind), []byte{0})
+	}
+	heifMeta := func(exifOffset int, pixelOffset int) []byte {
+		iloc := []byte{0, 0, 0, 0, 0x44, 0x00, 0, 2}
+		for _, item := range [][3]int{{1, pixelOffset, 6}, {2, exifOffset, len(heifExif)}} {
+			iloc = append(iloc, 0, byte(item[0]), 0, 0, 0, 1)
+			iloc = binary.BigEndian.AppendUint32(iloc, uint32(item[1]))
+			iloc = binary.BigEndian.AppendUint32(iloc, uint32(item[2]))
+		}
+		return box("meta", []byte{0, 0, 0, 0}, box("hdlr", make([]byte, 21)), box("pitm", []byte{0, 0, 0, 0, 0, 1}),
+			box("iinf", []byte{0, 0, 0, 0, 0, 2}, infe(1, "hvc1"), infe(2, "Exif")), box("iloc", iloc))
+	}
+	heifType := box("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+	start := len(heifType) + len(heifMeta(0, 0)) + 8
+	heif := bytes.Join([][]byte{heifType, heifMeta(start+6, start), box("mdat", []byte("pixels"), heifExif)}, nil)
+	picture := write("picture", heif)
+	info, err = analyzeAsset(picture)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/heic", info.mimeType)
+	scrubbed, err = scrubAsset(picture)
+	assert.NoError(t, err)
+	assert.True(t, scrubbed)
+	data = read(picture)
+	assert.Equal(t, len(heif), len(data))
+	assert.False(t, bytes.Contains(data, []byte("+37.7749")))
+	assert.True(t, bytes.Contains(data, []byte("pixels")))
+	assert.Equal(t, heif[:start], data[:start])
+
+	// wave duration from byte rate
+	wave := make([]byte, 44+16000)
+	copy(wave, "RIFF\x00\x00\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x01\x00\x40\x1f\x00\x00\x80\x3e\x00\x00\x02\x00\x10\x00data")
+	binary.LittleEndian.PutUint32(wave[40:], 16000)
+	info, err = analyzeAsset(write("audio", wave))
+	assert.NoError(t, err)
+	assert.Equal(t, &assetInfo{mimeType: "audio/wav", duration: 1}, info)
+
+	// document loses author
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for name, content := range map[string]string{
+		"word/document.xml": "<document>body</document>",
+		"docProps/core.xml": "<cp:coreProperties><dc:creator>jane</dc:creator></cp:coreProperties>",
+	} {
+		part, err := writer.Create(name)
+		assert.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	document := write("document", archive.Bytes())
+	info, err = analyzeAsset(document)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.wordprocessingml.document", info.mimeType)
+	scrubbed, err = scrubAsset(document)
+	assert.NoError(t, err)
+	assert.True(t, scrubbed)
+	reader, err := zip.OpenReader(document)
+	assert.NoError(t, err)
+	for _, entry := range reader.File {
+		part, err := entry.Open()
+		assert.NoError(t, err)
+		var content bytes.Buffer
+		_, err = content.ReadFrom(part)
+		assert.NoError(t, err)
+		assert.False(t, bytes.Contains(content.Bytes(), []byte("jane")))
+	}
+	assert.NoError(t, reader.Close())
+
+	// uploads scrubbed only when node enables it
+	setScrub := func(scrub bool) {
+		assert.NoError(t, store.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"bool_value"}),
+		}).Create(&store.Config{ConfigID: CNFScrubMetadata, BoolValue: scrub}).Error)
+	}
+	defer setScrub(false)
+
+	guid, token, params, err := addTestChannelTopic("assetmetadata", "video")
+	assert.NoError(t, err)
+	upload := func() string {
+		assets := []Asset{}
+		assert.NoError(t, APITestUpload(AddChannelTopicAsset, "POST", "/content/channels/{channelID}/topics/{topicID}/assets",
+			&params, movie, APPTokenAgent, token, &assets, nil))
+		return assets[0].AssetID
+	}
+	stored := func(assetID string) []byte {
+		return read(getStrConfigValue(CNFAssetPath, APPDefaultPath) + "/" + guid + "/" + assetID)
+	}
+
+	kept := upload()
+	assert.True(t, bytes.Contains(stored(kept), []byte("+37.7749")))
+	setScrub(true)
+	cleaned := upload()
+	assert.False(t, bytes.Contains(stored(cleaned), []byte("+37.7749")))
+	assert.Error(t, APITestUpload(AddChannelTopicAsset, "POST", "/content/channels/{channelID}/topics/{topicID}/assets",
+		&params, read(truncated), APPTokenAgent, token, nil, nil))
+
+	assets := []Asset{}
+	assert.NoError(t, APITestMsg(GetChannelTopicAssets, "GET", "/content/channels/{channelID}/topics/{topicID}/assets",
+		&params, nil, APPTokenAgent, token, &assets, nil))
+	assert.Equal(t, 2, len(assets))
+	for _, asset := range assets {
+		assert.Equal(t, "video/mp4", asset.MimeType)
+		assert.Equal(t, 640, asset.Width)
+		assert.Equal(t, 360, asset.Height)
+		assert.Equal(t, 5.5, asset.Duration)
+	}
+}
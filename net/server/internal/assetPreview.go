@@ -0,0 +1,154 @@
+package databag
+
+import (
+	"bytes"
+	"databag/internal/store"
+	"image"
+	"math"
+	"os"
+	"strings"
+)
+
+const blurhashCharacters = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// setAssetPreview stores blurhash of photo and video transform output that decodes as an image
+func setAssetPreview(asset *store.Asset, path string) {
+	if asset.TransformQueue != APPQueuePhoto && asset.TransformQueue != APPQueueVideo {
+		return
+	}
+	preview, err := getImagePreview(path)
+	if err != nil {
+		return
+	}
+	asset.Preview = preview
+}
+
+// getImagePreview computes blurhash of image file, errors for non image output
+func getImagePreview(path string) (string, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	if int64(config.Width)*int64(config.Height) > APPImageMaxPixels {
+		return "", errNativeUnsupported
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	img := scaleImage(orientImage(src, getImageOrientation(data)), APPPreviewSize, true)
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+	if width == 0 || height == 0 {
+		return "", errNativeUnsupported
+	}
+
+	// more components along longer side
+	xComponents, yComponents := APPPreviewComponents, APPPreviewComponents
+	if width > height {
+		yComponents = APPPreviewComponents - 1
+	} else if height > width {
+		xComponents = APPPreviewComponents - 1
+	}
+	return encodeBlurhash(flattenImage(img), xComponents, yComponents), nil
+}
+
+// encodeBlurhash encodes image as blurhash with specified components, see blurha.sh
+func encodeBlurhash(img image.Image, xComponents int, yComponents int) string {
+
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+
+	// linear color of each pixel
+	pixels := make([][3]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			pixels[y*width+x] = [3]float64{srgbToLinear(r >> 8), srgbToLinear(g >> 8), srgbToLinear(b >> 8)}
+		}
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1.0
+			}
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := normalisation * math.Cos(math.Pi*float64(i*x)/float64(width)) * math.Cos(math.Pi*float64(j*y)/float64(height))
+					pixel := pixels[y*width+x]
+					factor[0] += basis * pixel[0]
+					factor[1] += basis * pixel[1]
+					factor[2] += basis * pixel[2]
+				}
+			}
+			scale := 1.0 / float64(width*height)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(encodeBase83((xComponents-1)+(yComponents-1)*9, 1))
+
+	maximum := 1.0
+	if len(factors) > 1 {
+		actual := 0.0
+		for _, factor := range factors[1:] {
+			actual = math.Max(actual, math.Max(math.Abs(factor[0]), math.Max(math.Abs(factor[1]), math.Abs(factor[2]))))
+		}
+		quantised := int(math.Max(0, math.Min(82, math.Floor(actual*166-0.5))))
+		maximum = float64(quantised+1) / 166
+		hash.WriteString(encodeBase83(quantised, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+
+	dc := factors[0]
+	hash.WriteString(encodeBase83(linearToSrgb(dc[0])<<16+linearToSrgb(dc[1])<<8+linearToSrgb(dc[2]), 4))
+	for _, factor := range factors[1:] {
+		quantise := func(value float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(value/maximum, 0.5)*9+9.5))))
+		}
+		hash.WriteString(encodeBase83(quantise(factor[0])*19*19+quantise(factor[1])*19+quantise(factor[2]), 2))
+	}
+	return hash.String()
+}
+
+func encodeBase83(value int, length int) string {
+	digits := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		digits[i] = blurhashCharacters[value%83]
+		value /= 83
+	}
+	return string(digits)
+}
+
+func srgbToLinear(value uint32) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value float64, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
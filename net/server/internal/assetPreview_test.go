@@ -0,0 +1,94 @@
+package databag
+
+import (
+	"bytes"
+	"databag/internal/store"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/png"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAssetPreview(t *testing.T) {
+
+	dir := t.TempDir()
+	solid := func(width int, height int) []byte {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			}
+		}
+		var encoded bytes.Buffer
+		assert.NoError(t, png.Encode(&encoded, img))
+		return encoded.Bytes()
+	}
+	decode := func(hash string) int {
+		value := 0
+		for _, c := range hash {
+			value = value*83 + strings.IndexRune(blurhashCharacters, c)
+		}
+		return value
+	}
+
+	// landscape uses 4x3 components with average color leading
+	landscape := filepath.Join(dir, "landscape")
+	assert.NoError(t, os.WriteFile(landscape, solid(80, 40), 0600))
+	hash, err := getImagePreview(landscape)
+	assert.NoError(t, err)
+	assert.Equal(t, 6+2*(4*3-1), len(hash))
+	assert.Equal(t, 3+2*9, decode(hash[:1]))
+	assert.Equal(t, 0xFF0000, decode(hash[2:6]))
+
+	// portrait uses 3x4 components
+	portrait := filepath.Join(dir, "portrait")
+	assert.NoError(t, os.WriteFile(portrait, solid(40, 80), 0600))
+	hash, err = getImagePreview(portrait)
+	assert.NoError(t, err)
+	assert.Equal(t, 2+3*9, decode(hash[:1]))
+
+	// only image output of photo and video queues gets preview
+	other := filepath.Join(dir, "other")
+	assert.NoError(t, os.WriteFile(other, []byte("not an image"), 0600))
+	_, err = getImagePreview(other)
+	assert.Error(t, err)
+	asset := &store.Asset{TransformQueue: APPQueueAudio}
+	setAssetPreview(asset, landscape)
+	assert.Empty(t, asset.Preview)
+
+	// preview returned with assets and topic detail
+	_, token, params, err := addTestChannelTopic("assetpreview", "photo")
+	assert.NoError(t, err)
+	transforms, err := json.Marshal([]string{"ithumb;photo"})
+	assert.NoError(t, err)
+	assets := []Asset{}
+	assert.NoError(t, APITestUpload(AddChannelTopicAsset, "POST", "/content/channels/{channelID}/topics/{topicID}/assets?transforms="+url.QueryEscape(string(transforms)),
+		&params, solid(80, 40), APPTokenAgent, token, &assets, nil))
+	assetID := assets[1].AssetID
+	assert.Eventually(t, func() bool {
+		list := []Asset{}
+		assert.NoError(t, APITestMsg(GetChannelTopicAssets, "GET", "/content/channels/{channelID}/topics/{topicID}/assets",
+			&params, nil, APPTokenAgent, token, &list, nil))
+		for _, entry := range list {
+			if entry.AssetID == assetID && entry.Status == APPAssetReady {
+				return entry.Preview != ""
+			}
+		}
+		return false
+	}, 5*time.Second, 100*time.Millisecond)
+	detail := &Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopic, "GET", "/content/channels/{channelID}/topics/{topicID}/detail",
+		&params, nil, APPTokenAgent, token, detail, nil))
+	assert.Equal(t, 1, len(detail.Data.TopicDetail.Previews))
+	assert.Equal(t, assetID, detail.Data.TopicDetail.Previews[0].AssetID)
+	average := decode(detail.Data.TopicDetail.Previews[0].Preview[2:6])
+	assert.Greater(t, average>>16, 0xF0)
+	assert.Less(t, average&0xFFFF, 0x1010)
+}
@@ -0,0 +1,146 @@
+package databag
+
+import (
+	"bytes"
+	"context"
+	"databag/internal/store"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// screenUpload scans saved upload, infected files are quarantined and their asset failed with reason
+func screenUpload(ctx context.Context, asset *store.Asset, guid string, path string) error {
+	threat, err := scanFile(ctx, path)
+	if err != nil {
+		return err
+	}
+	if threat == "" {
+		return nil
+	}
+	LogMsg("quarantining asset " + guid + "/" + asset.AssetID + ": " + threat)
+	if err := quarantineFile(guid, asset.AssetID, path); err != nil {
+		return err
+	}
+	asset.Status = APPAssetError
+	asset.TransformError = "malware detected: " + threat
+	asset.Size = 0
+	asset.Crc = 0
+	return nil
+}
+
+// scanFile returns name of threat found in file by configured scanner, empty if clean or scanning disabled
+func scanFile(ctx context.Context, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, APPScanTimeout*time.Second)
+	defer cancel()
+
+	switch getStrConfigValue(CNFScanMode, "") {
+	case "":
+		return "", nil
+	case APPScanClamd:
+		return scanClamd(ctx, getStrConfigValue(CNFScanAddress, ""), path)
+	case APPScanScript:
+		return scanScript(ctx, getStrConfigValue(CNFScriptPath, ".")+"/scan_asset.sh", path)
+	default:
+		return "", errors.New("unsupported scan mode")
+	}
+}
+
+// scanClamd streams file to clamd with INSTREAM command
+func scanClamd(ctx context.Context, address string, path string) (string, error) {
+
+	network := "tcp"
+	if strings.HasPrefix(address, "unix:") {
+		network = "unix"
+		address = strings.TrimPrefix(address, "unix:")
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return "", err
+	}
+	chunk := make([]byte, 4+APPScanChunkSize)
+	for {
+		n, res := file.Read(chunk[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(chunk, uint32(n))
+			if _, err := conn.Write(chunk[:4+n]); err != nil {
+				return "", err
+			}
+		}
+		if res == io.EOF {
+			break
+		}
+		if res != nil {
+			return "", res
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return "", err
+	}
+
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		return "", err
+	}
+	result := strings.TrimSpace(string(bytes.TrimRight(reply, "\x00")))
+	result = strings.TrimPrefix(result, "stream: ")
+	if result == "OK" {
+		return "", nil
+	}
+	if strings.HasSuffix(result, " FOUND") {
+		return strings.TrimSuffix(result, " FOUND"), nil
+	}
+	return "", errors.New("clamd scan failed: " + result)
+}
+
+// scanScript runs scan script on file, exit 1 reports threat named on stdout
+func scanScript(ctx context.Context, script string, path string) (string, error) {
+	cmd := exec.CommandContext(ctx, script, path)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err == nil {
+		return "", nil
+	}
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 1 {
+		threat := strings.TrimSpace(stdout.String())
+		if threat == "" {
+			threat = "unknown"
+		}
+		return threat, nil
+	}
+	LogMsg(stderr.String())
+	return "", err
+}
+
+// quarantineFile moves infected upload out of account storage
+func quarantineFile(guid string, id string, path string) error {
+	dir := getStrConfigValue(CNFAssetPath, APPDefaultPath) + "/" + APPQuarantinePath
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return os.Rename(path, dir+"/"+guid+"_"+id)
+}
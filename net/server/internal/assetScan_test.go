@@ -0,0 +1,182 @@
+package databag
+
+import (
+	"bytes"
+	"context"
+	"databag/internal/store"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/clause"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testEicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// serveTestClamd answers INSTREAM scans, flagging streams holding eicar string
+func serveTestClamd(listener net.Listener, scanned func()) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			command := make([]byte, len("zINSTREAM\x00"))
+			if _, err := io.ReadFull(conn, command); err != nil {
+				return
+			}
+			var data bytes.Buffer
+			for {
+				var size uint32
+				if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+					return
+				}
+				if size == 0 {
+					break
+				}
+				if _, err := io.CopyN(&data, conn, int64(size)); err != nil {
+					return
+				}
+			}
+			if scanned != nil {
+				scanned()
+			}
+			if bytes.Contains(data.Bytes(), []byte(testEicar)) {
+				conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+			} else {
+				conn.Write([]byte("stream: OK\x00"))
+			}
+		}(conn)
+	}
+}
+
+func TestAssetScan(t *testing.T) {
+
+	dir := t.TempDir()
+	infected := filepath.Join(dir, "infected")
+	assert.NoError(t, os.WriteFile(infected, []byte(testEicar), 0600))
+	clean := filepath.Join(dir, "clean")
+	assert.NoError(t, os.WriteFile(clean, bytes.Repeat([]byte("clean"), APPScanChunkSize), 0600))
+
+	// clamd over tcp and unix socket
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer tcp.Close()
+	go serveTestClamd(tcp, nil)
+	unix, err := net.Listen("unix", filepath.Join(dir, "clamd.sock"))
+	assert.NoError(t, err)
+	defer unix.Close()
+	go serveTestClamd(unix, nil)
+	for _, address := range []string{tcp.Addr().String(), "unix:" + filepath.Join(dir, "clamd.sock")} {
+		threat, err := scanClamd(context.Background(), address, infected)
+		assert.NoError(t, err)
+		assert.Equal(t, "Eicar-Test-Signature", threat)
+		threat, err = scanClamd(context.Background(), address, clean)
+		assert.NoError(t, err)
+		assert.Empty(t, threat)
+	}
+
+	// script names threat with exit status 1
+	script := filepath.Join(dir, "scan_asset.sh")
+	assert.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\nif grep -q EICAR $1; then echo Eicar-Test-Signature; exit 1; fi\n"), 0555))
+	threat, err := scanScript(context.Background(), script, infected)
+	assert.NoError(t, err)
+	assert.Equal(t, "Eicar-Test-Signature", threat)
+	threat, err = scanScript(context.Background(), script, clean)
+	assert.NoError(t, err)
+	assert.Empty(t, threat)
+
+	// uploads are quarantined when infected
+	setScan := func(mode string, address string) {
+		assert.NoError(t, store.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"str_value"}),
+		}).Create([]store.Config{{ConfigID: CNFScanMode, StrValue: mode}, {ConfigID: CNFScanAddress, StrValue: address}}).Error)
+	}
+	defer setScan("", "")
+	setScan(APPScanClamd, tcp.Addr().String())
+
+	guid, token, params, err := addTestChannelTopic("assetscan", "binary")
+	assert.NoError(t, err)
+	transforms, err := json.Marshal([]string{"copy;default"})
+	assert.NoError(t, err)
+	upload := func(data []byte) ([]Asset, error) {
+		assets := []Asset{}
+		err := APITestUpload(AddChannelTopicAsset, "POST", "/content/channels/{channelID}/topics/{topicID}/assets?transforms="+url.QueryEscape(string(transforms)),
+			&params, data, APPTokenAgent, token, &assets, nil)
+		return assets, err
+	}
+	assetPath := getStrConfigValue(CNFAssetPath, APPDefaultPath)
+
+	assets, err := upload([]byte("clean binary " + testEicar[:10]))
+	assert.NoError(t, err)
+	assert.Equal(t, APPAssetReady, assets[0].Status)
+	assert.Equal(t, APPAssetWaiting, assets[1].Status)
+
+	assets, err = upload([]byte("binary " + testEicar))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(assets))
+	for _, asset := range assets {
+		assert.Equal(t, APPAssetError, asset.Status)
+		assert.Equal(t, "malware detected: Eicar-Test-Signature", asset.Error)
+	}
+	_, err = os.Stat(assetPath + "/" + guid + "/" + assets[0].AssetID)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(assetPath + "/" + APPQuarantinePath + "/" + guid + "_" + assets[0].AssetID)
+	assert.NoError(t, err)
+
+	// block uploads are scanned too
+	block := &Asset{}
+	assert.NoError(t, APITestUpload(AddChannelTopicBlock, "POST", "/content/channels/{channelID}/topics/{topicID}/blocks?body=multipart",
+		&params, []byte(testEicar), APPTokenAgent, token, block, nil))
+	assert.Equal(t, APPAssetError, block.Status)
+
+	// cleanup runs during scan and keeps held upload
+	account := &store.Account{}
+	assert.NoError(t, store.DB.Where("guid = ?", guid).First(account).Error)
+	collecting, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer collecting.Close()
+	blocked := false
+	go serveTestClamd(collecting, func() {
+		if !garbageSync.TryLock() {
+			blocked = true
+			return
+		}
+		garbageSync.Unlock()
+		garbageCollect(account)
+	})
+	setScan(APPScanClamd, collecting.Addr().String())
+	assets, err = upload([]byte("collected"))
+	assert.NoError(t, err)
+	assert.False(t, blocked)
+	data, err := os.ReadFile(assetPath + "/" + guid + "/" + assets[0].AssetID)
+	assert.NoError(t, err)
+	assert.Equal(t, "collected", string(data))
+
+	// unavailable scanner rejects upload
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	setScan(APPScanClamd, closed.Addr().String())
+	closed.Close()
+	assert.Eventually(t, func() bool {
+		var count int64
+		assert.NoError(t, store.DB.Model(&store.Asset{}).Where("account_id = ? AND status IN ?", account.ID,
+			[]string{APPAssetWaiting, APPAssetProcessing}).Count(&count).Error)
+		return count == 0
+	}, 5*time.Second, 100*time.Millisecond)
+	files, err := os.ReadDir(assetPath + "/" + guid)
+	assert.NoError(t, err)
+	_, err = upload([]byte("unscanned"))
+	assert.Error(t, err)
+	remaining, err := os.ReadDir(assetPath + "/" + guid)
+	assert.NoError(t, err)
+	assert.Equal(t, len(files), len(remaining))
+}
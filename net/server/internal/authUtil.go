@@ -1,9 +1,13 @@
 package databag
 
 import (
+	"crypto/sha256"
 	"databag/internal/store"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 	"net/http"
@@ -63,6 +67,54 @@ func AccountLogin(r *http.Request) (*store.Account, error) {
 	return account, nil
 }
 
+// AccountCode validates totp code param when account has mfa enabled, counting failures toward lockout
+func AccountCode(r *http.Request, account *store.Account) (int, error) {
+
+	curTime := time.Now().Unix()
+	if account.MFAFailedTime+APPMFAFailPeriod > curTime && account.MFAFailedCount > APPMFAFailCount {
+		return http.StatusTooManyRequests, errors.New("temporarily locked")
+	}
+	if !account.MFAEnabled || !account.MFAConfirmed {
+		return http.StatusOK, nil
+	}
+
+	code := r.FormValue("code")
+	if code == "" {
+		return http.StatusMethodNotAllowed, errors.New("totp code required")
+	}
+
+	algorithm := otp.AlgorithmSHA256
+	if account.MFAAlgorithm == APPMFASHA1 {
+		algorithm = otp.AlgorithmSHA1
+	}
+	opts := totp.ValidateOpts{Period: 30, Skew: 1, Digits: otp.DigitsSix, Algorithm: algorithm}
+	if valid, _ := totp.ValidateCustom(code, account.MFASecret, time.Now(), opts); !valid {
+		err := store.DB.Transaction(func(tx *gorm.DB) error {
+			if account.MFAFailedTime+APPMFAFailPeriod > curTime {
+				account.MFAFailedCount += 1
+				if res := tx.Model(account).Update("mfa_failed_count", account.MFAFailedCount).Error; res != nil {
+					return res
+				}
+			} else {
+				account.MFAFailedTime = curTime
+				if res := tx.Model(account).Update("mfa_failed_time", account.MFAFailedTime).Error; res != nil {
+					return res
+				}
+				account.MFAFailedCount = 1
+				if res := tx.Model(account).Update("mfa_failed_count", account.MFAFailedCount).Error; res != nil {
+					return res
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			LogMsg("failed to increment fail count")
+		}
+		return http.StatusForbidden, errors.New("invalid code")
+	}
+	return http.StatusOK, nil
+}
+
 // BearerAccountToken retrieves AccountToken object specified by authorization header
 func BearerAccountToken(r *http.Request) (*store.AccountToken, error) {
 
@@ -211,6 +263,15 @@ func GetSession(r *http.Request) (*store.Session, int, error) {
 
 // ParamAgentToken retrieves account specified by agent query param
 func ParamAgentToken(r *http.Request, detail bool) (*store.Account, int, error) {
+	return paramAgentToken(r, detail, false)
+}
+
+// ParamPosterToken retrieves account specified by agent query param, also accepting bot api keys
+func ParamPosterToken(r *http.Request, detail bool) (*store.Account, int, error) {
+	return paramAgentToken(r, detail, true)
+}
+
+func paramAgentToken(r *http.Request, detail bool, keys bool) (*store.Account, int, error) {
 
 	// parse authentication token
 	target, access, err := ParseToken(r.FormValue("agent"))
@@ -236,6 +297,13 @@ func ParamAgentToken(r *http.Request, detail bool) (*store.Account, int, error)
 		}
 	}
 
+	if session.ID == 0 {
+		if keys {
+			return paramBotKey(target, access, detail)
+		}
+		return nil, http.StatusUnauthorized, errors.New("invalid agent token")
+	}
+
 	if session.Account.Disabled {
 		return nil, http.StatusGone, errors.New("account is inactive")
 	}
@@ -243,6 +311,41 @@ func ParamAgentToken(r *http.Request, detail bool) (*store.Account, int, error)
 	return &session.Account, http.StatusOK, nil
 }
 
+func paramBotKey(target string, access string, detail bool) (*store.Account, int, error) {
+
+	// find api key record
+	var key store.ApiKey
+	query := store.DB.Preload("Account")
+	if detail {
+		query = store.DB.Preload("Account.AccountDetail")
+	}
+	if err := query.Where("account_id = ? AND token = ?", target, getApiKeyHash(access)).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusUnauthorized, errors.New("invalid agent token")
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if !key.Account.Bot {
+		return nil, http.StatusUnauthorized, errors.New("api key requires bot account")
+	}
+	if key.Account.Disabled {
+		return nil, http.StatusGone, errors.New("account is inactive")
+	}
+
+	if err := store.DB.Model(&key).Update("last_used", time.Now().Unix()).Error; err != nil {
+		ErrMsg(err)
+	}
+
+	return &key.Account, http.StatusOK, nil
+}
+
+// getApiKeyHash hex sha256 of api key, keys are only stored hashed
+func getApiKeyHash(access string) string {
+	sum := sha256.Sum256([]byte(access))
+	return hex.EncodeToString(sum[:])
+}
+
 // BearerAppToken retrieves account specified by authorization header
 func BearerAppToken(r *http.Request, detail bool) (*store.Account, int, error) {
 
@@ -278,14 +381,52 @@ func BearerAppToken(r *http.Request, detail bool) (*store.Account, int, error) {
 	return &app.Account, http.StatusOK, nil
 }
 
+// ParamWorkerToken retrieves remote transcode worker specified by worker query param
+func ParamWorkerToken(r *http.Request) (*store.TranscodeWorker, int, error) {
+
+	// parse authentication token
+	split := strings.Split(r.FormValue("worker"), ".")
+	if len(split) != 2 {
+		return nil, http.StatusBadRequest, errors.New("invalid token format")
+	}
+
+	// find worker record
+	var worker store.TranscodeWorker
+	if err := store.DB.Where("worker_id = ? AND token = ?", split[0], split[1]).First(&worker).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			RecordIPAuthFailure(getClientIP(r))
+			return nil, http.StatusUnauthorized, errors.New("invalid worker token")
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if err := store.DB.Model(&worker).Update("last_seen", time.Now().Unix()).Error; err != nil {
+		ErrMsg(err)
+	}
+	return &worker, http.StatusOK, nil
+}
+
 // ParseToken separates access token into its guid and random value parts
 func ParseToken(token string) (string, string, error) {
 
 	split := strings.Split(token, ".")
-	if len(split) != 2 {
+	if len(split) != 2 || split[1] == "" {
 		return "", "", errors.New("invalid token format")
 	}
-	return split[0], split[1], nil
+	return getSuccessorGUID(split[0]), split[1], nil
+}
+
+// tokens issued before a key rotation continue to reference the prior guid
+func getSuccessorGUID(guid string) string {
+	var succession store.Succession
+	if err := store.DB.Where("prior_guid = ?", guid).Limit(1).Find(&succession).Error; err != nil {
+		ErrMsg(err)
+		return guid
+	}
+	if succession.ID == 0 {
+		return guid
+	}
+	return succession.GUID
 }
 
 // ParamTokenType returns type of access token specified
@@ -310,24 +451,53 @@ func ParamTokenType(r *http.Request) string {
 
 // ParamContactToken retrieves card specified by contact query param
 func ParamContactToken(r *http.Request, detail bool) (*store.Card, int, error) {
+	return paramContactToken(r, detail, true)
+}
+
+// nodes verifying a contact retrieve its profile, which must not in turn wait on verification
+func paramContactToken(r *http.Request, detail bool, verify bool) (*store.Card, int, error) {
 
 	// parse authentication token
 	target, access, err := ParseToken(r.FormValue("contact"))
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
+	return getContactCard(target, access, detail, verify)
+}
+
+// ParamCardContact retrieves card of a local contact through the account's own card, so api keys
+// can post to channels the bot was added to without a contact token
+func ParamCardContact(account *store.Account, cardID string, detail bool) (*store.Card, int, error) {
+
+	var slot store.CardSlot
+	if err := store.DB.Preload("Card").Where("account_id = ? AND card_slot_id = ?", account.ID, cardID).First(&slot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusNotFound, err
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	if slot.Card == nil || slot.Card.Status != APPCardConnected || slot.Card.OutToken == "" {
+		return nil, http.StatusNotFound, errors.New("card not connected")
+	}
+
+	// only contacts hosted on this node hold the matching token
+	return getContactCard(slot.Card.GUID, slot.Card.OutToken, detail, true)
+}
+
+func getContactCard(target string, access string, detail bool, verify bool) (*store.Card, int, error) {
 
 	// find token record
 	var card store.Card
+	var err error
 	if detail {
-		if err := store.DB.Preload("CardSlot").Preload("Account.AccountDetail").Where("account_id = ? AND in_token = ?", target, access).First(&card).Error; err != nil {
+		if err := store.DB.Preload("CardSlot").Preload("Account.AccountDetail").Where("account_id = ? AND (in_token = ? OR prior_in_token = ?)", target, access, access).First(&card).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, http.StatusNotFound, err
 			}
 			return nil, http.StatusInternalServerError, err
 		}
 	} else {
-		if err := store.DB.Preload("CardSlot").Preload("Account").Where("account_id = ? AND in_token = ?", target, access).First(&card).Error; err != nil {
+		if err := store.DB.Preload("CardSlot").Preload("Account").Where("account_id = ? AND (in_token = ? OR prior_in_token = ?)", target, access, access).First(&card).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, http.StatusNotFound, err
 			}
@@ -340,6 +510,21 @@ func ParamContactToken(r *http.Request, detail bool) (*store.Card, int, error) {
 	if card.Status != APPCardConnecting && card.Status != APPCardConnected {
 		return nil, http.StatusUnauthorized, errors.New("invalid connection state")
 	}
+	if card.Blocked {
+		return nil, http.StatusUnauthorized, errors.New("contact is blocked")
+	}
+	var federated bool
+	if verify {
+		federated, err = isCardFederated(&card)
+	} else {
+		federated, err = isNodeFederated(card.Node)
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if !federated {
+		return nil, http.StatusForbidden, errors.New("contact node not federated")
+	}
 
 	return &card, http.StatusOK, nil
 }
@@ -358,14 +543,14 @@ func BearerContactToken(r *http.Request, detail bool) (*store.Card, int, error)
 	// find token record
 	var card store.Card
 	if detail {
-		if err := store.DB.Preload("Account.AccountDetail").Where("account_id = ? AND in_token = ?", target, access).First(&card).Error; err != nil {
+		if err := store.DB.Preload("Account.AccountDetail").Where("account_id = ? AND (in_token = ? OR prior_in_token = ?)", target, access, access).First(&card).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, http.StatusNotFound, err
 			}
 			return nil, http.StatusInternalServerError, err
 		}
 	} else {
-		if err := store.DB.Preload("Account").Where("account_id = ? AND in_token = ?", target, access).First(&card).Error; err != nil {
+		if err := store.DB.Preload("Account").Where("account_id = ? AND (in_token = ? OR prior_in_token = ?)", target, access, access).First(&card).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, http.StatusNotFound, err
 			}
@@ -378,6 +563,16 @@ func BearerContactToken(r *http.Request, detail bool) (*store.Card, int, error)
 	if card.Status != APPCardConnecting && card.Status != APPCardConnected {
 		return nil, http.StatusUnauthorized, errors.New("invalid connection state")
 	}
+	if card.Blocked {
+		return nil, http.StatusUnauthorized, errors.New("contact is blocked")
+	}
+	federated, err := isCardFederated(&card)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if !federated {
+		return nil, http.StatusForbidden, errors.New("contact node not federated")
+	}
 
 	return &card, http.StatusOK, nil
 }
@@ -17,31 +17,28 @@ var garbageCollectWG sync.WaitGroup
 var cleanupSchedulerSync sync.Mutex
 
 func StartCleanupScheduler() {
-	if !getCleanupEnabled() {
-		return
-	}
-
 	interval := getCleanupIntervalHours()
 	if interval <= 0 {
 		interval = 168
 	}
 
-	go runCleanupScheduler(interval)
+	// retention chosen by accounts applies even when node wide cleanup is disabled
+	go runCleanupScheduler(interval, getCleanupEnabled())
 }
 
-func runCleanupScheduler(intervalHours int64) {
+func runCleanupScheduler(intervalHours int64, enabled bool) {
 	ticker := time.NewTicker(time.Duration(intervalHours) * time.Hour)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			executeScheduledCleanup()
+			executeScheduledCleanup(enabled)
 		}
 	}
 }
 
-func executeScheduledCleanup() {
+func executeScheduledCleanup(enabled bool) {
 	cleanupSchedulerSync.Lock()
 	defer cleanupSchedulerSync.Unlock()
 
@@ -53,22 +50,21 @@ func executeScheduledCleanup() {
 		return
 	}
 
-	messageRetention := getMessageRetentionDays()
-	if messageRetention <= 0 {
-		messageRetention = 90
-	}
-
-	assetRetention := getAssetRetentionDays()
-	if assetRetention <= 0 {
-		assetRetention = 180
-	}
+	// without node wide cleanup only accounts with their own retention are cleaned
+	var messageRetention, assetRetention int64
+	if enabled {
+		messageRetention = getMessageRetentionDays()
+		if messageRetention <= 0 {
+			messageRetention = 90
+		}
 
-	retentionDays := messageRetention
-	if assetRetention < retentionDays {
-		retentionDays = assetRetention
+		assetRetention = getAssetRetentionDays()
+		if assetRetention <= 0 {
+			assetRetention = 180
+		}
 	}
 
-	response, err := performCleanup(retentionDays, true)
+	response, err := performCleanup(messageRetention, assetRetention)
 	if err != nil {
 		ErrMsg(err)
 		return
@@ -78,7 +74,7 @@ func executeScheduledCleanup() {
 
 	// 新增：IP封禁清理
 	var ipCleanupResult int64
-	if getIPBlockCleanupEnabled() {
+	if enabled && getIPBlockCleanupEnabled() {
 		ipCleanupResult = cleanupExpiredIPBlocks()
 	}
 
@@ -99,8 +95,10 @@ func setCleanupLastRun(timestamp int64) {
 	}
 }
 
-func performCleanup(retentionDays int64, includeAssets bool) (*CleanupResponse, error) {
-	cutoffTime := time.Now().Unix() - (retentionDays * 86400)
+// performCleanup removes topics and assets older than each account's retention, node defaults otherwise,
+// where zero days keeps content of accounts without their own retention
+func performCleanup(topicDays int64, assetDays int64) (*CleanupResponse, error) {
+	now := time.Now().Unix()
 	batchSize := 1000
 	var response CleanupResponse
 
@@ -112,7 +110,12 @@ func performCleanup(retentionDays int64, includeAssets bool) (*CleanupResponse,
 	response.AffectedAccounts = len(accounts)
 
 	for _, account := range accounts {
-		for {
+		var revised []uint
+		var removed []uint
+		result := CleanupAccount{AccountID: account.ID}
+		result.TopicDays, result.AssetDays = getAccountRetention(&account, topicDays, assetDays)
+		cutoffTime := now - (result.TopicDays * 86400)
+		for result.TopicDays > 0 {
 			var topics []store.Topic
 			err := store.DB.Where("account_id = ? AND created < ?", account.ID, cutoffTime).
 				Limit(batchSize).
@@ -129,52 +132,75 @@ func performCleanup(retentionDays int64, includeAssets bool) (*CleanupResponse,
 			var topicIDs []uint
 			for _, topic := range topics {
 				topicIDs = append(topicIDs, topic.ID)
+				removed = append(removed, topic.TopicSlotID)
 			}
 
 			err = store.DB.Transaction(func(tx *gorm.DB) error {
-				if includeAssets {
-					var assets []store.Asset
-					if res := tx.Where("topic_id IN ?", topicIDs).Find(&assets).Error; res != nil {
-						return res
-					}
-					response.DeletedAssets += int64(len(assets))
-					response.FreedBytes += calculateAssetSize(assets)
-
-					if res := tx.Where("topic_id IN ?", topicIDs).Delete(&store.Asset{}).Error; res != nil {
-						return res
-					}
-				}
-
-				if res := tx.Where("topic_id IN ?", topicIDs).Delete(&store.Tag{}).Error; res != nil {
-					return res
-				}
-
-				if res := tx.Where("topic_id IN ?", topicIDs).Delete(&store.TagSlot{}).Error; res != nil {
+				var assets []store.Asset
+				if res := tx.Where("topic_id IN ?", topicIDs).Find(&assets).Error; res != nil {
 					return res
 				}
+				result.DeletedAssets += int64(len(assets))
+				result.FreedBytes += calculateAssetSize(assets)
 
-				if res := tx.Where("id IN ?", topicIDs).Delete(&store.Topic{}).Error; res != nil {
+				if res := removeAssets(tx, "topic_id IN ?", topicIDs); res != nil {
 					return res
 				}
 
-				if res := tx.Where("topic_slot_id IN ?", topicIDs).Delete(&store.TopicSlot{}).Error; res != nil {
+				parents, res := releaseTopicParents(tx, topicIDs)
+				if res != nil {
 					return res
 				}
+				revised = append(revised, parents...)
 
-				return nil
+				return removeTopics(tx, topicIDs)
 			})
 
 			if err != nil {
 				return nil, err
 			}
 
-			response.DeletedTopics += int64(len(topics))
+			result.DeletedTopics += int64(len(topics))
 
 			if len(topics) < batchSize {
 				break
 			}
 		}
 
+		// assets may be kept for less time than the topics referencing them
+		var assets []store.Asset
+		if result.AssetDays > 0 {
+			if err := store.DB.Where("account_id = ? AND topic_id != 0 AND created < ?", account.ID, now-(result.AssetDays*86400)).Find(&assets).Error; err != nil {
+				return nil, err
+			}
+		}
+		if len(assets) > 0 {
+			var assetIDs []uint
+			for _, asset := range assets {
+				assetIDs = append(assetIDs, asset.ID)
+				revised = append(revised, asset.TopicID)
+			}
+			if err := store.DB.Transaction(func(tx *gorm.DB) error { return removeAssets(tx, "id IN ?", assetIDs) }); err != nil {
+				return nil, err
+			}
+			result.DeletedAssets += int64(len(assets))
+			result.FreedBytes += calculateAssetSize(assets)
+		}
+
+		if err := setTopicRevisions(&account, revised); err != nil {
+			return nil, err
+		}
+		if err := setRemovedTopicRevisions(&account, removed); err != nil {
+			return nil, err
+		}
+
+		response.DeletedTopics += result.DeletedTopics
+		response.DeletedAssets += result.DeletedAssets
+		response.FreedBytes += result.FreedBytes
+		if result.DeletedTopics > 0 || result.DeletedAssets > 0 {
+			response.Accounts = append(response.Accounts, result)
+		}
+
 		garbageCollectWG.Add(1)
 		go func(acc *store.Account) {
 			garbageCollectSemaphore <- struct{}{}
@@ -188,11 +214,48 @@ func performCleanup(retentionDays int64, includeAssets bool) (*CleanupResponse,
 
 	garbageCollectWG.Wait()
 
-	cleanupOrphanedAssets(cutoffTime)
+	orphanDays := topicDays
+	if assetDays < orphanDays {
+		orphanDays = assetDays
+	}
+	if orphanDays > 0 {
+		cleanupOrphanedAssets(now - (orphanDays * 86400))
+	}
 
 	return &response, nil
 }
 
+// getRetentionBounds range of retention days accounts may choose
+func getRetentionBounds() (int64, int64) {
+	minDays := getNumConfigValue(CNFRetentionMinDays, APPRetentionMinDays)
+	if minDays < 1 {
+		minDays = 1
+	}
+	maxDays := getNumConfigValue(CNFRetentionMaxDays, APPRetentionMaxDays)
+	if maxDays < minDays {
+		maxDays = minDays
+	}
+	return minDays, maxDays
+}
+
+// account retention applies within current bounds, falling back to node defaults
+func getAccountRetention(account *store.Account, topicDays int64, assetDays int64) (int64, int64) {
+	minDays, maxDays := getRetentionBounds()
+	clamp := func(days int64, fallback int64) int64 {
+		if days == 0 {
+			return fallback
+		}
+		if days < minDays {
+			return minDays
+		}
+		if days > maxDays {
+			return maxDays
+		}
+		return days
+	}
+	return clamp(account.TopicRetention, topicDays), clamp(account.AssetRetention, assetDays)
+}
+
 func getIPBlockCleanupEnabled() bool {
 	return getBoolConfigValue("DATABAG_IP_BLOCK_CLEANUP_ENABLED", true)
 }
@@ -217,3 +280,171 @@ func calculateAssetSize(assets []store.Asset) int64 {
 	}
 	return total
 }
+
+// releaseTopicParents lowers reply count of thread parents kept while their published replies are removed
+func releaseTopicParents(tx *gorm.DB, topicIDs []uint) ([]uint, error) {
+	var replies []struct {
+		ParentID uint
+		Count    int64
+	}
+	if res := tx.Model(&store.TopicSlot{}).Select("topic_slots.parent_id, COUNT(*) AS count").
+		Joins("JOIN topics ON topics.topic_slot_id = topic_slots.id").
+		Where("topics.id IN ? AND topic_slots.parent_id != 0 AND (topics.publish = 0 OR topics.status = ?)", topicIDs, APPTopicConfirmed).Group("topic_slots.parent_id").Scan(&replies).Error; res != nil {
+		return nil, res
+	}
+
+	var parents []uint
+	for _, reply := range replies {
+		var parent store.Topic
+		if res := tx.Where("topic_slot_id = ? AND id NOT IN ?", reply.ParentID, topicIDs).Find(&parent).Error; res != nil {
+			return nil, res
+		}
+		if parent.ID == 0 {
+			continue
+		}
+		if res := tx.Model(&parent).Update("reply_count", gorm.Expr("CASE WHEN reply_count > ? THEN reply_count - ? ELSE 0 END", reply.Count, reply.Count)).Error; res != nil {
+			return nil, res
+		}
+		parents = append(parents, parent.ID)
+	}
+	return parents, nil
+}
+
+// setTopicRevisions bumps revision of changed topics and their channels, notifying members as RemoveChannelTopicAsset does
+func setTopicRevisions(act *store.Account, topicIDs []uint) error {
+	if len(topicIDs) == 0 {
+		return nil
+	}
+
+	var topics []store.Topic
+	err := store.DB.Transaction(func(tx *gorm.DB) error {
+		if res := tx.First(act, act.ID).Error; res != nil {
+			return res
+		}
+		if res := tx.Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Where("id IN ?", topicIDs).Find(&topics).Error; res != nil {
+			return res
+		}
+		var slotIDs []uint
+		var channelIDs []int
+		for _, topic := range topics {
+			slotIDs = append(slotIDs, topic.TopicSlotID)
+			channelIDs = append(channelIDs, topic.ChannelID)
+		}
+		if len(topics) == 0 {
+			return nil
+		}
+
+		revision := act.ChannelRevision + 1
+		if res := tx.Model(&store.Topic{}).Where("id IN ?", topicIDs).Update("detail_revision", revision).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(&store.TopicSlot{}).Where("id IN ?", slotIDs).Update("revision", revision).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(&store.Channel{}).Where("id IN ?", channelIDs).Update("topic_revision", revision).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(&store.ChannelSlot{}).Where("channel_id IN ?", channelIDs).Update("revision", revision).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(act).Update("channel_revision", revision).Error; res != nil {
+			return res
+		}
+		return nil
+	})
+	if err != nil || len(topics) == 0 {
+		return err
+	}
+
+	var channels []*store.Channel
+	for _, topic := range topics {
+		channels = append(channels, topic.Channel)
+	}
+	setChannelNotifications(act, channels)
+	return nil
+}
+
+// removeTopics deletes topics with their tags and reactions, leaving slots for members to sync removal as removeTopicSlot does
+func removeTopics(tx *gorm.DB, topicIDs []uint) error {
+	if res := tx.Where("topic_id IN ?", topicIDs).Delete(&store.Tag{}).Error; res != nil {
+		return res
+	}
+	if res := tx.Where("topic_id IN ?", topicIDs).Delete(&store.Reaction{}).Error; res != nil {
+		return res
+	}
+	if res := tx.Where("topic_id IN ?", topicIDs).Delete(&store.TagSlot{}).Error; res != nil {
+		return res
+	}
+	for _, topicID := range topicIDs {
+		cancelTopicTranscode(topicID)
+	}
+	return tx.Where("id IN ?", topicIDs).Delete(&store.Topic{}).Error
+}
+
+// setRemovedTopicRevisions bumps revision of slots left by removed topics and their channels, notifying members
+func setRemovedTopicRevisions(act *store.Account, slotIDs []uint) error {
+	if len(slotIDs) == 0 {
+		return nil
+	}
+
+	var channels []store.Channel
+	err := store.DB.Transaction(func(tx *gorm.DB) error {
+		if res := tx.First(act, act.ID).Error; res != nil {
+			return res
+		}
+		var channelIDs []int
+		if res := tx.Model(&store.TopicSlot{}).Where("id IN ?", slotIDs).Distinct().Pluck("channel_id", &channelIDs).Error; res != nil {
+			return res
+		}
+		if res := tx.Preload("Members.Card").Preload("Groups.Cards").Where("id IN ?", channelIDs).Find(&channels).Error; res != nil {
+			return res
+		}
+
+		revision := act.ChannelRevision + 1
+		if res := tx.Model(&store.TopicSlot{}).Where("id IN ?", slotIDs).Update("revision", revision).Error; res != nil {
+			return res
+		}
+		if len(channelIDs) > 0 {
+			if res := tx.Model(&store.Channel{}).Where("id IN ?", channelIDs).Update("topic_revision", revision).Error; res != nil {
+				return res
+			}
+			if res := tx.Model(&store.ChannelSlot{}).Where("channel_id IN ?", channelIDs).Update("revision", revision).Error; res != nil {
+				return res
+			}
+		}
+		return tx.Model(act).Update("channel_revision", revision).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	var notify []*store.Channel
+	for i := range channels {
+		notify = append(notify, &channels[i])
+	}
+	setChannelNotifications(act, notify)
+	return nil
+}
+
+// setChannelNotifications notifies members and viewers of changed channels
+func setChannelNotifications(act *store.Account, channels []*store.Channel) {
+	cards := make(map[string]store.Card)
+	for _, channel := range channels {
+		if channel == nil {
+			continue
+		}
+		for _, member := range channel.Members {
+			cards[member.Card.GUID] = member.Card
+		}
+		for _, group := range channel.Groups {
+			for _, card := range group.Cards {
+				cards[card.GUID] = card
+			}
+		}
+	}
+
+	SetStatus(act)
+	for _, card := range cards {
+		SetContactChannelNotification(act, &card)
+	}
+}
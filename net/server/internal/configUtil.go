@@ -33,6 +33,36 @@ const CNFAssetPath = "asset_path"
 // CNFScriptPath specifies the path where transform scripts are found
 const CNFScriptPath = "script_path"
 
+// CNFVideoWorkers specifies number of concurrent video transforms
+const CNFVideoWorkers = "video_workers"
+
+// CNFAudioWorkers specifies number of concurrent audio transforms
+const CNFAudioWorkers = "audio_workers"
+
+// CNFPhotoWorkers specifies number of concurrent photo transforms
+const CNFPhotoWorkers = "photo_workers"
+
+// CNFDefaultWorkers specifies number of concurrent other transforms
+const CNFDefaultWorkers = "default_workers"
+
+// CNFRemoteTranscode specifies whether transforms are left to remote workers
+const CNFRemoteTranscode = "remote_transcode"
+
+// CNFScanMode specifies whether uploads are scanned by clamd or script, empty for no scan
+const CNFScanMode = "scan_mode"
+
+// CNFScanAddress specifies clamd socket as unix:path or host:port
+const CNFScanAddress = "scan_address"
+
+// CNFScrubMetadata specifies whether location and identifying metadata is removed from uploads
+const CNFScrubMetadata = "scrub_metadata"
+
+// CNFWebhookPrivate specifies whether webhooks may target plain http and private network addresses
+const CNFWebhookPrivate = "webhook_private"
+
+// CNFScriptTransform specifies whether scripts replace the native image transforms
+const CNFScriptTransform = "script_transform"
+
 // CNFAllowUnsealed specified if plantext channels can be created
 const CNFAllowUnsealed = "allow_unsealed"
 
@@ -66,6 +96,9 @@ const CNFIceUsername = "ice_username"
 // CNFIceUrl specifies the ice candidate url
 const CNFIcePassword = "ice_password"
 
+// CNFAPIHost specifies optional host and path delegated to serve the api
+const CNFAPIHost = "api_host"
+
 // CNFMFAFailedTime start of mfa failure window
 const CNFMFAFailedTime = "mfa_failed_time"
 
@@ -123,9 +156,24 @@ const CNFMessageRetentionDays = "message_retention_days"
 // CNFAssetRetentionDays specifies how long to keep assets
 const CNFAssetRetentionDays = "asset_retention_days"
 
+// CNFRetentionMinDays specifies shortest retention an account may choose
+const CNFRetentionMinDays = "retention_min_days"
+
+// CNFRetentionMaxDays specifies longest retention an account may choose
+const CNFRetentionMaxDays = "retention_max_days"
+
 // CNFCleanupLastRun tracks last cleanup execution time
 const CNFCleanupLastRun = "cleanup_last_run"
 
+// CNFFsckIntervalHours specifies hours between scheduled asset fsck runs, 0 for none
+const CNFFsckIntervalHours = "fsck_interval_hours"
+
+// CNFFsckRepair specifies whether scheduled asset fsck repairs findings
+const CNFFsckRepair = "fsck_repair"
+
+// CNFFsckLastRun tracks last asset fsck completion time
+const CNFFsckLastRun = "fsck_last_run"
+
 func getStrConfigValue(configID string, empty string) string {
 	var config store.Config
 	err := store.DB.Where("config_id = ?", configID).First(&config).Error
@@ -0,0 +1,143 @@
+package databag
+
+import (
+	"context"
+	"databag/internal/store"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func getContactNode(node string) string {
+	return strings.ToLower(strings.TrimSpace(node))
+}
+
+// node rules only apply when account blocks or allows senders by node
+func usesContactNodes(account *store.Account) (bool, error) {
+	if account.ContactPolicy == APPContactNodes {
+		return true, nil
+	}
+	var count int64
+	if err := store.DB.Model(&store.ContactBlock{}).Where("account_id = ? AND node != ''", account.ID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count != 0, nil
+}
+
+// isVerifiedNode checks the node claimed by sender actually hosts the sender
+func isVerifiedNode(guid string, connect *Connect) (bool, error) {
+	node := getContactNode(connect.Node)
+	if node == "" || node == getContactNode(getStrConfigValue(CNFDomain, "")) {
+		var count int64
+		if err := store.DB.Model(&store.Account{}).Where("guid = ?", guid).Count(&count).Error; err != nil {
+			return false, err
+		}
+		return count != 0, nil
+	}
+	if err := verifyContactNode(connect.Node, guid, connect.Token); err != nil {
+		LogMsg("unverified contact node " + node + ": " + err.Error())
+		return false, nil
+	}
+	return true, nil
+}
+
+// node hosting sender holds the connection token and signs the sender profile with it
+func verifyContactNode(node string, guid string, token string) error {
+	base, _ := getNodeDiscovery(node)
+	req, err := http.NewRequest(http.MethodGet, base+"/profile/message?contact="+url.QueryEscape(guid+"."+token), nil)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), APPDiscoveryTimeout*time.Second)
+	defer cancel()
+	resp, err := getFederationClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("profile not available from node")
+	}
+
+	var message DataMessage
+	if err := json.NewDecoder(io.LimitReader(resp.Body, APPBodyLimit)).Decode(&message); err != nil {
+		return err
+	}
+	var identity Identity
+	signer, messageType, _, err := ReadDataMessage(&message, &identity)
+	if err != nil {
+		return err
+	}
+	if messageType != APPMsgIdentity || signer != guid {
+		return errors.New("profile not signed by sender")
+	}
+	return nil
+}
+
+// blocked senders are ignored without letting them know
+func isContactBlocked(account *store.Account, guid string, node string) (bool, error) {
+	var count int64
+	if err := store.DB.Model(&store.ContactBlock{}).Where("account_id = ? AND (guid = ? OR (node != '' AND node = ?))",
+		account.ID, guid, getContactNode(node)).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count != 0 {
+		return true, nil
+	}
+	if err := store.DB.Model(&store.Card{}).Where("account_id = ? AND guid = ? AND blocked = ?", account.GUID, guid, true).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count != 0, nil
+}
+
+// muted and blocked contacts keep syncing without push notifications
+func sendContactPushEvent(card *store.Card, event string) {
+	if card.Muted || card.Blocked {
+		return
+	}
+	SendPushEvent(card.Account, event)
+}
+
+// new contact requests must satisfy account policy and sender rate limit
+func checkContactRequest(account *store.Account, guid string, node string) (int, error) {
+
+	switch account.ContactPolicy {
+	case APPContactNobody:
+		return http.StatusForbidden, errors.New("account not accepting contact requests")
+	case APPContactNodes:
+		allowed := false
+		for _, listed := range strings.Split(account.ContactNodes, ",") {
+			if listed != "" && listed == getContactNode(node) {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return http.StatusForbidden, errors.New("account not accepting contact requests from node")
+		}
+	case APPContactMutual:
+		// only contacts of contacts hosted on this node are known, remote contact lists are private
+		var count int64
+		contacts := store.DB.Model(&store.Card{}).Select("guid").Where("account_id = ? AND status = ?", account.GUID, APPCardConnected)
+		if err := store.DB.Model(&store.Card{}).Where("guid = ? AND status = ? AND account_id IN (?)", guid, APPCardConnected, contacts).Count(&count).Error; err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if count == 0 {
+			return http.StatusForbidden, errors.New("account only accepting contact requests from contacts of contacts")
+		}
+	}
+
+	var count int64
+	since := time.Now().Unix() - getContactRequestPeriod()
+	if err := store.DB.Model(&store.Card{}).Where("guid = ? AND status = ? AND created > ?", guid, APPCardPending, since).Count(&count).Error; err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if count >= getContactRequestLimit() {
+		return http.StatusTooManyRequests, errors.New("too many contact requests from sender")
+	}
+
+	return http.StatusOK, nil
+}
@@ -0,0 +1,151 @@
+package databag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type nodeDiscovery struct {
+	base      string
+	discovery *NodeDiscovery
+	expires   int64
+}
+
+var discoveryCache = make(map[string]*nodeDiscovery)
+var discoveryPending = make(map[string][]func())
+var discoverySync sync.Mutex
+
+// legacyKeyTypes identity keys verifiable by nodes predating discovery
+var legacyKeyTypes = []string{APPRSA2048, APPRSA4096}
+
+// getCachedDiscovery returns unexpired discovery of node without fetching, false if it must be resolved
+func getCachedDiscovery(node string) (string, *NodeDiscovery, bool) {
+	discoverySync.Lock()
+	defer discoverySync.Unlock()
+	cached, set := discoveryCache[node]
+	if !set || cached.expires <= time.Now().Unix() {
+		return "", nil, false
+	}
+	return cached.base, cached.discovery, true
+}
+
+// refreshNodeDiscovery resolves discovery of node in background, calling resolved once it is cached
+func refreshNodeDiscovery(node string, resolved func()) {
+	discoverySync.Lock()
+	waiting, fetching := discoveryPending[node]
+	discoveryPending[node] = append(waiting, resolved)
+	discoverySync.Unlock()
+	if fetching {
+		return
+	}
+
+	go func() {
+		getNodeDiscovery(node)
+		discoverySync.Lock()
+		waiting := discoveryPending[node]
+		delete(discoveryPending, node)
+		discoverySync.Unlock()
+		for _, resolved := range waiting {
+			resolved()
+		}
+	}()
+}
+
+// getNodeDiscovery resolves api base url and advertised capabilities of a federated node
+func getNodeDiscovery(node string) (string, *NodeDiscovery) {
+
+	now := time.Now().Unix()
+	discoverySync.Lock()
+	cached, set := discoveryCache[node]
+	discoverySync.Unlock()
+	if set && cached.expires > now {
+		return cached.base, cached.discovery
+	}
+
+	// nodes predating discovery serve the api at their domain
+	cached = &nodeDiscovery{base: "https://" + node, expires: now + APPDiscoveryRetry}
+	discovery, err := fetchNodeDiscovery(node)
+	if err != nil {
+		LogMsg("no discovery document for " + node)
+	} else {
+		cached.discovery = discovery
+		cached.expires = now + APPDiscoveryExpire
+		if discovery.API != "" {
+			cached.base = "https://" + strings.TrimSuffix(discovery.API, "/")
+		}
+	}
+
+	discoverySync.Lock()
+	discoveryCache[node] = cached
+	discoverySync.Unlock()
+	return cached.base, cached.discovery
+}
+
+// well-known document is served at root of host even for subpath deployments
+func fetchNodeDiscovery(node string) (*NodeDiscovery, error) {
+
+	host := strings.SplitN(node, "/", 2)[0]
+	req, err := http.NewRequest(http.MethodGet, "https://"+host+APPDiscoveryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), APPDiscoveryTimeout*time.Second)
+	defer cancel()
+	resp, err := getFederationClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("discovery document not available")
+	}
+
+	var discovery NodeDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&discovery); err != nil {
+		return nil, err
+	}
+	if strings.Contains(discovery.API, "://") {
+		return nil, errors.New("invalid delegated api host")
+	}
+	return &discovery, nil
+}
+
+// nodeCompatible checks advertised api version shares major version of this node
+func nodeCompatible(discovery *NodeDiscovery) bool {
+	if discovery == nil || discovery.Version == "" {
+		return true
+	}
+	return strings.SplitN(discovery.Version, ".", 2)[0] == strings.SplitN(APPVersion, ".", 2)[0]
+}
+
+// getNodeSignType selects signature for key type that contact node can verify
+func getNodeSignType(discovery *NodeDiscovery, keyType string) (string, error) {
+	keyTypes := legacyKeyTypes
+	if discovery != nil {
+		keyTypes = discovery.KeyTypes
+	}
+	for _, supported := range keyTypes {
+		if supported == keyType {
+			return getSignType(keyType), nil
+		}
+	}
+	return "", errors.New("contact node does not support key type " + keyType)
+}
+
+// nodes without discovery document predate capabilities and support none of them
+func nodeCapable(discovery *NodeDiscovery, capability string) bool {
+	if discovery == nil {
+		return false
+	}
+	for _, supported := range discovery.Capabilities {
+		if supported == capability {
+			return true
+		}
+	}
+	return false
+}
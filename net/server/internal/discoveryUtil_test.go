@@ -0,0 +1,98 @@
+package databag
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNodeDiscoveryDocument(t *testing.T) {
+
+	discovery := &NodeDiscovery{}
+	params := &TestAPIParams{query: "/.well-known/databag"}
+	response := &TestAPIResponse{data: discovery}
+	assert.NoError(t, TestAPIRequest(GetNodeDiscovery, params, response))
+	assert.Equal(t, APPVersion, discovery.Version)
+	assert.Contains(t, discovery.KeyTypes, APPED25519)
+	assert.Contains(t, discovery.Capabilities, APPCapabilitySuccession)
+	assert.Empty(t, discovery.API)
+}
+
+func TestNodeDiscoveryResolve(t *testing.T) {
+
+	advertised := &NodeDiscovery{Version: APPVersion, Capabilities: []string{APPCapabilityCalls}}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != APPDiscoveryPath || advertised == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(advertised)
+	}))
+	defer server.Close()
+	client := getFederationClient()
+	federationClient = server.Client()
+	defer func() { federationClient = client }()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	// delegated api host with subpath
+	advertised.API = "api.example.org/databag/"
+	base, discovery := getNodeDiscovery(host)
+	assert.Equal(t, "https://api.example.org/databag", base)
+	assert.True(t, nodeCapable(discovery, APPCapabilityCalls))
+	assert.False(t, nodeCapable(discovery, APPCapabilitySuccession))
+
+	// document of subpath deployment served at host root
+	advertised.API = ""
+	base, discovery = getNodeDiscovery(host + "/databag")
+	assert.Equal(t, "https://"+host+"/databag", base)
+	assert.NotNil(t, discovery)
+
+	// legacy node without capabilities
+	advertised = nil
+	delete(discoveryCache, host)
+	base, discovery = getNodeDiscovery(host)
+	assert.Equal(t, "https://"+host, base)
+	assert.Nil(t, discovery)
+	assert.False(t, nodeCapable(discovery, APPCapabilitySuccession))
+
+	// resolved in background once for waiting notifications
+	advertised = &NodeDiscovery{Version: APPVersion}
+	delete(discoveryCache, host)
+	_, _, cached := getCachedDiscovery(host)
+	assert.False(t, cached)
+	resolved := make(chan bool, 2)
+	refreshNodeDiscovery(host, func() { resolved <- true })
+	refreshNodeDiscovery(host, func() { resolved <- true })
+	<-resolved
+	<-resolved
+	base, discovery, cached = getCachedDiscovery(host)
+	assert.True(t, cached)
+	assert.Equal(t, "https://"+host, base)
+	assert.NotNil(t, discovery)
+}
+
+func TestNodeDiscoveryKeyTypes(t *testing.T) {
+
+	// legacy nodes verify rsa keys only
+	signType, err := getNodeSignType(nil, APPRSA2048)
+	assert.NoError(t, err)
+	assert.Equal(t, APPSignPKCS1V15, signType)
+	_, err = getNodeSignType(nil, APPED25519)
+	assert.Error(t, err)
+
+	// advertised key types select signature
+	discovery := &NodeDiscovery{Version: APPVersion, KeyTypes: []string{APPED25519}}
+	signType, err = getNodeSignType(discovery, APPED25519)
+	assert.NoError(t, err)
+	assert.Equal(t, APPSignEd25519, signType)
+	_, err = getNodeSignType(discovery, APPRSA4096)
+	assert.Error(t, err)
+
+	// major version must match
+	assert.True(t, nodeCompatible(nil))
+	assert.True(t, nodeCompatible(discovery))
+	assert.False(t, nodeCompatible(&NodeDiscovery{Version: "1.0.0"}))
+}
@@ -0,0 +1,116 @@
+package databag
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"databag/internal/store"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var federationClient *http.Client
+var federationSync sync.Mutex
+
+// getFederationClient returns the shared client for requests to federated nodes and push services
+func getFederationClient() *http.Client {
+	federationSync.Lock()
+	defer federationSync.Unlock()
+	if federationClient == nil {
+		federationClient = &http.Client{
+			Transport: getFederationTransport(),
+			Timeout:   APPFederationTimeout * time.Second,
+		}
+	}
+	return federationClient
+}
+
+func getFederationTransport() *http.Transport {
+
+	// private ca bundle is trusted in addition to system roots for closed networks
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if path := os.Getenv("DATABAG_FEDERATION_CA"); path != "" {
+		bundle, err := ioutil.ReadFile(path)
+		if err != nil {
+			ErrMsg(err)
+		} else if !pool.AppendCertsFromPEM(bundle) {
+			LogMsg("no certificates found in federation ca bundle")
+		}
+	}
+
+	// https and socks5 proxies are supported, i.e. socks5h://127.0.0.1:9050 for tor
+	proxy := http.ProxyFromEnvironment
+	if value := os.Getenv("DATABAG_FEDERATION_PROXY"); value != "" {
+		proxyURL, err := url.Parse(value)
+		if err != nil {
+			ErrMsg(err)
+		} else {
+			proxy = http.ProxyURL(proxyURL)
+		}
+	}
+
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   APPFederationTimeout * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig: &tls.Config{
+			RootCAs:          pool,
+			MinVersion:       tls.VersionTLS12,
+			VerifyConnection: verifyNodePin,
+		},
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: APPFederationIdleConns,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: APPFederationTimeout * time.Second,
+	}
+}
+
+// pinned nodes must present a certificate with a listed public key in addition to a valid chain
+func verifyNodePin(state tls.ConnectionState) error {
+
+	var pins []store.NodePin
+	if err := store.DB.Where("node = ?", getNodeHost(state.ServerName)).Find(&pins).Error; err != nil {
+		return err
+	}
+	if len(pins) == 0 {
+		return nil
+	}
+	for _, cert := range state.PeerCertificates {
+		pin := getCertificatePin(cert)
+		for _, pinned := range pins {
+			if pinned.Pin == pin {
+				return nil
+			}
+		}
+	}
+	return errors.New("certificate not pinned for " + state.ServerName)
+}
+
+// getCertificatePin computes base64 sha256 of certificate public key info
+func getCertificatePin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// getNodeHost hostname of node regardless of port, path or trailing dot of fully qualified name
+func getNodeHost(node string) string {
+	host := strings.SplitN(getContactNode(node), "/", 2)[0]
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	return strings.TrimSuffix(host, ".")
+}
@@ -0,0 +1,73 @@
+package databag
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFederationPin(t *testing.T) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	transport := getFederationTransport()
+	transport.TLSClientConfig.RootCAs.AddCert(server.Certificate())
+	dial := transport.DialContext
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dial(ctx, network, server.Listener.Addr().String())
+	}
+	client := &http.Client{Transport: transport}
+	node := "example.com"
+	url := "https://example.com/"
+
+	// ip nodes cannot be identified during handshake
+	params := &TestAPIParams{restType: "POST", query: "/admin/pins/{node}?token=pass",
+		path: map[string]string{"node": server.Listener.Addr().String()}, body: getCertificatePin(server.Certificate())}
+	assert.Error(t, TestAPIRequest(AddNodePin, params, nil))
+
+	// unpinned node verified by chain only
+	resp, err := client.Get(url)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	// pinned node rejected with other key
+	other := make([]byte, 32)
+	rand.Read(other)
+	params = &TestAPIParams{restType: "POST", query: "/admin/pins/{node}?token=pass",
+		path: map[string]string{"node": node}, body: base64.StdEncoding.EncodeToString(other)}
+	assert.NoError(t, TestAPIRequest(AddNodePin, params, nil))
+	transport.CloseIdleConnections()
+	_, err = client.Get(url)
+	assert.Error(t, err)
+
+	// pinned node accepted with matching key
+	params = &TestAPIParams{restType: "POST", query: "/admin/pins/{node}?token=pass",
+		path: map[string]string{"node": node}, body: getCertificatePin(server.Certificate())}
+	assert.NoError(t, TestAPIRequest(AddNodePin, params, nil))
+	resp, err = client.Get(url)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	pins := []NodePin{}
+	params = &TestAPIParams{query: "/admin/pins?token=pass"}
+	assert.NoError(t, TestAPIRequest(GetNodePins, params, &TestAPIResponse{data: &pins}))
+	assert.Equal(t, 2, len(pins))
+	assert.Equal(t, node, pins[0].Node)
+
+	// invalid pin rejected
+	params = &TestAPIParams{restType: "POST", query: "/admin/pins/{node}?token=pass",
+		path: map[string]string{"node": node}, body: "notapin"}
+	assert.Error(t, TestAPIRequest(AddNodePin, params, nil))
+
+	params = &TestAPIParams{restType: "DELETE", query: "/admin/pins/{node}?token=pass", path: map[string]string{"node": node}}
+	assert.NoError(t, TestAPIRequest(RemoveNodePin, params, nil))
+	params = &TestAPIParams{query: "/admin/pins?token=pass"}
+	pins = []NodePin{}
+	assert.NoError(t, TestAPIRequest(GetNodePins, params, &TestAPIResponse{data: &pins}))
+	assert.Equal(t, 0, len(pins))
+}
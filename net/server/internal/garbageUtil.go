@@ -7,6 +7,21 @@ import (
 )
 
 var garbageSync sync.Mutex
+var garbageHeld = make(map[string]bool)
+
+// holdAsset keeps file of asset without a record yet from cleanup
+func holdAsset(assetID string) {
+	garbageSync.Lock()
+	defer garbageSync.Unlock()
+	garbageHeld[assetID] = true
+}
+
+// releaseAsset allows cleanup of asset file once record is created or upload abandoned
+func releaseAsset(assetID string) {
+	garbageSync.Lock()
+	defer garbageSync.Unlock()
+	delete(garbageHeld, assetID)
+}
 
 func garbageCollect(act *store.Account) {
 	garbageSync.Lock()
@@ -33,16 +48,20 @@ func garbageCollect(act *store.Account) {
 		return
 	}
 
-	// mark all referenced files
+	// mark all referenced and held files
 	for _, asset := range assets {
 		list[asset.AssetID] = true
+		list[asset.AssetID+APPStreamSuffix] = true
+	}
+	for id := range garbageHeld {
+		list[id] = true
 	}
 
 	// delete any unreferenced file
 	for id, set := range list {
 		if !set {
 			LogMsg("removing file asset " + act.GUID + "/" + id)
-			if err := os.Remove(dir + "/" + id); err != nil {
+			if err := os.RemoveAll(dir + "/" + id); err != nil {
 				ErrMsg(err)
 			}
 		}
@@ -0,0 +1,259 @@
+package databag
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	_ "golang.org/x/image/bmp"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+)
+
+// errNativeUnsupported indicates the native transform defers to the transform script
+var errNativeUnsupported = errors.New("unsupported by native transform")
+
+type nativeTransform func(ctx context.Context, input string, output string, params string) error
+
+// nativeTransforms built-in transforms that run without an external script
+var nativeTransforms = map[string]nativeTransform{
+	"ithumb": func(ctx context.Context, input string, output string, params string) error {
+		return transformImage(ctx, input, output, params, APPImageThumbSize, false)
+	},
+	"icopy": func(ctx context.Context, input string, output string, params string) error {
+		return transformImage(ctx, input, output, params, 0, false)
+	},
+	"ilg": func(ctx context.Context, input string, output string, params string) error {
+		return transformImage(ctx, input, output, params, APPImageLargeSize, true)
+	},
+}
+
+// getNativeTransform returns built-in transform unless node prefers scripts
+func getNativeTransform(name string) (nativeTransform, bool) {
+	if getBoolConfigValue(CNFScriptTransform, false) {
+		return nil, false
+	}
+	transform, ok := nativeTransforms[name]
+	return transform, ok
+}
+
+// transformImage orients, scales to fit bound and re-encodes image without metadata
+func transformImage(ctx context.Context, input string, output string, params string, bound int, shrink bool) error {
+
+	// no native webp encoder, so webp and other outputs are left to the script
+	format := strings.ToLower(strings.TrimSpace(params))
+	if format != "" && format != "jpeg" && format != "jpg" && format != "png" {
+		return errNativeUnsupported
+	}
+
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+
+	// check dimensions before allocating pixels, leaving unknown formats and large images to the script
+	config, kind, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return errNativeUnsupported
+	}
+	if int64(config.Width)*int64(config.Height) > APPImageMaxPixels {
+		return errNativeUnsupported
+	}
+	if kind == "gif" {
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		if len(anim.Image) > 1 {
+			return errNativeUnsupported
+		}
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	img := orientImage(src, getImageOrientation(data))
+	img = scaleImage(img, bound, shrink)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	if format == "png" || (format == "" && !isImageOpaque(img)) {
+		err = png.Encode(writer, img)
+	} else {
+		err = jpeg.Encode(writer, flattenImage(img), &jpeg.Options{Quality: APPImageQuality})
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if res := file.Close(); err == nil {
+		err = res
+	}
+	return err
+}
+
+// scaleImage fits image within bound, only reducing size when shrink is set
+func scaleImage(img image.Image, bound int, shrink bool) image.Image {
+	if bound <= 0 {
+		return img
+	}
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+	if width == 0 || height == 0 || (shrink && width <= bound && height <= bound) {
+		return img
+	}
+
+	scaledWidth, scaledHeight := bound, bound
+	if width > height {
+		scaledHeight = (height*bound + width/2) / width
+	} else {
+		scaledWidth = (width*bound + height/2) / height
+	}
+	if scaledWidth < 1 {
+		scaledWidth = 1
+	}
+	if scaledHeight < 1 {
+		scaledHeight = 1
+	}
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, scaledWidth, scaledHeight))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+	return scaled
+}
+
+// orientImage applies exif orientation so pixels display upright
+func orientImage(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	src := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.Draw(src, src.Bounds(), img, bounds.Min, xdraw.Src)
+
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = width-1-x, y
+			case 3:
+				sx, sy = width-1-x, height-1-y
+			case 4:
+				sx, sy = x, height-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, height-1-x
+			case 7:
+				sx, sy = width-1-y, height-1-x
+			case 8:
+				sx, sy = width-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// getImageOrientation reads exif orientation tag from jpeg data, 1 if absent
+func getImageOrientation(data []byte) int {
+	reader := bytes.NewReader(data)
+	var marker [2]byte
+	if _, err := io.ReadFull(reader, marker[:]); err != nil || marker[0] != 0xFF || marker[1] != 0xD8 {
+		return 1
+	}
+	for {
+		if _, err := io.ReadFull(reader, marker[:]); err != nil || marker[0] != 0xFF {
+			return 1
+		}
+		if marker[1] == 0xDA || marker[1] == 0xD9 {
+			return 1
+		}
+		var length uint16
+		if err := binary.Read(reader, binary.BigEndian, &length); err != nil || length < 2 {
+			return 1
+		}
+		segment := make([]byte, length-2)
+		if _, err := io.ReadFull(reader, segment); err != nil {
+			return 1
+		}
+		if marker[1] == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return getExifOrientation(segment[6:])
+		}
+	}
+}
+
+func getExifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
+
+func isImageOpaque(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return opaque.Opaque()
+	}
+	return false
+}
+
+// flattenImage composites transparent pixels onto white for jpeg output
+func flattenImage(img image.Image) image.Image {
+	if isImageOpaque(img) {
+		return img
+	}
+	flat := image.NewRGBA(img.Bounds())
+	xdraw.Draw(flat, flat.Bounds(), &image.Uniform{C: color.White}, image.Point{}, xdraw.Src)
+	xdraw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, xdraw.Over)
+	return flat
+}
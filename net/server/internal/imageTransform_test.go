@@ -0,0 +1,95 @@
+package databag
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImageTransform(t *testing.T) {
+
+	dir := t.TempDir()
+
+	// landscape photo, red on left and blue on right, tagged to rotate clockwise
+	photo := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			if x < 200 {
+				photo.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				photo.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	var encoded bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&encoded, photo, nil))
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+	segment := append([]byte{0xFF, 0xE1, byte((len(exif) + 2) >> 8), byte(len(exif) + 2)}, exif...)
+	tagged := append(append(append([]byte{}, encoded.Bytes()[:2]...), segment...), encoded.Bytes()[2:]...)
+	input := filepath.Join(dir, "photo")
+	assert.NoError(t, os.WriteFile(input, tagged, 0600))
+	assert.Equal(t, 6, getImageOrientation(tagged))
+
+	// thumbnail is upright, bounded and stripped
+	output := filepath.Join(dir, "thumb")
+	assert.NoError(t, nativeTransforms["ithumb"](context.Background(), input, output, ""))
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(data, []byte("Exif")))
+	thumb, format, err := image.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, image.Pt(96, 192), thumb.Bounds().Size())
+	r, _, b, _ := thumb.At(48, 10).RGBA()
+	assert.True(t, r > b)
+	r, _, b, _ = thumb.At(48, 180).RGBA()
+	assert.True(t, b > r)
+
+	// large only shrinks
+	output = filepath.Join(dir, "large")
+	assert.NoError(t, nativeTransforms["ilg"](context.Background(), input, output, ""))
+	data, err = os.ReadFile(output)
+	assert.NoError(t, err)
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, config.Width)
+	assert.Equal(t, 400, config.Height)
+
+	// transparency is kept as png
+	clear := image.NewNRGBA(image.Rect(0, 0, 20, 10))
+	encoded.Reset()
+	assert.NoError(t, png.Encode(&encoded, clear))
+	input = filepath.Join(dir, "clear")
+	assert.NoError(t, os.WriteFile(input, encoded.Bytes(), 0600))
+	output = filepath.Join(dir, "copy")
+	assert.NoError(t, nativeTransforms["icopy"](context.Background(), input, output, ""))
+	data, err = os.ReadFile(output)
+	assert.NoError(t, err)
+	_, format, err = image.DecodeConfig(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "png", format)
+
+	// unsupported output defers to script
+	assert.ErrorIs(t, nativeTransforms["icopy"](context.Background(), input, output, "webp"), errNativeUnsupported)
+
+	// unknown and oversized input defer to script
+	input = filepath.Join(dir, "unknown")
+	assert.NoError(t, os.WriteFile(input, []byte("\x00\x00\x00\x18ftypheic"), 0600))
+	assert.ErrorIs(t, nativeTransforms["icopy"](context.Background(), input, output, ""), errNativeUnsupported)
+	header := encoded.Bytes()
+	binary.BigEndian.PutUint32(header[16:], 100000)
+	binary.BigEndian.PutUint32(header[20:], 100000)
+	binary.BigEndian.PutUint32(header[29:], crc32.ChecksumIEEE(header[12:29]))
+	input = filepath.Join(dir, "oversized")
+	assert.NoError(t, os.WriteFile(input, header, 0600))
+	assert.ErrorIs(t, nativeTransforms["icopy"](context.Background(), input, output, ""), errNativeUnsupported)
+}
@@ -1,6 +1,10 @@
 package databag
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -8,6 +12,124 @@ import (
 	"errors"
 )
 
+// GenerateKeyPair creates pem encoded public/private key of configured type for a new account
+func GenerateKeyPair() (string, string, string, error) {
+	keyType := getStrConfigValue(CNFKeyType, APPRSA2048)
+	switch keyType {
+	case APPRSA2048, APPRSA4096:
+		privkey, pubkey, keyType, err := GenerateRsaKeyPair()
+		if err != nil {
+			return "", "", "", err
+		}
+		publicPem, err := ExportRsaPublicKeyAsPemStr(pubkey)
+		if err != nil {
+			return "", "", "", err
+		}
+		return ExportRsaPrivateKeyAsPemStr(privkey), publicPem, keyType, nil
+	case APPED25519:
+		pubkey, privkey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", "", "", err
+		}
+		return exportKeyPair(privkey, pubkey, APPED25519)
+	case APPP256:
+		privkey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return "", "", "", err
+		}
+		return exportKeyPair(privkey, &privkey.PublicKey, APPP256)
+	default:
+		return "", "", "", errors.New("invalid key setting")
+	}
+}
+
+func exportKeyPair(privkey crypto.PrivateKey, pubkey crypto.PublicKey, keyType string) (string, string, string, error) {
+	privkeyBytes, err := x509.MarshalPKCS8PrivateKey(privkey)
+	if err != nil {
+		return "", "", "", err
+	}
+	privkeyPEM := pem.EncodeToMemory(
+		&pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: privkeyBytes,
+		},
+	)
+	pubkeyBytes, err := x509.MarshalPKIXPublicKey(pubkey)
+	if err != nil {
+		return "", "", "", err
+	}
+	pubkeyPEM := pem.EncodeToMemory(
+		&pem.Block{
+			Type:  "PUBLIC KEY",
+			Bytes: pubkeyBytes,
+		},
+	)
+	return string(privkeyPEM), string(pubkeyPEM), keyType, nil
+}
+
+// ParsePrivateKeyFromPemStr loads account private key of any supported type
+func ParsePrivateKeyFromPemStr(privPEM string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(privPEM))
+	if block == nil {
+		return nil, errors.New("failed to parse PEM block containing the key")
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch priv := priv.(type) {
+	case *rsa.PrivateKey:
+		return priv, nil
+	case ed25519.PrivateKey:
+		return priv, nil
+	case *ecdsa.PrivateKey:
+		return priv, nil
+	}
+	return nil, errors.New("unsupported private key")
+}
+
+// ParsePublicKeyFromPemStr loads account public key of any supported type
+func ParsePublicKeyFromPemStr(pubPEM string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pubPEM))
+	if block == nil {
+		return nil, errors.New("failed to parse PEM block containing the key")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return pub, nil
+	case ed25519.PublicKey:
+		return pub, nil
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return nil, errors.New("unsupported ecdsa curve")
+		}
+		return pub, nil
+	}
+	return nil, errors.New("unsupported public key")
+}
+
+// getSignType selects signature algorithm for account key type
+func getSignType(keyType string) string {
+	switch keyType {
+	case APPED25519:
+		return APPSignEd25519
+	case APPP256:
+		return APPSignECDSA
+	default:
+		return APPSignPKCS1V15
+	}
+}
+
 // GenerateRsaKeyPair creates a public/private key for a new account
 func GenerateRsaKeyPair() (*rsa.PrivateKey, *rsa.PublicKey, string, error) {
 	keyType := getStrConfigValue(CNFKeyType, "RSA2048")
@@ -86,3 +86,11 @@ func getMessageRetentionDays() int64 {
 func getAssetRetentionDays() int64 {
 	return getLoginIntConfig("DATABAG_ASSET_RETENTION_DAYS", 180)
 }
+
+func getContactRequestLimit() int64 {
+	return getLoginIntConfig("DATABAG_CONTACT_REQUEST_LIMIT", APPContactRequestLimit)
+}
+
+func getContactRequestPeriod() int64 {
+	return getLoginIntConfig("DATABAG_CONTACT_REQUEST_PERIOD", APPContactRequestPeriod)
+}
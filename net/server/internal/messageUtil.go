@@ -2,13 +2,17 @@ package databag
 
 import (
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"databag/internal/store"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"gorm.io/gorm"
 	"time"
 )
 
@@ -18,18 +22,14 @@ func ReadDataMessage(msg *DataMessage, obj interface{}) (string, string, int64,
 	var data []byte
 	var hash [32]byte
 	var err error
-	var publicKey *rsa.PublicKey
-
-	if msg.KeyType != APPRSA4096 && msg.KeyType != APPRSA2048 {
-		return "", "", 0, errors.New("unsupported key type")
-	}
+	var publicKey crypto.PublicKey
 
 	// extract public key
 	data, err = base64.StdEncoding.DecodeString(msg.PublicKey)
 	if err != nil {
 		return "", "", 0, err
 	}
-	publicKey, err = ParseRsaPublicKeyFromPemStr(string(data))
+	publicKey, err = ParsePublicKeyFromPemStr(string(data))
 	if err != nil {
 		return "", "", 0, err
 	}
@@ -50,19 +50,8 @@ func ReadDataMessage(msg *DataMessage, obj interface{}) (string, string, int64,
 	if err != nil {
 		return "", "", 0, err
 	}
-	hash = sha256.Sum256(data)
-	if msg.SignatureType == APPSignPKCS1V15 {
-		err = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature)
-		if err != nil {
-			return "", "", 0, err
-		}
-	} else if msg.SignatureType == APPSignPSS {
-		err = rsa.VerifyPSS(publicKey, crypto.SHA256, hash[:], signature, nil)
-		if err != nil {
-			return "", "", 0, err
-		}
-	} else {
-		return "", "", 0, errors.New("unsupported signature type")
+	if err = verifySignature(msg.KeyType, msg.SignatureType, publicKey, data, signature); err != nil {
+		return "", "", 0, err
 	}
 
 	// extract data
@@ -77,62 +66,132 @@ func ReadDataMessage(msg *DataMessage, obj interface{}) (string, string, int64,
 		return "", "", 0, errors.New("invalid message source")
 	}
 
+	// identity messages are long lived profiles, only state changing messages are bound to a time window
+	protected := isReplayProtected(signedData.MessageType)
+
+	// validate timestamp
+	now := time.Now().Unix()
+	if protected && signedData.Timestamp > now+APPMessageSkew {
+		return "", "", 0, errors.New("message timestamp in future")
+	}
+	if protected && signedData.Timestamp+APPNonceExpire <= now {
+		return "", "", 0, errors.New("message timestamp expired")
+	}
+
 	// extract data
 	err = json.Unmarshal([]byte(signedData.Value), obj)
 	if err != nil {
 		return "", "", 0, err
 	}
+	if !protected {
+		return guid, signedData.MessageType, signedData.Timestamp, nil
+	}
+
+	// reject replayed messages, identifying messages without nonce by signature
+	nonce := signedData.Nonce
+	if nonce == "" {
+		hash = sha256.Sum256(signature)
+		nonce = "signature:" + hex.EncodeToString(hash[:])
+	}
+	if err = setMessageNonce(guid, nonce, signedData.Timestamp+APPNonceExpire); err != nil {
+		return "", "", 0, err
+	}
 
 	return guid, signedData.MessageType, signedData.Timestamp, nil
 }
 
+func isReplayProtected(messageType string) bool {
+	return messageType == APPMsgConnect || messageType == APPMsgDisconnect || messageType == APPMsgSuccession
+}
+
+func setMessageNonce(guid string, nonce string, expires int64) error {
+	return store.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if res := tx.Model(&store.Nonce{}).Where("guid = ? AND nonce = ?", guid, nonce).Count(&count).Error; res != nil {
+			return res
+		}
+		if count != 0 {
+			return errors.New("message replayed")
+		}
+		return tx.Create(&store.Nonce{GUID: guid, Nonce: nonce, Expires: expires}).Error
+	})
+}
+
+var nonceSweepExit = make(chan bool)
+
+// ExitNonceSweep stop removing expired nonces
+func ExitNonceSweep() {
+	nonceSweepExit <- true
+}
+
+// SweepNonces removes nonces of signed messages no longer within the accepted timestamp window
+func SweepNonces() {
+
+	ticker := time.NewTicker(APPNonceSweepInterval * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sweepNonces(time.Now().Unix())
+		case <-nonceSweepExit:
+			return
+		}
+	}
+}
+
+func sweepNonces(now int64) {
+	if err := store.DB.Where("expires < ?", now).Delete(&store.Nonce{}).Error; err != nil {
+		ErrMsg(err)
+	}
+}
+
 //WriteDataMessage is a helper function to write signed protocol messages
 func WriteDataMessage(privateKey string, publicKey string, keyType string,
 	signType string, guid string, messageType string, obj interface{}) (*DataMessage, error) {
 
-	var data []byte
-	var hash [32]byte
-	var err error
-	var private *rsa.PrivateKey
-
 	// create message to sign
-	data, err = json.Marshal(obj)
+	data, err := json.Marshal(obj)
 	if err != nil {
 		return nil, err
 	}
+	nonce := make([]byte, APPNonceSize)
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
 	var signedData SignedData
 	signedData.GUID = guid
 	signedData.Timestamp = time.Now().Unix()
 	signedData.MessageType = messageType
 	signedData.Value = string(data)
-	data, err = json.Marshal(&signedData)
+	signedData.Nonce = hex.EncodeToString(nonce)
+	return writeSignedData(privateKey, publicKey, keyType, signType, &signedData)
+}
+
+func writeSignedData(privateKey string, publicKey string, keyType string,
+	signType string, signedData *SignedData) (*DataMessage, error) {
+
+	var data []byte
+	var err error
+	var private crypto.Signer
+
+	data, err = json.Marshal(signedData)
 	if err != nil {
 		return nil, errors.New("marshall failed")
 	}
 	message := base64.StdEncoding.EncodeToString(data)
 
-	if keyType != APPRSA2048 && keyType != APPRSA4096 {
-		return nil, errors.New("unsupported key type")
-	}
-
 	// get private key
-	private, err = ParseRsaPrivateKeyFromPemStr(privateKey)
+	private, err = ParsePrivateKeyFromPemStr(privateKey)
 	if err != nil {
 		return nil, err
 	}
 	key := base64.StdEncoding.EncodeToString([]byte(publicKey))
 
 	// compute signature
-	hash = sha256.Sum256(data)
-	if signType == APPSignPKCS1V15 {
-		data, err = rsa.SignPKCS1v15(rand.Reader, private, crypto.SHA256, hash[:])
-	} else if signType == APPSignPSS {
-		data, err = rsa.SignPSS(rand.Reader, private, crypto.SHA256, hash[:], nil)
-	} else {
-		return nil, errors.New("unsupported signature type")
-	}
+	data, err = computeSignature(keyType, signType, private, data)
 	if err != nil {
-		return nil, errors.New("rsa sign failed")
+		return nil, err
 	}
 	signature := base64.StdEncoding.EncodeToString(data)
 
@@ -145,3 +204,73 @@ func WriteDataMessage(privateKey string, publicKey string, keyType string,
 	}
 	return &dataMessage, nil
 }
+
+func computeSignature(keyType string, signType string, private crypto.Signer, data []byte) ([]byte, error) {
+	hash := sha256.Sum256(data)
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		if keyType != APPRSA2048 && keyType != APPRSA4096 {
+			return nil, errors.New("unsupported key type")
+		}
+		if signType == APPSignPKCS1V15 {
+			return rsa.SignPKCS1v15(rand.Reader, private, crypto.SHA256, hash[:])
+		} else if signType == APPSignPSS {
+			return rsa.SignPSS(rand.Reader, private, crypto.SHA256, hash[:], nil)
+		}
+	case ed25519.PrivateKey:
+		if keyType != APPED25519 {
+			return nil, errors.New("unsupported key type")
+		}
+		if signType == APPSignEd25519 {
+			return ed25519.Sign(private, data), nil
+		}
+	case *ecdsa.PrivateKey:
+		if keyType != APPP256 {
+			return nil, errors.New("unsupported key type")
+		}
+		if signType == APPSignECDSA {
+			return ecdsa.SignASN1(rand.Reader, private, hash[:])
+		}
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+	return nil, errors.New("unsupported signature type")
+}
+
+func verifySignature(keyType string, signType string, publicKey crypto.PublicKey, data []byte, signature []byte) error {
+	hash := sha256.Sum256(data)
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		if keyType != APPRSA2048 && keyType != APPRSA4096 {
+			return errors.New("unsupported key type")
+		}
+		if signType == APPSignPKCS1V15 {
+			return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature)
+		} else if signType == APPSignPSS {
+			return rsa.VerifyPSS(publicKey, crypto.SHA256, hash[:], signature, nil)
+		}
+	case ed25519.PublicKey:
+		if keyType != APPED25519 {
+			return errors.New("unsupported key type")
+		}
+		if signType == APPSignEd25519 {
+			if !ed25519.Verify(publicKey, data, signature) {
+				return errors.New("invalid signature")
+			}
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if keyType != APPP256 {
+			return errors.New("unsupported key type")
+		}
+		if signType == APPSignECDSA {
+			if !ecdsa.VerifyASN1(publicKey, hash[:], signature) {
+				return errors.New("invalid signature")
+			}
+			return nil
+		}
+	default:
+		return errors.New("unsupported key type")
+	}
+	return errors.New("unsupported signature type")
+}
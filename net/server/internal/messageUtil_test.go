@@ -0,0 +1,201 @@
+package databag
+
+import (
+	"crypto/sha256"
+	"databag/internal/store"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func setTestKeyType(t *testing.T, keyType string) {
+	assert.NoError(t, store.DB.Model(&store.Config{}).Where("config_id = ?", CNFKeyType).Update("str_value", keyType).Error)
+}
+
+func TestDataMessageKeyTypes(t *testing.T) {
+	defer setTestKeyType(t, APPRSA2048)
+
+	for _, keyType := range []string{APPRSA2048, APPED25519, APPP256} {
+		setTestKeyType(t, keyType)
+		privatePem, publicPem, generated, err := GenerateKeyPair()
+		assert.NoError(t, err)
+		assert.Equal(t, keyType, generated)
+
+		// guid derived from public key pem for all key types
+		hash := sha256.Sum256([]byte(publicPem))
+		guid := hex.EncodeToString(hash[:])
+
+		claim := &Claim{Token: "1234abcd"}
+		msg, err := WriteDataMessage(privatePem, publicPem, keyType, getSignType(keyType), guid, APPMsgAuthenticate, claim)
+		assert.NoError(t, err)
+
+		var read Claim
+		signer, messageType, _, err := ReadDataMessage(msg, &read)
+		assert.NoError(t, err)
+		assert.Equal(t, guid, signer)
+		assert.Equal(t, APPMsgAuthenticate, messageType)
+		assert.Equal(t, "1234abcd", read.Token)
+
+		// signature scheme must match key
+		mismatch := *msg
+		mismatch.SignatureType = APPSignPSS
+		if keyType == APPRSA2048 {
+			mismatch.SignatureType = APPSignEd25519
+		}
+		_, _, _, err = ReadDataMessage(&mismatch, &read)
+		assert.Error(t, err)
+
+		// declared key type must match key
+		mismatch = *msg
+		mismatch.KeyType = APPRSA4096
+		if keyType == APPRSA2048 {
+			mismatch.KeyType = APPED25519
+		}
+		_, _, _, err = ReadDataMessage(&mismatch, &read)
+		assert.Error(t, err)
+
+		// tampered message rejected
+		tampered, err := WriteDataMessage(privatePem, publicPem, keyType, getSignType(keyType), guid, APPMsgAuthenticate, &Claim{Token: "tampered"})
+		assert.NoError(t, err)
+		tampered.Signature = msg.Signature
+		_, _, _, err = ReadDataMessage(tampered, &read)
+		assert.Error(t, err)
+	}
+}
+
+func TestMixedKeyFederation(t *testing.T) {
+	defer setTestKeyType(t, APPRSA2048)
+
+	// contacts with each key type
+	setTestKeyType(t, APPRSA2048)
+	rsaGUID, rsaToken, err := addTestAccount("mixedkeyA")
+	assert.NoError(t, err)
+	setTestKeyType(t, APPED25519)
+	edGUID, edToken, err := addTestAccount("mixedkeyB")
+	assert.NoError(t, err)
+	setTestKeyType(t, APPP256)
+	ecGUID, ecToken, err := addTestAccount("mixedkeyC")
+	assert.NoError(t, err)
+
+	// connect each pair
+	_, _, err = connectTestCards(rsaToken, edToken)
+	assert.NoError(t, err)
+	_, _, err = connectTestCards(edToken, ecToken)
+	assert.NoError(t, err)
+	_, _, err = connectTestCards(ecToken, rsaToken)
+	assert.NoError(t, err)
+
+	// each sees both contacts by guid
+	for token, expected := range map[string][]string{
+		rsaToken: {edGUID, ecGUID},
+		edToken:  {rsaGUID, ecGUID},
+		ecToken:  {rsaGUID, edGUID},
+	} {
+		cards := []Card{}
+		assert.NoError(t, APITestMsg(GetCards, "GET", "/contact/cards", nil, nil, APPTokenAgent, token, &cards, nil))
+		guids := []string{}
+		for _, card := range cards {
+			assert.Equal(t, APPCardConnected, card.Data.CardDetail.Status)
+			guids = append(guids, card.Data.CardProfile.GUID)
+		}
+		assert.ElementsMatch(t, expected, guids)
+	}
+}
+
+func TestDataMessageReplay(t *testing.T) {
+	privatePem, publicPem, keyType, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	hash := sha256.Sum256([]byte(publicPem))
+	guid := hex.EncodeToString(hash[:])
+	signType := getSignType(keyType)
+
+	// first read accepted, replay rejected
+	msg, err := WriteDataMessage(privatePem, publicPem, keyType, signType, guid, APPMsgConnect, &Connect{Contact: "replay"})
+	assert.NoError(t, err)
+	var connect Connect
+	_, _, _, err = ReadDataMessage(msg, &connect)
+	assert.NoError(t, err)
+	_, _, _, err = ReadDataMessage(msg, &connect)
+	assert.Error(t, err)
+
+	// same content with fresh nonce accepted
+	msg, err = WriteDataMessage(privatePem, publicPem, keyType, signType, guid, APPMsgConnect, &Connect{Contact: "replay"})
+	assert.NoError(t, err)
+	_, _, _, err = ReadDataMessage(msg, &connect)
+	assert.NoError(t, err)
+
+	// timestamp within skew accepted
+	value, err := json.Marshal(&Connect{Contact: "skew"})
+	assert.NoError(t, err)
+	signed := &SignedData{GUID: guid, Timestamp: time.Now().Unix() + APPMessageSkew/2,
+		MessageType: APPMsgConnect, Value: string(value), Nonce: "skewnonce"}
+	msg, err = writeSignedData(privatePem, publicPem, keyType, signType, signed)
+	assert.NoError(t, err)
+	_, _, _, err = ReadDataMessage(msg, &connect)
+	assert.NoError(t, err)
+
+	// far future timestamp rejected
+	signed = &SignedData{GUID: guid, Timestamp: time.Now().Unix() + 3600,
+		MessageType: APPMsgConnect, Value: string(value), Nonce: "futurenonce"}
+	msg, err = writeSignedData(privatePem, publicPem, keyType, signType, signed)
+	assert.NoError(t, err)
+	_, _, _, err = ReadDataMessage(msg, &connect)
+	assert.Error(t, err)
+
+	// timestamp outside nonce window rejected
+	signed = &SignedData{GUID: guid, Timestamp: time.Now().Unix() - APPNonceExpire,
+		MessageType: APPMsgConnect, Value: string(value), Nonce: "stalenonce"}
+	msg, err = writeSignedData(privatePem, publicPem, keyType, signType, signed)
+	assert.NoError(t, err)
+	_, _, _, err = ReadDataMessage(msg, &connect)
+	assert.Error(t, err)
+
+	// message without nonce tracked by signature
+	signed = &SignedData{GUID: guid, Timestamp: time.Now().Unix(),
+		MessageType: APPMsgConnect, Value: string(value)}
+	msg, err = writeSignedData(privatePem, publicPem, keyType, signType, signed)
+	assert.NoError(t, err)
+	_, _, _, err = ReadDataMessage(msg, &connect)
+	assert.NoError(t, err)
+	_, _, _, err = ReadDataMessage(msg, &connect)
+	assert.Error(t, err)
+
+	// sweep removes nonces once outside window
+	var count int64
+	assert.NoError(t, store.DB.Model(&store.Nonce{}).Where("guid = ?", guid).Count(&count).Error)
+	assert.NotZero(t, count)
+	sweepNonces(time.Now().Unix() + APPNonceExpire + APPMessageSkew + 1)
+	assert.NoError(t, store.DB.Model(&store.Nonce{}).Where("guid = ?", guid).Count(&count).Error)
+	assert.Zero(t, count)
+
+	// guid of another key rejected
+	_, otherPem, _, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	hash = sha256.Sum256([]byte(otherPem))
+	other := hex.EncodeToString(hash[:])
+	msg, err = WriteDataMessage(privatePem, publicPem, keyType, signType, other, APPMsgConnect, &connect)
+	assert.NoError(t, err)
+	_, _, _, err = ReadDataMessage(msg, &connect)
+	assert.Error(t, err)
+
+	// public key of another signer rejected
+	msg, err = WriteDataMessage(privatePem, publicPem, keyType, signType, guid, APPMsgConnect, &connect)
+	assert.NoError(t, err)
+	msg.PublicKey = base64.StdEncoding.EncodeToString([]byte(otherPem))
+	_, _, _, err = ReadDataMessage(msg, &connect)
+	assert.Error(t, err)
+
+	// identity messages read repeatedly regardless of age
+	var identity Identity
+	signed = &SignedData{GUID: guid, Timestamp: time.Now().Unix() - 2*APPNonceExpire,
+		MessageType: APPMsgIdentity, Value: string(value)}
+	msg, err = writeSignedData(privatePem, publicPem, keyType, signType, signed)
+	assert.NoError(t, err)
+	_, _, _, err = ReadDataMessage(msg, &identity)
+	assert.NoError(t, err)
+	_, _, _, err = ReadDataMessage(msg, &identity)
+	assert.NoError(t, err)
+}
@@ -2,6 +2,7 @@ package databag
 
 import (
 	"databag/internal/store"
+	"strings"
 )
 
 func getProfileModel(account *store.Account) *Profile {
@@ -17,6 +18,7 @@ func getProfileModel(account *store.Account) *Profile {
 		Version:     APPVersion,
 		Node:        getStrConfigValue(CNFDomain, ""),
     Seal:        account.AccountDetail.SealPublic,
+		Bot:         account.Bot,
 	}
 }
 
@@ -81,6 +83,8 @@ func getCardDetailModel(slot *store.CardSlot) *CardDetail {
 		Token:  slot.Card.OutToken,
 		Notes:  slot.Card.Notes,
 		Groups: groups,
+		Blocked: slot.Card.Blocked,
+		Muted:   slot.Card.Muted,
 	}
 }
 
@@ -96,6 +100,7 @@ func getCardProfileModel(slot *store.CardSlot) *CardProfile {
 		Version:     slot.Card.Version,
 		Node:        slot.Card.Node,
     Seal:        slot.Card.Seal,
+		Bot:         slot.Card.Bot,
 	}
 }
 
@@ -175,7 +180,9 @@ func getChannelDetailModel(slot *store.ChannelSlot, showList bool, image bool, a
 	}
 
 	var contacts *ChannelContacts
+	var muted bool
 	if showList {
+		muted = slot.Channel.Muted
 		var groups []string
 		for _, group := range slot.Channel.Groups {
 			groups = append(groups, group.GroupSlot.GroupSlotID)
@@ -188,8 +195,14 @@ func getChannelDetailModel(slot *store.ChannelSlot, showList bool, image bool, a
 	}
 
 	members := []string{}
+	roles := map[string]string{}
 	for _, member := range slot.Channel.Members {
 		members = append(members, member.Card.GUID)
+		if member.Role == "" {
+			roles[member.Card.GUID] = APPChannelPoster
+		} else {
+			roles[member.Card.GUID] = member.Role
+		}
 	}
 
 	return &ChannelDetail{
@@ -203,6 +216,9 @@ func getChannelDetailModel(slot *store.ChannelSlot, showList bool, image bool, a
     EnableBinary: binary,
 		Contacts: contacts,
 		Members:  members,
+		Roles:    roles,
+		Muted:    muted,
+		Expire:   slot.Channel.Expire,
 	}
 }
 
@@ -274,23 +290,45 @@ func getTopicDetailModel(slot *store.TopicSlot) *TopicDetail {
 	}
 
 	transform := APPTransformComplete
+	var previews []Asset
 	for _, asset := range slot.Topic.Assets {
+		if asset.Preview != "" {
+			previews = append(previews, Asset{AssetID: asset.AssetID, Preview: asset.Preview})
+		}
 		if asset.Status == APPAssetError {
 			transform = APPTransformError
-		} else if asset.Status == APPAssetWaiting && transform == APPTransformComplete {
+		} else if (asset.Status == APPAssetWaiting || asset.Status == APPAssetProcessing) && transform == APPTransformComplete {
 			transform = APPTransformIncomplete
 		}
 	}
 
 	return &TopicDetail{
-		GUID:      slot.Topic.GUID,
-		DataType:  slot.Topic.DataType,
-		Data:      slot.Topic.Data,
-		Created:   slot.Topic.Created,
-		Updated:   slot.Topic.Updated,
-		Status:    slot.Topic.Status,
-		Transform: transform,
+		GUID:       slot.Topic.GUID,
+		DataType:   slot.Topic.DataType,
+		Data:       slot.Topic.Data,
+		Created:    slot.Topic.Created,
+		Updated:    slot.Topic.Updated,
+		Status:     slot.Topic.Status,
+		Transform:  transform,
+		Parent:     slot.Topic.ParentSlotID,
+		ReplyCount: slot.Topic.ReplyCount,
+		Reactions:  getReactionCounts(slot.Topic.Reactions),
+		Publish:    slot.Topic.Publish,
+		Expires:    slot.Topic.Expires,
+		Previews:   previews,
+	}
+}
+
+func getReactionCounts(reactions []store.Reaction) map[string]int64 {
+
+	if len(reactions) == 0 {
+		return nil
+	}
+	counts := map[string]int64{}
+	for _, reaction := range reactions {
+		counts[reaction.Emoji] += 1
 	}
+	return counts
 }
 
 func getTopicModel(slot *store.TopicSlot) *Topic {
@@ -334,3 +372,107 @@ func getTagModel(slot *store.TagSlot) *Tag {
 		},
 	}
 }
+
+func getReactionModel(reaction *store.Reaction) *Reaction {
+
+	return &Reaction{
+		GUID:    reaction.GUID,
+		Emoji:   reaction.Emoji,
+		Created: reaction.Created,
+	}
+}
+
+func getWebhookModel(webhook *store.Webhook, showSecret bool) *Webhook {
+
+	events := []string{}
+	if webhook.Events != "" {
+		events = strings.Split(webhook.Events, ",")
+	}
+
+	var secret string
+	if showSecret {
+		secret = webhook.Secret
+	}
+
+	return &Webhook{
+		ID:      webhook.WebhookID,
+		URL:     webhook.URL,
+		Secret:  secret,
+		Events:  events,
+		Created: webhook.Created,
+		Updated: webhook.Updated,
+	}
+}
+
+func getWebhookDeliveryModel(delivery *store.WebhookDelivery) *WebhookDelivery {
+
+	return &WebhookDelivery{
+		ID:           delivery.DeliveryID,
+		Event:        delivery.Event,
+		Status:       delivery.Status,
+		Attempts:     delivery.Attempts,
+		NextAttempt:  delivery.NextAttempt,
+		ResponseCode: delivery.ResponseCode,
+		Error:        delivery.Error,
+		Created:      delivery.Created,
+		Updated:      delivery.Updated,
+	}
+}
+
+func getApiKeyModel(key *store.ApiKey) *ApiKey {
+
+	return &ApiKey{
+		ID:       key.ApiKeyID,
+		Name:     key.Name,
+		LastUsed: key.LastUsed,
+		Created:  key.Created,
+	}
+}
+
+func getTranscodeWorkerModel(worker *store.TranscodeWorker) *TranscodeWorker {
+
+	queues := []string{}
+	if worker.Queues != "" {
+		queues = strings.Split(worker.Queues, ",")
+	}
+
+	return &TranscodeWorker{
+		ID:       worker.WorkerID,
+		Name:     worker.Name,
+		Queues:   queues,
+		LastSeen: worker.LastSeen,
+		Created:  worker.Created,
+	}
+}
+
+func getContactBlockModel(block *store.ContactBlock) *ContactBlock {
+
+	return &ContactBlock{
+		ID:      block.BlockID,
+		GUID:    block.GUID,
+		Node:    block.Node,
+		Created: block.Created,
+	}
+}
+
+func getAccountRetentionModel(account *store.Account) *AccountRetention {
+	minDays, maxDays := getRetentionBounds()
+	return &AccountRetention{
+		TopicDays: account.TopicRetention,
+		AssetDays: account.AssetRetention,
+		MinDays:   minDays,
+		MaxDays:   maxDays,
+	}
+}
+
+func getContactPolicyModel(account *store.Account) *ContactPolicy {
+
+	policy := &ContactPolicy{Policy: account.ContactPolicy}
+	if policy.Policy == "" {
+		policy.Policy = APPContactAnyone
+	}
+	if account.ContactNodes != "" {
+		policy.Nodes = strings.Split(account.ContactNodes, ",")
+	}
+	return policy
+}
@@ -20,7 +20,36 @@ type AccountProfile struct {
 
 	Disabled bool `json:"disabled"`
 
+	Bot bool `json:"bot"`
+
 	StorageUsed int64 `json:"storageUsed"`
+
+	StorageQuota int64 `json:"storageQuota,omitempty"`
+}
+
+// AccountStorage storage used by account with breakdown by channel and asset type
+type AccountStorage struct {
+	Used int64 `json:"used"`
+
+	Quota int64 `json:"quota"`
+
+	Channels []ChannelStorage `json:"channels"`
+
+	Types []AssetTypeStorage `json:"types"`
+}
+
+// ChannelStorage storage used by assets of channel
+type ChannelStorage struct {
+	ChannelID string `json:"channelId"`
+
+	Size int64 `json:"size"`
+}
+
+// AssetTypeStorage storage used by assets of media type
+type AssetTypeStorage struct {
+	Type string `json:"type"`
+
+	Size int64 `json:"size"`
 }
 
 // AccountStatus server settings for account
@@ -35,6 +64,8 @@ type AccountStatus struct {
 
 	Searchable bool `json:"searchable"`
 
+	Bot bool `json:"bot"`
+
 	MFAEnabled bool `json:"mfaEnabled"`
 
 	PushEnabled bool `json:"pushEnabled"`
@@ -100,6 +131,18 @@ type Asset struct {
 	Transform string `json:"transform,omitempty"`
 
 	Status string `json:"status,omitempty"`
+
+	Preview string `json:"preview,omitempty"`
+
+	MimeType string `json:"mimeType,omitempty"`
+
+	Width int `json:"width,omitempty"`
+
+	Height int `json:"height,omitempty"`
+
+	Duration float64 `json:"duration,omitempty"`
+
+	Error string `json:"error,omitempty"`
 }
 
 // Card slot for references to an account contact
@@ -141,6 +184,10 @@ type CardDetail struct {
 	Notes string `json:"notes,omitempty"`
 
 	Groups []string `json:"groups,omitempty"`
+
+	Blocked bool `json:"blocked,omitempty"`
+
+	Muted bool `json:"muted,omitempty"`
 }
 
 // CardProfile profile for account contact
@@ -159,6 +206,8 @@ type CardProfile struct {
 
 	Seal string `json:"seal,omitempty"`
 
+	Bot bool `json:"bot,omitempty"`
+
 	Version string `json:"version,omitempty"`
 
 	Node string `json:"node"`
@@ -212,6 +261,12 @@ type ChannelDetail struct {
 	Contacts *ChannelContacts `json:"contacts,omitempty"`
 
 	Members []string `json:"members"`
+
+	Roles map[string]string `json:"roles,omitempty"`
+
+	Muted bool `json:"muted,omitempty"`
+
+	Expire int64 `json:"expire,omitempty"`
 }
 
 // ChannelMember contact member of channel
@@ -270,9 +325,115 @@ type Connect struct {
 
 	Seal string `json:"seal,omitempty"`
 
+	Bot bool `json:"bot,omitempty"`
+
 	Node string `json:"node,omitempty"`
 }
 
+// ContactBlock sender guid or node whose contact requests are ignored
+type ContactBlock struct {
+	ID string `json:"id"`
+
+	GUID string `json:"guid,omitempty"`
+
+	Node string `json:"node,omitempty"`
+
+	Created int64 `json:"created"`
+}
+
+// NodePin certificate public key pinned for outbound connections to node
+type NodePin struct {
+	Node string `json:"node"`
+
+	Pin string `json:"pin"`
+
+	Created int64 `json:"created"`
+}
+
+// TranscodeJob running or failed asset transform
+type TranscodeJob struct {
+	AssetID string `json:"assetId"`
+
+	GUID string `json:"guid"`
+
+	Transform string `json:"transform"`
+
+	Queue string `json:"queue"`
+
+	Status string `json:"status"`
+
+	Progress int `json:"progress"`
+
+	Started int64 `json:"started,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	Worker string `json:"worker,omitempty"`
+
+	Updated int64 `json:"updated"`
+}
+
+// TranscodeWorker remote worker permitted to lease asset transforms
+type TranscodeWorker struct {
+	ID string `json:"id"`
+
+	Name string `json:"name,omitempty"`
+
+	Queues []string `json:"queues"`
+
+	Token string `json:"token,omitempty"`
+
+	LastSeen int64 `json:"lastSeen,omitempty"`
+
+	Created int64 `json:"created"`
+}
+
+// TranscodeWorkerParams params used when adding a remote worker
+type TranscodeWorkerParams struct {
+	Name string `json:"name"`
+
+	Queues []string `json:"queues"`
+}
+
+// TranscodeLease asset transform leased to a remote worker
+type TranscodeLease struct {
+	LeaseID string `json:"leaseId"`
+
+	Transform string `json:"transform"`
+
+	Params string `json:"params,omitempty"`
+
+	Queue string `json:"queue"`
+
+	Expires int64 `json:"expires"`
+}
+
+// NodeRule node domain pattern allowed or denied federation
+type NodeRule struct {
+	Pattern string `json:"pattern"`
+
+	Created int64 `json:"created"`
+}
+
+// AccountRetention days topics and assets of account are kept, zero for node default
+type AccountRetention struct {
+	TopicDays int64 `json:"topicDays"`
+
+	AssetDays int64 `json:"assetDays"`
+
+	MinDays int64 `json:"minDays,omitempty"`
+
+	MaxDays int64 `json:"maxDays,omitempty"`
+}
+
+// ContactPolicy which senders may request a connection with account,
+// nodes policy requires sender be verified on a listed node and contacts policy only sees contacts of local accounts
+type ContactPolicy struct {
+	Policy string `json:"policy"`
+
+	Nodes []string `json:"nodes,omitempty"`
+}
+
 // ContactStatus status of contact returned after connection message
 type ContactStatus struct {
 	Token string `json:"token,omitempty"`
@@ -345,6 +506,8 @@ type Identity struct {
 	Node string `json:"node"`
 
 	Seal string `json:"seal"`
+
+	Bot bool `json:"bot,omitempty"`
 }
 
 // IDList general list of ids
@@ -398,6 +561,39 @@ type NodeConfig struct {
 	EnableOpenAccess bool `json:"enableOpenAccess,omitempty"`
 
 	OpenAccessLimit int64 `json:"openAccessLimit,omitempty"`
+
+	APIHost string `json:"apiHost,omitempty"`
+
+	ScriptTransform bool `json:"scriptTransform,omitempty"`
+
+	VideoWorkers int64 `json:"videoWorkers,omitempty"`
+
+	AudioWorkers int64 `json:"audioWorkers,omitempty"`
+
+	PhotoWorkers int64 `json:"photoWorkers,omitempty"`
+
+	DefaultWorkers int64 `json:"defaultWorkers,omitempty"`
+
+	RemoteTranscode bool `json:"remoteTranscode,omitempty"`
+
+	ScrubMetadata bool `json:"scrubMetadata,omitempty"`
+
+	ScanMode string `json:"scanMode,omitempty"`
+
+	ScanAddress string `json:"scanAddress,omitempty"`
+
+	WebhookPrivate bool `json:"webhookPrivate,omitempty"`
+}
+
+// NodeDiscovery advertised protocol support of node
+type NodeDiscovery struct {
+	Version string `json:"version"`
+
+	KeyTypes []string `json:"keyTypes"`
+
+	Capabilities []string `json:"capabilities"`
+
+	API string `json:"api,omitempty"`
 }
 
 // Profile public attributes of account
@@ -416,6 +612,8 @@ type Profile struct {
 
 	Seal string `json:"seal,omitempty"`
 
+	Bot bool `json:"bot,omitempty"`
+
 	Revision int64 `json:"revision"`
 
 	Version string `json:"version,omitempty"`
@@ -491,6 +689,8 @@ type SignedData struct {
 	MessageType string `json:"messageType"`
 
 	Value string `json:"value"`
+
+	Nonce string `json:"nonce,omitempty"`
 }
 
 // Subject payload of attribute, channel, topic or tag
@@ -500,6 +700,19 @@ type Subject struct {
 	Data string `json:"data"`
 }
 
+// Succession data exchanged when an account replaces its identity key
+type Succession struct {
+	GUID string `json:"guid"`
+
+	PublicKey string `json:"publicKey"`
+
+	KeyType string `json:"keyType"`
+
+	Token string `json:"token"`
+
+	Revision int64 `json:"revision"`
+}
+
 // Tag slot for tags associated with topic
 type Tag struct {
 	ID string `json:"id"`
@@ -557,6 +770,27 @@ type TopicDetail struct {
 	Transform string `json:"transform,omitempty"`
 
 	ReadByMe bool `json:"readByMe"`
+
+	Parent string `json:"parent,omitempty"`
+
+	ReplyCount int64 `json:"replyCount,omitempty"`
+
+	Reactions map[string]int64 `json:"reactions,omitempty"`
+
+	Publish int64 `json:"publish,omitempty"`
+
+	Expires int64 `json:"expires,omitempty"`
+
+	Previews []Asset `json:"previews,omitempty"`
+}
+
+// Reaction emoji reaction of contact to topic
+type Reaction struct {
+	GUID string `json:"guid"`
+
+	Emoji string `json:"emoji"`
+
+	Created int64 `json:"created"`
 }
 
 type Call struct {
@@ -626,3 +860,74 @@ type PushMessage struct {
 type PushResponse struct {
 	Message string `json:"message"`
 }
+
+// Webhook account subscription receiving signed event deliveries
+type Webhook struct {
+	ID string `json:"id"`
+
+	URL string `json:"url"`
+
+	Secret string `json:"secret,omitempty"`
+
+	Events []string `json:"events"`
+
+	Created int64 `json:"created"`
+
+	Updated int64 `json:"updated"`
+}
+
+// WebhookParams params used when creating a webhook
+type WebhookParams struct {
+	URL string `json:"url"`
+
+	Secret string `json:"secret,omitempty"`
+
+	Events []string `json:"events"`
+}
+
+// WebhookDelivery record of an event delivered to a webhook
+type WebhookDelivery struct {
+	ID string `json:"id"`
+
+	Event string `json:"event"`
+
+	Status string `json:"status"`
+
+	Attempts int64 `json:"attempts"`
+
+	NextAttempt int64 `json:"nextAttempt,omitempty"`
+
+	ResponseCode int `json:"responseCode,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	Created int64 `json:"created"`
+
+	Updated int64 `json:"updated"`
+}
+
+// WebhookEvent payload posted to a webhook
+type WebhookEvent struct {
+	ID string `json:"id"`
+
+	Event string `json:"event"`
+
+	GUID string `json:"guid"`
+
+	Timestamp int64 `json:"timestamp"`
+
+	Data interface{} `json:"data,omitempty"`
+}
+
+// ApiKey key authenticating a bot account without a device session
+type ApiKey struct {
+	ID string `json:"id"`
+
+	Name string `json:"name,omitempty"`
+
+	Key string `json:"key,omitempty"`
+
+	LastUsed int64 `json:"lastUsed,omitempty"`
+
+	Created int64 `json:"created"`
+}
@@ -0,0 +1,166 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm/clause"
+	"net/http"
+	"path"
+	"sync"
+)
+
+var nodeRuleSync sync.Mutex
+var nodeRuleCache []store.NodeRule
+var nodeRuleLoaded bool
+
+// loadNodeRules returns admin rules, read from the store only after they change
+func loadNodeRules() ([]store.NodeRule, error) {
+	nodeRuleSync.Lock()
+	defer nodeRuleSync.Unlock()
+	if !nodeRuleLoaded {
+		var rules []store.NodeRule
+		if err := store.DB.Find(&rules).Error; err != nil {
+			return nil, err
+		}
+		nodeRuleCache = rules
+		nodeRuleLoaded = true
+	}
+	return nodeRuleCache, nil
+}
+
+func resetNodeRules() {
+	nodeRuleSync.Lock()
+	defer nodeRuleSync.Unlock()
+	nodeRuleLoaded = false
+}
+
+// isNodeLocal checks node refers to this node
+func isNodeLocal(node string) bool {
+	host := getNodeHost(node)
+	return host == "" || host == getNodeHost(getStrConfigValue(CNFDomain, ""))
+}
+
+// isNodeFederated checks node against admin deny list and, when set, allow list
+func isNodeFederated(node string) (bool, error) {
+
+	if isNodeLocal(node) {
+		return true, nil
+	}
+	host := getNodeHost(node)
+
+	rules, err := loadNodeRules()
+	if err != nil {
+		return false, err
+	}
+	allowed := true
+	for _, rule := range rules {
+		if rule.Mode == APPNodeAllow {
+			allowed = false
+		}
+	}
+	for _, rule := range rules {
+		if match, _ := path.Match(rule.Pattern, host); match {
+			if rule.Mode == APPNodeDeny {
+				return false, nil
+			}
+			allowed = true
+		}
+	}
+	return allowed, nil
+}
+
+// isCardFederated checks node of contact against admin rules, once contact is shown to be hosted on that node
+func isCardFederated(card *store.Card) (bool, error) {
+
+	if federated, err := isNodeFederated(card.Node); err != nil || !federated {
+		return false, err
+	}
+	if rules, err := loadNodeRules(); err != nil {
+		return false, err
+	} else if len(rules) == 0 || card.VerifiedNode == card.Node {
+		return true, nil
+	}
+
+	if isNodeLocal(card.Node) {
+		var count int64
+		if err := store.DB.Model(&store.Account{}).Where("guid = ?", card.GUID).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count == 0 {
+			return false, nil
+		}
+	} else if err := verifyContactNode(card.Node, card.GUID, card.OutToken); err != nil {
+		LogMsg("unverified contact node " + card.Node + ": " + err.Error())
+		return false, nil
+	}
+	if err := store.DB.Model(&store.Card{}).Where("id = ?", card.ID).Update("verified_node", card.Node).Error; err != nil {
+		return false, err
+	}
+	card.VerifiedNode = card.Node
+	return true, nil
+}
+
+// patterns match hostname of node where * matches any characters, i.e. *.example.org
+func getNodeRules(w http.ResponseWriter, r *http.Request, mode string) {
+
+	if code, err := ParamAdminToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	var rules []store.NodeRule
+	if err := store.DB.Where("mode = ?", mode).Order("pattern").Find(&rules).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := []NodeRule{}
+	for _, rule := range rules {
+		response = append(response, NodeRule{Pattern: rule.Pattern, Created: rule.Created})
+	}
+	WriteResponse(w, response)
+}
+
+func addNodeRule(w http.ResponseWriter, r *http.Request, mode string) {
+
+	if code, err := ParamAdminToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	pattern := getNodeHost(mux.Vars(r)["node"])
+	if pattern == "" {
+		ErrResponse(w, http.StatusBadRequest, errors.New("node not set"))
+		return
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		ErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := store.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&store.NodeRule{Pattern: pattern, Mode: mode}).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	resetNodeRules()
+
+	WriteResponse(w, nil)
+}
+
+func removeNodeRule(w http.ResponseWriter, r *http.Request, mode string) {
+
+	if code, err := ParamAdminToken(r); err != nil {
+		ErrResponse(w, code, err)
+		return
+	}
+
+	pattern := getNodeHost(mux.Vars(r)["node"])
+	if err := store.DB.Where("pattern = ? AND mode = ?", pattern, mode).Delete(&store.NodeRule{}).Error; err != nil {
+		ErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	resetNodeRules()
+
+	WriteResponse(w, nil)
+}
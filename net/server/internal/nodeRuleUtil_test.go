@@ -0,0 +1,88 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestFederationRules(t *testing.T) {
+
+	// setup testing accounts with remote contact
+	_, aToken, err := addTestAccount("federationruleA")
+	assert.NoError(t, err)
+	_, bToken, err := addTestAccount("federationruleB")
+	assert.NoError(t, err)
+	_, bCardID, err := connectTestCards(aToken, bToken)
+	assert.NoError(t, err)
+	contact, err := getCardToken(bToken, bCardID)
+	assert.NoError(t, err)
+	card := store.DB.Model(&store.Card{}).Where("in_token = ?", strings.Split(contact, ".")[1])
+	assert.NoError(t, card.Updates(map[string]interface{}{"node": "chat.example.org:8443", "verified_node": "chat.example.org:8443"}).Error)
+	channels := []Channel{}
+	assert.NoError(t, APITestMsg(GetChannels, "GET", "/content/channels",
+		nil, nil, APPTokenContact, contact, &channels, nil))
+
+	// wildcard deny rejects contact
+	params := &TestAPIParams{restType: "POST", query: "/admin/federation/deny/{node}?token=pass", path: map[string]string{"node": "*.Example.org"}}
+	assert.NoError(t, TestAPIRequest(AddFederationDeny, params, nil))
+	rules := []NodeRule{}
+	assert.NoError(t, TestAPIRequest(GetFederationDeny, &TestAPIParams{query: "/admin/federation/deny?token=pass"}, &TestAPIResponse{data: &rules}))
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, "*.example.org", rules[0].Pattern)
+	assert.Error(t, APITestMsg(GetChannels, "GET", "/content/channels",
+		nil, nil, APPTokenContact, contact, &channels, nil))
+	federated, err := isNodeFederated("example.org")
+	assert.NoError(t, err)
+	assert.True(t, federated)
+
+	// fully qualified name with trailing dot matched as well
+	federated, err = isNodeFederated("chat.example.org.:8443")
+	assert.NoError(t, err)
+	assert.False(t, federated)
+	assert.Equal(t, "chat.example.org", getNodeHost("Chat.Example.Org./databag"))
+
+	// local node always federated
+	federated, err = isNodeFederated("databag.coredb.org")
+	assert.NoError(t, err)
+	assert.True(t, federated)
+
+	// allow list restricts to listed nodes
+	params = &TestAPIParams{restType: "DELETE", query: "/admin/federation/deny/{node}?token=pass", path: map[string]string{"node": "*.example.org"}}
+	assert.NoError(t, TestAPIRequest(RemoveFederationDeny, params, nil))
+	params = &TestAPIParams{restType: "POST", query: "/admin/federation/allow/{node}?token=pass", path: map[string]string{"node": "other.org"}}
+	assert.NoError(t, TestAPIRequest(AddFederationAllow, params, nil))
+	assert.Error(t, APITestMsg(GetChannels, "GET", "/content/channels",
+		nil, nil, APPTokenContact, contact, &channels, nil))
+	params = &TestAPIParams{restType: "POST", query: "/admin/federation/allow/{node}?token=pass", path: map[string]string{"node": "chat.example.org"}}
+	assert.NoError(t, TestAPIRequest(AddFederationAllow, params, nil))
+	assert.NoError(t, APITestMsg(GetChannels, "GET", "/content/channels",
+		nil, nil, APPTokenContact, contact, &channels, nil))
+
+	// claimed node not hosting contact rejected while rules are set
+	card = store.DB.Model(&store.Card{}).Where("in_token = ?", strings.Split(contact, ".")[1])
+	assert.NoError(t, card.Update("node", "chat.example.org:9443").Error)
+	assert.Error(t, APITestMsg(GetChannels, "GET", "/content/channels",
+		nil, nil, APPTokenContact, contact, &channels, nil))
+	card = store.DB.Model(&store.Card{}).Where("in_token = ?", strings.Split(contact, ".")[1])
+	assert.NoError(t, card.Update("node", "chat.example.org:8443").Error)
+
+	// deny takes precedence over allow
+	params = &TestAPIParams{restType: "POST", query: "/admin/federation/deny/{node}?token=pass", path: map[string]string{"node": "chat.*"}}
+	assert.NoError(t, TestAPIRequest(AddFederationDeny, params, nil))
+	assert.Error(t, APITestMsg(GetChannels, "GET", "/content/channels",
+		nil, nil, APPTokenContact, contact, &channels, nil))
+
+	// invalid pattern rejected
+	params = &TestAPIParams{restType: "POST", query: "/admin/federation/deny/{node}?token=pass", path: map[string]string{"node": "[example.org"}}
+	assert.Error(t, TestAPIRequest(AddFederationDeny, params, nil))
+
+	// clear rules for other tests
+	params = &TestAPIParams{restType: "DELETE", query: "/admin/federation/deny/{node}?token=pass", path: map[string]string{"node": "chat.*"}}
+	assert.NoError(t, TestAPIRequest(RemoveFederationDeny, params, nil))
+	for _, rule := range []string{"other.org", "chat.example.org"} {
+		params = &TestAPIParams{restType: "DELETE", query: "/admin/federation/allow/{node}?token=pass", path: map[string]string{"node": rule}}
+		assert.NoError(t, TestAPIRequest(RemoveFederationAllow, params, nil))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"databag/internal/store"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"gorm.io/gorm"
@@ -35,11 +36,27 @@ func SendNotifications() {
 	for {
 		select {
 		case notification := <-notify:
+			var err error
 			node := getStrConfigValue(CNFDomain, "")
 			if notification.Node == "" || notification.Node == node {
-				sendLocalNotification(notification)
+				err = sendLocalNotification(notification)
+			} else if _, _, cached := getCachedDiscovery(notification.Node); !cached {
+				// resolve node in background without holding up other deliveries
+				pending := notification
+				refreshNodeDiscovery(notification.Node, func() {
+					notify <- pending
+				})
+				continue
 			} else {
-				sendRemoteNotification(notification)
+				err = sendRemoteNotification(notification)
+			}
+			if err != nil {
+				ErrMsg(err)
+				if notification.Module == APPNotifySuccession && retryNotification(notification) {
+					continue
+				}
+			} else if notification.Module == APPNotifySuccession {
+				acknowledgeSuccession(notification)
 			}
 			if err := store.DB.Delete(&notification).Error; err != nil {
 				ErrMsg(err)
@@ -50,47 +67,79 @@ func SendNotifications() {
 	}
 }
 
-func sendLocalNotification(notification *store.Notification) {
+// retryNotification requeues failed delivery with backoff, returning false once attempts are exhausted
+func retryNotification(notification *store.Notification) bool {
+	if notification.Attempts >= APPSuccessionAttempts {
+		LogMsg("contact did not receive key succession")
+		return false
+	}
+	delay := time.Duration(APPSuccessionRetry<<notification.Attempts) * time.Second
+	notification.Attempts++
+	if err := store.DB.Model(notification).Update("attempts", notification.Attempts).Error; err != nil {
+		ErrMsg(err)
+	}
+	time.AfterFunc(delay, func() {
+		select {
+		case notify <- notification:
+		default:
+			// saved notification is requeued on restart
+		}
+	})
+	return true
+}
+
+// acknowledgeSuccession stops accepting the token replaced by succession once the contact has received it
+func acknowledgeSuccession(notification *store.Notification) {
+	if err := store.DB.Model(&store.Card{}).Where("guid = ? AND out_token = ?", notification.GUID, notification.Token).Update("prior_in_token", "").Error; err != nil {
+		ErrMsg(err)
+	}
+}
+
+func sendLocalNotification(notification *store.Notification) error {
 
 	// pull reference account
+	if notification.Token == "" {
+		return errors.New("missing contact token")
+	}
 	var card store.Card
-	if err := store.DB.Preload("Account").Preload("CardSlot").Where("in_token = ?", notification.Token).First(&card).Error; err != nil {
-		ErrMsg(err)
-		return
+	if err := store.DB.Preload("Account").Preload("CardSlot").Where("in_token = ? OR prior_in_token = ?", notification.Token, notification.Token).First(&card).Error; err != nil {
+		return err
 	}
 	if card.Account.Disabled {
-		ErrMsg(errors.New("account is inactive"))
-		return
+		return errors.New("account is inactive")
 	}
 
 	if notification.Module == APPNotifyProfile {
-		if err := NotifyProfileRevision(&card, notification.Revision); err != nil {
-			ErrMsg(err)
-		}
+		return NotifyProfileRevision(&card, notification.Revision)
 	} else if notification.Module == APPNotifyArticle {
-		if err := NotifyArticleRevision(&card, notification.Revision); err != nil {
-			ErrMsg(err)
-		}
+		return NotifyArticleRevision(&card, notification.Revision)
 	} else if notification.Module == APPNotifyChannel {
-		if err := NotifyChannelRevision(&card, notification.Revision); err != nil {
-			ErrMsg(err)
-		}
+		return NotifyChannelRevision(&card, notification.Revision)
 	} else if notification.Module == APPNotifyView {
-		if err := NotifyViewRevision(&card, notification.Revision); err != nil {
-			ErrMsg(err)
-		}
+		return NotifyViewRevision(&card, notification.Revision)
 	} else if notification.Module == APPNotifyTopicRead {
-		if err := NotifyChannelRevision(&card, notification.Revision); err != nil {
-			ErrMsg(err)
+		return NotifyChannelRevision(&card, notification.Revision)
+	} else if notification.Module == APPNotifySuccession {
+		var message DataMessage
+		if err := json.Unmarshal([]byte(notification.Event), &message); err != nil {
+			return err
 		}
+		return NotifySuccession(&card, &message)
 	} else if notification.Module == APPPushNotify {
-		SendPushEvent(card.Account, notification.Event)
-	} else {
-		LogMsg("unknown notification type")
+		sendContactPushEvent(&card, notification.Event)
+		return nil
 	}
+	LogMsg("unknown notification type")
+	return nil
 }
 
-func sendRemoteNotification(notification *store.Notification) {
+func sendRemoteNotification(notification *store.Notification) error {
+
+	if federated, err := isNodeFederated(notification.Node); err != nil {
+		return err
+	} else if !federated {
+		return errors.New("contact node not federated")
+	}
 
 	var module string
 	if notification.Module == APPNotifyProfile {
@@ -103,66 +152,114 @@ func sendRemoteNotification(notification *store.Notification) {
 		module = "view/revision"
 	} else if notification.Module == APPNotifyTopicRead {
 		module = "channel/revision"
+	} else if notification.Module == APPNotifySuccession {
+		module = "succession"
 	} else if notification.Module == APPPushNotify {
 		module = "notification"
 	} else {
 		LogMsg("unknown notification type")
-		return
+		return nil
 	}
 
-	if module == "notification" {
+	base, discovery := getNodeDiscovery(notification.Node)
+	if !nodeCompatible(discovery) {
+		return errors.New("contact node api version not compatible")
+	}
+	if module == "succession" {
+		if !nodeCapable(discovery, APPCapabilitySuccession) {
+			return errors.New("contact node does not support key succession")
+		}
+
+		// contact must verify both the signing and successor keys
+		var message DataMessage
+		if err := json.Unmarshal([]byte(notification.Event), &message); err != nil {
+			return err
+		}
+		signType, err := getNodeSignType(discovery, message.KeyType)
+		if err != nil {
+			return err
+		}
+		if signType != message.SignatureType {
+			return errors.New("contact node does not support signature type " + message.SignatureType)
+		}
+		data, err := base64.StdEncoding.DecodeString(message.Message)
+		if err != nil {
+			return err
+		}
+		var signed SignedData
+		if err := json.Unmarshal(data, &signed); err != nil {
+			return err
+		}
+		var succession Succession
+		if err := json.Unmarshal([]byte(signed.Value), &succession); err != nil {
+			return err
+		}
+		if _, err := getNodeSignType(discovery, succession.KeyType); err != nil {
+			return err
+		}
+		url := base + "/contact/" + module + "?contact=" + notification.GUID + "." + notification.Token
+		req, err := http.NewRequest(http.MethodPut, url, bytes.NewBufferString(notification.Event))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		req = req.WithContext(ctx)
+		resp, err := getFederationClient().Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			return errors.New("failed to notify contact")
+		}
+	} else if module == "notification" {
 		body, err := json.Marshal(notification.Event)
 		if err != nil {
-			ErrMsg(err)
-			return
+			return err
 		}
-		url := "https://" + notification.Node + "/contact/" + module + "?contact=" + notification.GUID + "." + notification.Token
+		url := base + "/contact/" + module + "?contact=" + notification.GUID + "." + notification.Token
 		req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
 		if err != nil {
-			ErrMsg(err)
-			return
+			return err
 		}
 		req.Header.Set("Content-Type", "application/json; charset=utf-8")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		req = req.WithContext(ctx)
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		resp, err := getFederationClient().Do(req)
 		if err != nil {
-			ErrMsg(err)
-			return
+			return err
 		}
 		defer resp.Body.Close()
-		if resp == nil || resp.StatusCode != 200 {
-			LogMsg("failed to notify contact")
+		if resp.StatusCode != 200 {
+			return errors.New("failed to notify contact")
 		}
 	} else {
 		body, err := json.Marshal(notification.Revision)
 		if err != nil {
-			ErrMsg(err)
-			return
+			return err
 		}
-		url := "https://" + notification.Node + "/contact/" + module + "?contact=" + notification.GUID + "." + notification.Token
+		url := base + "/contact/" + module + "?contact=" + notification.GUID + "." + notification.Token
 		req, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(body))
 		if err != nil {
-			ErrMsg(err)
-			return
+			return err
 		}
 		req.Header.Set("Content-Type", "application/json; charset=utf-8")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		req = req.WithContext(ctx)
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		resp, err := getFederationClient().Do(req)
 		if err != nil {
-			ErrMsg(err)
-			return
+			return err
 		}
 		defer resp.Body.Close()
-		if resp == nil || resp.StatusCode != 200 {
-			LogMsg("failed to notify contact")
+		if resp.StatusCode != 200 {
+			return errors.New("failed to notify contact")
 		}
 	}
+	return nil
 }
 
 // SetProfileNotification notifies all connected contacts of profile changes
@@ -18,7 +18,13 @@ type routes []route
 // NewRouter allocate router for databag API
 func NewRouter(path string) *mux.Router {
 
+	recountStorage()
 	go SendNotifications()
+	go SendWebhooks()
+	go SweepTopics()
+	go SweepAssets()
+	go SweepNonces()
+	StartTranscode()
 
 	router := mux.NewRouter().StrictSlash(true)
 	for _, route := range endpoints {
@@ -202,6 +208,118 @@ var endpoints = routes{
 		SetAccountSearchable,
 	},
 
+	route{
+		"AddAccountWebhook",
+		strings.ToUpper("Post"),
+		"/account/webhooks",
+		AddAccountWebhook,
+	},
+
+	route{
+		"GetAccountWebhooks",
+		strings.ToUpper("Get"),
+		"/account/webhooks",
+		GetAccountWebhooks,
+	},
+
+	route{
+		"RemoveAccountWebhook",
+		strings.ToUpper("Delete"),
+		"/account/webhooks/{webhookID}",
+		RemoveAccountWebhook,
+	},
+
+	route{
+		"GetAccountWebhookDeliveries",
+		strings.ToUpper("Get"),
+		"/account/webhooks/{webhookID}/deliveries",
+		GetAccountWebhookDeliveries,
+	},
+
+	route{
+		"GetAccountRetention",
+		strings.ToUpper("Get"),
+		"/account/retention",
+		GetAccountRetention,
+	},
+
+	route{
+		"GetAccountStorage",
+		strings.ToUpper("Get"),
+		"/account/storage",
+		GetAccountStorage,
+	},
+
+	route{
+		"SetAccountRetention",
+		strings.ToUpper("Put"),
+		"/account/retention",
+		SetAccountRetention,
+	},
+
+	route{
+		"GetAccountContactPolicy",
+		strings.ToUpper("Get"),
+		"/account/contact/policy",
+		GetAccountContactPolicy,
+	},
+
+	route{
+		"SetAccountContactPolicy",
+		strings.ToUpper("Put"),
+		"/account/contact/policy",
+		SetAccountContactPolicy,
+	},
+
+	route{
+		"AddAccountContactBlock",
+		strings.ToUpper("Post"),
+		"/account/contact/blocks",
+		AddAccountContactBlock,
+	},
+
+	route{
+		"GetAccountContactBlocks",
+		strings.ToUpper("Get"),
+		"/account/contact/blocks",
+		GetAccountContactBlocks,
+	},
+
+	route{
+		"RemoveAccountContactBlock",
+		strings.ToUpper("Delete"),
+		"/account/contact/blocks/{blockID}",
+		RemoveAccountContactBlock,
+	},
+
+	route{
+		"SetAccountIdentity",
+		strings.ToUpper("Put"),
+		"/account/identity",
+		SetAccountIdentity,
+	},
+
+	route{
+		"AddAccountKey",
+		strings.ToUpper("Post"),
+		"/account/keys",
+		AddAccountKey,
+	},
+
+	route{
+		"GetAccountKeys",
+		strings.ToUpper("Get"),
+		"/account/keys",
+		GetAccountKeys,
+	},
+
+	route{
+		"RemoveAccountKey",
+		strings.ToUpper("Delete"),
+		"/account/keys/{keyID}",
+		RemoveAccountKey,
+	},
+
 	route{
 		"AddMultiFactorAuth",
 		strings.ToUpper("Post"),
@@ -244,6 +362,20 @@ var endpoints = routes{
 		SetNodeAccountStatus,
 	},
 
+	route{
+		"SetNodeAccountBot",
+		strings.ToUpper("Put"),
+		"/admin/accounts/{accountID}/bot",
+		SetNodeAccountBot,
+	},
+
+	route{
+		"SetNodeAccountStorage",
+		strings.ToUpper("Put"),
+		"/admin/accounts/{accountID}/storage",
+		SetNodeAccountStorage,
+	},
+
 	route{
 		"AddNodeAccountAccess",
 		strings.ToUpper("Post"),
@@ -272,6 +404,13 @@ var endpoints = routes{
 		GetNodeStatus,
 	},
 
+	route{
+		"GetNodeDiscovery",
+		strings.ToUpper("Get"),
+		"/.well-known/databag",
+		GetNodeDiscovery,
+	},
+
 	route{
 		"ImportAccount",
 		strings.ToUpper("Post"),
@@ -356,6 +495,139 @@ var endpoints = routes{
 		RemoveIPWhitelist,
 	},
 
+	route{
+		"GetFederationAllow",
+		strings.ToUpper("Get"),
+		"/admin/federation/allow",
+		GetFederationAllow,
+	},
+
+	route{
+		"AddFederationAllow",
+		strings.ToUpper("Post"),
+		"/admin/federation/allow/{node}",
+		AddFederationAllow,
+	},
+
+	route{
+		"RemoveFederationAllow",
+		strings.ToUpper("Delete"),
+		"/admin/federation/allow/{node}",
+		RemoveFederationAllow,
+	},
+
+	route{
+		"GetFederationDeny",
+		strings.ToUpper("Get"),
+		"/admin/federation/deny",
+		GetFederationDeny,
+	},
+
+	route{
+		"AddFederationDeny",
+		strings.ToUpper("Post"),
+		"/admin/federation/deny/{node}",
+		AddFederationDeny,
+	},
+
+	route{
+		"RemoveFederationDeny",
+		strings.ToUpper("Delete"),
+		"/admin/federation/deny/{node}",
+		RemoveFederationDeny,
+	},
+
+	route{
+		"GetNodePins",
+		strings.ToUpper("Get"),
+		"/admin/pins",
+		GetNodePins,
+	},
+
+	route{
+		"AddNodePin",
+		strings.ToUpper("Post"),
+		"/admin/pins/{node}",
+		AddNodePin,
+	},
+
+	route{
+		"RemoveNodePin",
+		strings.ToUpper("Delete"),
+		"/admin/pins/{node}",
+		RemoveNodePin,
+	},
+
+	route{
+		"GetTranscodeJobs",
+		strings.ToUpper("Get"),
+		"/admin/transcode",
+		GetTranscodeJobs,
+	},
+
+	route{
+		"CancelTranscodeJob",
+		strings.ToUpper("Delete"),
+		"/admin/transcode/{assetID}",
+		CancelTranscodeJob,
+	},
+
+	route{
+		"GetTranscodeWorkers",
+		strings.ToUpper("Get"),
+		"/admin/workers",
+		GetTranscodeWorkers,
+	},
+
+	route{
+		"AddTranscodeWorker",
+		strings.ToUpper("Post"),
+		"/admin/workers",
+		AddTranscodeWorker,
+	},
+
+	route{
+		"RemoveTranscodeWorker",
+		strings.ToUpper("Delete"),
+		"/admin/workers/{workerID}",
+		RemoveTranscodeWorker,
+	},
+
+	route{
+		"AddTranscodeLease",
+		strings.ToUpper("Post"),
+		"/transcode/leases",
+		AddTranscodeLease,
+	},
+
+	route{
+		"GetTranscodeLeaseSource",
+		strings.ToUpper("Get"),
+		"/transcode/leases/{leaseID}/source",
+		GetTranscodeLeaseSource,
+	},
+
+	route{
+		"SetTranscodeLeaseProgress",
+		strings.ToUpper("Put"),
+		"/transcode/leases/{leaseID}/progress",
+		SetTranscodeLeaseProgress,
+	},
+
+	route{
+		"SetTranscodeLeaseOutput",
+		strings.ToUpper("Put"),
+		"/transcode/leases/{leaseID}/output",
+		SetTranscodeLeaseOutput,
+	},
+
+	route{
+		"RemoveTranscodeLease",
+		strings.ToUpper("Delete"),
+		"/transcode/leases/{leaseID}",
+		RemoveTranscodeLease,
+	},
+
 	route{
 		"RemoveNodeAccount",
 		strings.ToUpper("Delete"),
@@ -398,6 +670,13 @@ var endpoints = routes{
 		GetCleanupStatus,
 	},
 
+	route{
+		"FsckAssets",
+		strings.ToUpper("Post"),
+		"/admin/cleanup/fsck",
+		FsckAssets,
+	},
+
 	route{
 		"GetCleanupConfig",
 		strings.ToUpper("Get"),
@@ -608,6 +887,20 @@ var endpoints = routes{
 		SetCardNotes,
 	},
 
+	route{
+		"SetCardBlocked",
+		strings.ToUpper("Put"),
+		"/contact/cards/{cardID}/blocked",
+		SetCardBlocked,
+	},
+
+	route{
+		"SetCardMuted",
+		strings.ToUpper("Put"),
+		"/contact/cards/{cardID}/muted",
+		SetCardMuted,
+	},
+
 	route{
 		"SetCardProfile",
 		strings.ToUpper("Put"),
@@ -657,6 +950,13 @@ var endpoints = routes{
 		SetViewRevision,
 	},
 
+	route{
+		"SetSuccession",
+		strings.ToUpper("Put"),
+		"/contact/succession",
+		SetSuccession,
+	},
+
 	route{
 		"SetPushEvent",
 		strings.ToUpper("Post"),
@@ -720,6 +1020,13 @@ var endpoints = routes{
 		GetChannelTopicAsset,
 	},
 
+	route{
+		"GetChannelTopicAssetStream",
+		strings.ToUpper("Get"),
+		"/content/channels/{channelID}/topics/{topicID}/assets/{assetID}/stream/{file}",
+		GetChannelTopicAssetStream,
+	},
+
 	route{
 		"GetChannelTopicAssets",
 		strings.ToUpper("Get"),
@@ -755,6 +1062,13 @@ var endpoints = routes{
 		GetChannelTopic,
 	},
 
+	route{
+		"GetChannelTopicReplies",
+		strings.ToUpper("Get"),
+		"/content/channels/{channelID}/topics/{topicID}/replies",
+		GetChannelTopicReplies,
+	},
+
 	route{
 		"GetChannelTopicDetail",
 		strings.ToUpper("Get"),
@@ -783,6 +1097,27 @@ var endpoints = routes{
 		GetChannelTopicTags,
 	},
 
+	route{
+		"AddChannelTopicReaction",
+		strings.ToUpper("Post"),
+		"/content/channels/{channelID}/topics/{topicID}/reactions",
+		AddChannelTopicReaction,
+	},
+
+	route{
+		"GetChannelTopicReactions",
+		strings.ToUpper("Get"),
+		"/content/channels/{channelID}/topics/{topicID}/reactions",
+		GetChannelTopicReactions,
+	},
+
+	route{
+		"RemoveChannelTopicReaction",
+		strings.ToUpper("Delete"),
+		"/content/channels/{channelID}/topics/{topicID}/reactions/{emoji}",
+		RemoveChannelTopicReaction,
+	},
+
 	route{
 		"GetChannelTopics",
 		strings.ToUpper("Get"),
@@ -832,6 +1167,13 @@ var endpoints = routes{
 		SetChannelCard,
 	},
 
+	route{
+		"SetChannelCardRole",
+		strings.ToUpper("Put"),
+		"/content/channels/{channelID}/cards/{cardID}/role",
+		SetChannelCardRole,
+	},
+
 	route{
 		"SetChannelTopicConfirmed",
 		strings.ToUpper("Put"),
@@ -860,6 +1202,20 @@ var endpoints = routes{
 		SetChannelGroup,
 	},
 
+	route{
+		"SetChannelMuted",
+		strings.ToUpper("Put"),
+		"/content/channels/{channelID}/muted",
+		SetChannelMuted,
+	},
+
+	route{
+		"SetChannelExpire",
+		strings.ToUpper("Put"),
+		"/content/channels/{channelID}/expire",
+		SetChannelExpire,
+	},
+
 	route{
 		"GetChannelNotification",
 		strings.ToUpper("Get"),
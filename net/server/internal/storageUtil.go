@@ -0,0 +1,71 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"gorm.io/gorm"
+)
+
+// adjustStorage changes storage counted against account within transaction
+func adjustStorage(tx *gorm.DB, accountID uint, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	return tx.Model(&store.Account{}).Where("id = ?", accountID).UpdateColumn("storage_used", gorm.Expr("storage_used + ?", delta)).Error
+}
+
+// removeAssets deletes selected asset records and releases their storage
+func removeAssets(tx *gorm.DB, query string, args ...interface{}) error {
+	var usage []struct {
+		AccountID uint
+		Size      int64
+	}
+	if res := tx.Model(&store.Asset{}).Select("account_id, SUM(size) AS size").Where(query, args...).Group("account_id").Scan(&usage).Error; res != nil {
+		return res
+	}
+	if res := tx.Where(query, args...).Delete(&store.Asset{}).Error; res != nil {
+		return res
+	}
+	for _, account := range usage {
+		if res := adjustStorage(tx, account.AccountID, -account.Size); res != nil {
+			return res
+		}
+	}
+	return nil
+}
+
+// getStorageQuota returns bytes account may store, admin override replaces node default, 0 for unlimited
+func getStorageQuota(act *store.Account) int64 {
+	if act.StorageQuota > 0 {
+		return act.StorageQuota
+	}
+	if act.StorageQuota < 0 {
+		return 0
+	}
+	return getNumConfigValue(CNFStorage, 0)
+}
+
+// getStorageAvailable returns bytes account may still store, -1 for unlimited
+func getStorageAvailable(act *store.Account) int64 {
+	quota := getStorageQuota(act)
+	if quota == 0 {
+		return -1
+	}
+	if act.StorageUsed >= quota {
+		return 0
+	}
+	return quota - act.StorageUsed
+}
+
+// isStorageFull checks whether account has reached its storage quota
+func isStorageFull(act *store.Account) bool {
+	quota := getStorageQuota(act)
+	return quota > 0 && act.StorageUsed >= quota
+}
+
+// recountStorage resets usage counters from asset records, correcting any drift
+func recountStorage() {
+	if err := store.DB.Model(&store.Account{}).Where("1 = 1").UpdateColumn("storage_used",
+		gorm.Expr("(SELECT COALESCE(SUM(assets.size), 0) FROM assets WHERE assets.account_id = accounts.id)")).Error; err != nil {
+		ErrMsg(err)
+	}
+}
@@ -29,6 +29,16 @@ func AutoMigrate(db *gorm.DB) {
 	db.AutoMigrate(&Flag{})
 	db.AutoMigrate(&IPBlock{})
 	db.AutoMigrate(&IPWhitelist{})
+	db.AutoMigrate(&Webhook{})
+	db.AutoMigrate(&WebhookDelivery{})
+	db.AutoMigrate(&ApiKey{})
+	db.AutoMigrate(&Reaction{})
+	db.AutoMigrate(&Succession{})
+	db.AutoMigrate(&Nonce{})
+	db.AutoMigrate(&ContactBlock{})
+	db.AutoMigrate(&NodePin{})
+	db.AutoMigrate(&NodeRule{})
+	db.AutoMigrate(&TranscodeWorker{})
 }
 
 type Notification struct {
@@ -39,6 +49,38 @@ type Notification struct {
 	Token    string `gorm:"not null"`
 	Revision int64  `gorm:"not null"`
 	Event    string
+	Attempts int64 `gorm:"not null;default:0"`
+}
+
+type Nonce struct {
+	ID      uint   `gorm:"primaryKey;not null;unique;autoIncrement"`
+	GUID    string `gorm:"not null;index:noncesigner,unique"`
+	Nonce   string `gorm:"not null;index:noncesigner,unique"`
+	Expires int64  `gorm:"not null;index"`
+}
+
+type NodePin struct {
+	ID      uint   `gorm:"primaryKey;not null;unique;autoIncrement"`
+	Node    string `gorm:"not null;index:nodepin,unique"`
+	Pin     string `gorm:"not null;index:nodepin,unique"`
+	Created int64  `gorm:"autoCreateTime"`
+}
+
+type NodeRule struct {
+	ID      uint   `gorm:"primaryKey;not null;unique;autoIncrement"`
+	Pattern string `gorm:"not null;index:noderule,unique"`
+	Mode    string `gorm:"not null;index:noderule,unique"`
+	Created int64  `gorm:"autoCreateTime"`
+}
+
+type TranscodeWorker struct {
+	ID       uint   `gorm:"primaryKey;not null;unique;autoIncrement"`
+	WorkerID string `gorm:"not null;uniqueIndex"`
+	Name     string
+	Token    string `gorm:"not null"`
+	Queues   string
+	LastSeen int64
+	Created  int64 `gorm:"autoCreateTime"`
 }
 
 type Config struct {
@@ -98,6 +140,7 @@ type Account struct {
 	Updated          int64  `gorm:"autoUpdateTime"`
 	Disabled         bool   `gorm:"not null;default:false"`
 	Searchable       bool   `gorm:"not null;default:false"`
+	Bot              bool   `gorm:"not null;default:false"`
 	MFAEnabled       bool   `gorm:"not null;default:false"`
 	MFAConfirmed     bool   `gorm:"not null;default:false"`
 	MFASecret        string
@@ -107,6 +150,12 @@ type Account struct {
 	LoginFailedTime  int64
 	LoginFailedCount uint
 	Forward          string
+	ContactPolicy    string `gorm:"not null;default:anyone"`
+	ContactNodes     string
+	TopicRetention   int64 `gorm:"not null;default:0"`
+	AssetRetention   int64 `gorm:"not null;default:0"`
+	StorageUsed      int64 `gorm:"not null;default:0"`
+	StorageQuota     int64 `gorm:"not null;default:0"`
 	AccountDetail    AccountDetail
 	Apps             []App
 	Assets           []Asset
@@ -166,6 +215,35 @@ type App struct {
 	Account     Account `gorm:"references:GUID"`
 }
 
+type ApiKey struct {
+	ID        uint   `gorm:"primaryKey;not null;unique;autoIncrement"`
+	ApiKeyID  string `gorm:"not null;uniqueIndex"`
+	AccountID string `gorm:"not null;index:apikeyguid,unique"`
+	Name      string
+	Token     string `gorm:"not null;index:apikeyguid,unique"`
+	LastUsed  int64
+	Created   int64   `gorm:"autoCreateTime"`
+	Account   Account `gorm:"references:GUID"`
+}
+
+type Succession struct {
+	ID        uint   `gorm:"primaryKey;not null;unique;autoIncrement"`
+	AccountID uint   `gorm:"not null;index"`
+	PriorGUID string `gorm:"not null;uniqueIndex"`
+	GUID      string `gorm:"not null;index"`
+	Created   int64  `gorm:"autoCreateTime"`
+}
+
+type ContactBlock struct {
+	ID        uint   `gorm:"primaryKey;not null;unique;autoIncrement"`
+	BlockID   string `gorm:"not null;uniqueIndex"`
+	AccountID uint   `gorm:"not null;index"`
+	GUID      string `gorm:"index"`
+	Node      string `gorm:"index"`
+	Created   int64  `gorm:"autoCreateTime"`
+	Account   Account
+}
+
 type GroupSlot struct {
 	ID          uint
 	GroupSlotID string `gorm:"not null;index:groupslot,unique"`
@@ -216,6 +294,7 @@ type Card struct {
 	Location        string
 	Image           string
 	Seal            string
+	Bot             bool
 	Version         string `gorm:"not null"`
 	Node            string `gorm:"not null"`
 	ProfileRevision int64  `gorm:"not null"`
@@ -223,8 +302,12 @@ type Card struct {
 	Status          string `gorm:"not null"`
 	StatusUpdated   int64
 	InToken         string `gorm:"not null;index:cardguid,unique"`
+	PriorInToken    string
+	VerifiedNode    string
 	OutToken        string
 	Notes           string
+	Blocked         bool  `gorm:"not null;default:false"`
+	Muted           bool  `gorm:"not null;default:false"`
 	Created         int64 `gorm:"autoCreateTime"`
 	Updated         int64 `gorm:"autoUpdateTime"`
 	ViewRevision    int64 `gorm:"not null;default:1"`
@@ -277,6 +360,8 @@ type Channel struct {
 	DataType       string `gorm:"index"`
 	Data           string
 	HostPush       bool
+	Muted          bool    `gorm:"not null;default:false"`
+	Expire         int64   `gorm:"not null;default:0"`
 	Created        int64   `gorm:"autoCreateTime"`
 	Updated        int64   `gorm:"autoUpdateTime"`
 	Groups         []Group `gorm:"many2many:channel_groups;"`
@@ -290,6 +375,7 @@ type Member struct {
 	ChannelID   int
 	CardID      int
 	PushEnabled bool
+	Role        string `gorm:"not null;default:poster"`
 	Card        Card
 	Channel     *Channel
 }
@@ -299,6 +385,7 @@ type TopicSlot struct {
 	TopicSlotID string `gorm:"not null;index:topicaccount,unique;index:topicchannel,unique"`
 	AccountID   uint   `gorm:"not null;index:topicaccount,unique"`
 	ChannelID   int    `gorm:"not null;index:topicchannel,unique"`
+	ParentID    uint   `gorm:"not null;default:0;index"`
 	Revision    int64  `gorm:"not null"`
 	Topic       *Topic
 	Channel     *Channel
@@ -319,31 +406,48 @@ type Topic struct {
 	Updated        int64  `gorm:"autoUpdateTime"`
 	TagRevision    int64  `gorm:"not null"`
 	ReadCount      int64  `gorm:"not null;default:0"`
+	ParentSlotID   string
+	ReplyCount     int64 `gorm:"not null;default:0"`
+	Publish        int64 `gorm:"not null;default:0"`
+	Expires        int64 `gorm:"not null;default:0;index"`
 	Account        Account
 	Channel        *Channel
 	Assets         []Asset
 	Tags           []Tag
+	Reactions      []Reaction
 	TopicSlot      TopicSlot
 }
 
 type Asset struct {
-	ID              uint   `gorm:"primaryKey;not null;unique;autoIncrement"`
-	AssetID         string `gorm:"not null;index:asset,unique"`
-	AccountID       uint   `gorm:"not null;index:asset,unique"`
-	ChannelID       int
-	TopicID         uint
-	Status          string `gorm:"not null;index"`
-	Size            int64
-	Crc             uint32
-	Transform       string
-	TransformID     string
-	TransformParams string
-	TransformQueue  string
-	Created         int64 `gorm:"autoCreateTime"`
-	Updated         int64 `gorm:"autoUpdateTime"`
-	Account         Account
-	Channel         *Channel
-	Topic           *Topic
+	ID                uint   `gorm:"primaryKey;not null;unique;autoIncrement"`
+	AssetID           string `gorm:"not null;index:asset,unique"`
+	AccountID         uint   `gorm:"not null;index:asset,unique"`
+	ChannelID         int
+	TopicID           uint
+	Status            string `gorm:"not null;index"`
+	Size              int64
+	Crc               uint32
+	Transform         string
+	TransformID       string
+	TransformParams   string
+	TransformQueue    string
+	TransformStarted  int64
+	TransformProgress int
+	TransformError    string
+	TransformAttempts int
+	TransformWorker   string
+	TransformLease    string `gorm:"index"`
+	TransformExpires  int64
+	Preview           string
+	MimeType          string
+	Width             int
+	Height            int
+	Duration          float64
+	Created           int64 `gorm:"autoCreateTime"`
+	Updated           int64 `gorm:"autoUpdateTime"`
+	Account           Account
+	Channel           *Channel
+	Topic             *Topic
 }
 
 type TagSlot struct {
@@ -374,3 +478,43 @@ type Tag struct {
 	Topic     *Topic
 	TagSlot   TagSlot
 }
+
+type Reaction struct {
+	ID        uint `gorm:"primaryKey;not null;unique;autoIncrement"`
+	AccountID uint
+	ChannelID int    `gorm:"not null;index:channelreaction"`
+	TopicID   uint   `gorm:"not null;index:topicreaction,unique"`
+	GUID      string `gorm:"not null;index:topicreaction,unique"`
+	Emoji     string `gorm:"not null;index:topicreaction,unique"`
+	Created   int64  `gorm:"autoCreateTime"`
+	Topic     *Topic
+}
+
+type Webhook struct {
+	ID        uint   `gorm:"primaryKey;not null;unique;autoIncrement"`
+	WebhookID string `gorm:"not null;uniqueIndex"`
+	AccountID uint   `gorm:"not null;index"`
+	URL       string `gorm:"not null"`
+	Secret    string `gorm:"not null"`
+	Events    string
+	Created   int64 `gorm:"autoCreateTime"`
+	Updated   int64 `gorm:"autoUpdateTime"`
+	Account   Account
+}
+
+type WebhookDelivery struct {
+	ID           uint   `gorm:"primaryKey;not null;unique;autoIncrement"`
+	DeliveryID   string `gorm:"not null;uniqueIndex"`
+	WebhookID    uint   `gorm:"not null;index"`
+	AccountID    uint   `gorm:"not null;index"`
+	Event        string `gorm:"not null"`
+	Payload      string
+	Status       string `gorm:"not null;index"`
+	Attempts     int64  `gorm:"not null;default:0"`
+	NextAttempt  int64  `gorm:"not null;default:0"`
+	ResponseCode int
+	Error        string
+	Created      int64 `gorm:"autoCreateTime"`
+	Updated      int64 `gorm:"autoUpdateTime"`
+	Webhook      *Webhook
+}
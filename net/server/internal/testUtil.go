@@ -581,6 +581,20 @@ func openTestCard(account string, cardID string) (err error) {
 	return
 }
 
+func connectTestCards(account string, contact string) (cardID string, contactCardID string, err error) {
+	if cardID, err = addTestCard(account, contact); err != nil {
+		return
+	}
+	if err = openTestCard(account, cardID); err != nil {
+		return
+	}
+	if contactCardID, err = addTestCard(contact, account); err != nil {
+		return
+	}
+	err = openTestCard(contact, contactCardID)
+	return
+}
+
 func addTestCard(account string, contact string) (cardID string, err error) {
 	var r *http.Request
 	var w *httptest.ResponseRecorder
@@ -608,6 +622,39 @@ func addTestCard(account string, contact string) (cardID string, err error) {
 	return
 }
 
+func addTestChannelTopic(username string, topicData string) (guid string, token string, params map[string]string, err error) {
+	if guid, token, err = addTestAccount(username); err != nil {
+		return
+	}
+	var channelID string
+	if channelID, err = addTestChannel(token); err != nil {
+		return
+	}
+	params, err = addTestTopic(token, channelID, topicData)
+	return
+}
+
+func addTestChannel(token string) (channelID string, err error) {
+	var channel Channel
+	subject := &Subject{Data: "channeldata", DataType: "channeldatatype"}
+	if err = APITestMsg(AddChannel, "POST", "/content/channels", nil, subject, APPTokenAgent, token, &channel, nil); err != nil {
+		return
+	}
+	channelID = channel.ID
+	return
+}
+
+func addTestTopic(token string, channelID string, topicData string) (params map[string]string, err error) {
+	var topic Topic
+	params = map[string]string{"channelID": channelID}
+	subject := &Subject{Data: topicData, DataType: "topicdatatype"}
+	if err = APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics", &params, subject, APPTokenAgent, token, &topic, nil); err != nil {
+		return
+	}
+	params["topicID"] = topic.ID
+	return
+}
+
 func addTestAccount(username string) (guid string, token string, err error) {
 	var r *http.Request
 	var w *httptest.ResponseRecorder
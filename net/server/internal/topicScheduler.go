@@ -0,0 +1,198 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"gorm.io/gorm"
+	"time"
+)
+
+var topicSweepExit = make(chan bool)
+
+// ExitTopicSweep stop publishing scheduled and removing expired topics
+func ExitTopicSweep() {
+	topicSweepExit <- true
+}
+
+// SweepTopics publishes scheduled topics and removes expired topics as their time arrives
+func SweepTopics() {
+
+	ticker := time.NewTicker(APPTopicSweepInterval * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sweepTopics(time.Now().Unix())
+		case <-topicSweepExit:
+			return
+		}
+	}
+}
+
+func sweepTopics(now int64) {
+
+	var scheduled []store.Topic
+	if err := store.DB.Where("status = ? AND publish <= ?", APPTopicScheduled, now).Find(&scheduled).Error; err != nil {
+		ErrMsg(err)
+	}
+	for _, topic := range scheduled {
+		if err := sweepTopic(&topic, true); err != nil {
+			ErrMsg(err)
+		}
+	}
+
+	var expired []store.Topic
+	if err := store.DB.Where("expires > ? AND expires <= ?", 0, now).Find(&expired).Error; err != nil {
+		ErrMsg(err)
+	}
+	for _, topic := range expired {
+		if err := sweepTopic(&topic, false); err != nil {
+			ErrMsg(err)
+		}
+	}
+}
+
+// sweepTopic publishes or removes topic with revisions bumped as if its author had
+func sweepTopic(topic *store.Topic, publish bool) error {
+
+	var channelSlot store.ChannelSlot
+	if err := store.DB.Preload("Account").Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Where("channel_id = ?", topic.ChannelID).First(&channelSlot).Error; err != nil {
+		return err
+	}
+	if channelSlot.Channel == nil {
+		return nil
+	}
+	var topicSlot store.TopicSlot
+	if err := store.DB.Preload("Topic").Where("id = ?", topic.TopicSlotID).First(&topicSlot).Error; err != nil {
+		return err
+	}
+	if topicSlot.Topic == nil {
+		return nil
+	}
+	act := &channelSlot.Account
+
+	err := store.DB.Transaction(func(tx *gorm.DB) error {
+		if !publish {
+			return removeTopicSlot(tx, act, &channelSlot, &topicSlot)
+		}
+		if res := tx.Model(topicSlot.Topic).Updates(map[string]interface{}{"status": APPTopicConfirmed, "detail_revision": act.ChannelRevision + 1}).Error; res != nil {
+			return res
+		}
+		if res := updateReplyCount(tx, topicSlot.ParentID, 1, act.ChannelRevision+1); res != nil {
+			return res
+		}
+		if res := tx.Model(&topicSlot).Update("revision", act.ChannelRevision+1).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(channelSlot.Channel).Update("topic_revision", act.ChannelRevision+1).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(&channelSlot).Update("revision", act.ChannelRevision+1).Error; res != nil {
+			return res
+		}
+		if res := tx.Model(act).Update("channel_revision", act.ChannelRevision+1).Error; res != nil {
+			return res
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// determine affected contact list
+	cards := make(map[string]store.Card)
+	notify := make(map[string]store.Card)
+	for _, member := range channelSlot.Channel.Members {
+		cards[member.Card.GUID] = member.Card
+		if publish && member.PushEnabled && member.Card.GUID != topic.GUID {
+			notify[member.Card.GUID] = member.Card
+		}
+	}
+	for _, group := range channelSlot.Channel.Groups {
+		for _, card := range group.Cards {
+			cards[card.GUID] = card
+		}
+	}
+
+	if !publish {
+		go garbageCollect(act)
+	}
+	SetStatus(act)
+	for _, card := range cards {
+		SetContactChannelNotification(act, &card)
+	}
+	for _, card := range notify {
+		SetContactPushNotification(&card, "content.addChannelTopic."+channelSlot.Channel.DataType)
+	}
+	if publish && act.GUID != topic.GUID && !channelSlot.Channel.Muted {
+		if card, ok := cards[topic.GUID]; !ok || (!card.Muted && !card.Blocked) {
+			go SendPushEvent(*act, "content.addChannelTopic."+channelSlot.Channel.DataType)
+		}
+	}
+	return nil
+}
+
+// scheduled topics are only visible to their author until published
+func isTopicHidden(topic *store.Topic, guid string) bool {
+	return !isTopicPublished(topic) && topic.GUID != guid
+}
+
+func isTopicPublished(topic *store.Topic) bool {
+	return topic.Publish == 0 || topic.Status == APPTopicConfirmed
+}
+
+// updateReplyCount adjusts reply count of thread parent as replies are published or removed, hidden replies are not counted
+func updateReplyCount(tx *gorm.DB, parentID uint, delta int64, revision int64) error {
+	if parentID == 0 {
+		return nil
+	}
+	count := gorm.Expr("reply_count + ?", delta)
+	if delta < 0 {
+		count = gorm.Expr("CASE WHEN reply_count > ? THEN reply_count - ? ELSE 0 END", -delta, -delta)
+	}
+	if res := tx.Model(&store.Topic{}).Where("topic_slot_id = ?", parentID).Updates(map[string]interface{}{"reply_count": count, "detail_revision": revision}).Error; res != nil {
+		return res
+	}
+	return tx.Model(&store.TopicSlot{}).Where("id = ?", parentID).Update("revision", revision).Error
+}
+
+// removeTopicSlot deletes topic and its attachments leaving slot to sync removal
+func removeTopicSlot(tx *gorm.DB, act *store.Account, channelSlot *store.ChannelSlot, topicSlot *store.TopicSlot) error {
+
+	if res := tx.Where("topic_id = ?", topicSlot.Topic.ID).Delete(&store.Tag{}).Error; res != nil {
+		return res
+	}
+	if res := tx.Where("topic_id = ?", topicSlot.Topic.ID).Delete(&store.Reaction{}).Error; res != nil {
+		return res
+	}
+	if res := tx.Where("topic_id = ?", topicSlot.Topic.ID).Delete(&store.TagSlot{}).Error; res != nil {
+		return res
+	}
+	if res := removeAssets(tx, "topic_id = ?", topicSlot.Topic.ID); res != nil {
+		return res
+	}
+	cancelTopicTranscode(topicSlot.Topic.ID)
+	visible := isTopicPublished(topicSlot.Topic)
+	if res := tx.Delete(&topicSlot.Topic).Error; res != nil {
+		return res
+	}
+	topicSlot.Topic = nil
+	if res := tx.Model(topicSlot).Update("revision", act.ChannelRevision+1).Error; res != nil {
+		return res
+	}
+	if visible {
+		if res := updateReplyCount(tx, topicSlot.ParentID, -1, act.ChannelRevision+1); res != nil {
+			return res
+		}
+	}
+	if res := tx.Model(&channelSlot.Channel).Update("topic_revision", act.ChannelRevision+1).Error; res != nil {
+		return res
+	}
+	if res := tx.Model(channelSlot).Update("revision", act.ChannelRevision+1).Error; res != nil {
+		return res
+	}
+	if res := tx.Model(act).Update("channel_revision", act.ChannelRevision+1).Error; res != nil {
+		return res
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package databag
+
+import (
+	"bytes"
+	"context"
+	"databag/internal/store"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type transcodeJob struct {
+	id        uint
+	channelID int
+	topicID   uint
+	ctx       context.Context
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+var transcodeJobs = make(map[uint]*transcodeJob)
+var transcodeJobSync sync.Mutex
+
+// getTranscodeTimeout returns seconds allowed for a transform on the queue
+func getTranscodeTimeout(queue string) time.Duration {
+	switch queue {
+	case APPQueueVideo:
+		return APPTranscodeVideoTimeout * time.Second
+	case APPQueueAudio:
+		return APPTranscodeAudioTimeout * time.Second
+	case APPQueuePhoto:
+		return APPTranscodePhotoTimeout * time.Second
+	default:
+		return APPTranscodeDefaultTimeout * time.Second
+	}
+}
+
+// startTranscodeJob registers job bounded by queue timeout so it can be cancelled
+func startTranscodeJob(asset *store.Asset) *transcodeJob {
+	ctx, cancel := context.WithTimeout(context.Background(), getTranscodeTimeout(asset.TransformQueue))
+	job := &transcodeJob{id: asset.ID, channelID: asset.ChannelID, topicID: asset.TopicID, ctx: ctx, cancel: cancel}
+	transcodeJobSync.Lock()
+	defer transcodeJobSync.Unlock()
+	transcodeJobs[asset.ID] = job
+	return job
+}
+
+// endTranscodeJob unregisters job and reports whether it was cancelled
+func endTranscodeJob(job *transcodeJob) bool {
+	transcodeJobSync.Lock()
+	defer transcodeJobSync.Unlock()
+	if transcodeJobs[job.id] == job {
+		delete(transcodeJobs, job.id)
+	}
+	return job.cancelled
+}
+
+// watchTranscodeJob cancels job if its asset is removed while running
+func watchTranscodeJob(job *transcodeJob) {
+	ticker := time.NewTicker(APPTranscodeCheckInterval * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-job.ctx.Done():
+			return
+		case <-ticker.C:
+			var count int64
+			if err := store.DB.Model(&store.Asset{}).Where("id = ?", job.id).Count(&count).Error; err != nil {
+				ErrMsg(err)
+			} else if count == 0 {
+				cancelTranscodeJobs(func(running *transcodeJob) bool { return running == job })
+			}
+		}
+	}
+}
+
+// cancelTranscodeJobs stops running jobs selected by match and returns count
+func cancelTranscodeJobs(match func(job *transcodeJob) bool) int {
+	transcodeJobSync.Lock()
+	defer transcodeJobSync.Unlock()
+	count := 0
+	for _, job := range transcodeJobs {
+		if match(job) {
+			job.cancelled = true
+			job.cancel()
+			count++
+		}
+	}
+	return count
+}
+
+// cancelTopicTranscode stops running jobs for assets of topic
+func cancelTopicTranscode(topicID uint) {
+	cancelTranscodeJobs(func(job *transcodeJob) bool { return job.topicID == topicID })
+}
+
+// cancelChannelTranscode stops running jobs for assets of channel
+func cancelChannelTranscode(channelID int) {
+	cancelTranscodeJobs(func(job *transcodeJob) bool { return job.channelID == channelID })
+}
+
+var transcodeDuration = regexp.MustCompile(`Duration: (\d+):(\d+):(\d+(?:\.\d+)?)`)
+var transcodeTime = regexp.MustCompile(`time=(\d+):(\d+):(\d+(?:\.\d+)?)`)
+
+// transcodeProgress parses ffmpeg output and stores percent complete of asset
+type transcodeProgress struct {
+	id       uint
+	duration float64
+	percent  int
+	updated  time.Time
+	line     []byte
+}
+
+func (p *transcodeProgress) Write(data []byte) (int, error) {
+	for _, b := range data {
+		if b == '\r' || b == '\n' {
+			p.parse(p.line)
+			p.line = p.line[:0]
+		} else {
+			p.line = append(p.line, b)
+		}
+	}
+	return len(data), nil
+}
+
+func (p *transcodeProgress) parse(line []byte) {
+	if p.duration == 0 {
+		if match := transcodeDuration.FindSubmatch(line); match != nil {
+			p.duration = parseTranscodeTime(match)
+		}
+		return
+	}
+	match := transcodeTime.FindSubmatch(line)
+	if match == nil {
+		return
+	}
+	percent := int(parseTranscodeTime(match) * 100 / p.duration)
+	if percent > 99 {
+		percent = 99
+	}
+	if percent <= p.percent || time.Since(p.updated) < APPTranscodeProgressInterval*time.Second {
+		return
+	}
+	p.percent = percent
+	p.updated = time.Now()
+	if err := store.DB.Model(&store.Asset{}).Where("id = ?", p.id).Update("transform_progress", percent).Error; err != nil {
+		ErrMsg(err)
+	}
+}
+
+func parseTranscodeTime(match [][]byte) float64 {
+	hours, _ := strconv.ParseFloat(string(match[1]), 64)
+	minutes, _ := strconv.ParseFloat(string(match[2]), 64)
+	seconds, _ := strconv.ParseFloat(string(bytes.TrimSpace(match[3])), 64)
+	return hours*3600 + minutes*60 + seconds
+}
@@ -0,0 +1,107 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// getWorkerQueues returns queues a worker leases from, all queues when none are set
+func getWorkerQueues(worker *store.TranscodeWorker) []string {
+	if worker.Queues == "" {
+		return []string{APPQueueVideo, APPQueueAudio, APPQueuePhoto, APPQueueDefault}
+	}
+	queues := []string{}
+	for _, queue := range strings.Split(worker.Queues, ",") {
+		if queue == APPWorkerDefaultQueue {
+			queues = append(queues, APPQueueDefault)
+		} else {
+			queues = append(queues, queue)
+		}
+	}
+	return queues
+}
+
+// expireTranscodeLeases requeues leased assets of queue whose worker stopped reporting
+func expireTranscodeLeases(queue string) {
+
+	var assets []store.Asset
+	query := store.DB.Preload("Account").Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Preload("Channel.ChannelSlot").Preload("Topic.TopicSlot").
+		Where("assets.status = ? AND assets.transform_lease != ? AND assets.transform_expires < ?", APPAssetProcessing, "", time.Now().Unix())
+	if err := whereTranscodeQueue(query, queue).Find(&assets).Error; err != nil {
+		ErrMsg(err)
+		return
+	}
+	for i := range assets {
+		requeueTranscodeAsset(&assets[i], "transform lease expired")
+	}
+}
+
+// leaseTranscodeAsset claims next waiting asset from queues of worker
+func leaseTranscodeAsset(worker *store.TranscodeWorker) (*store.Asset, error) {
+	for _, queue := range getWorkerQueues(worker) {
+		expireTranscodeLeases(queue)
+		for {
+			id, err := nextTranscodeAsset(queue, transcodeScopeFile, time.Now().Unix()-APPTranscodeAgeLimit)
+			if err != nil {
+				return nil, err
+			}
+			if id == 0 {
+				break
+			}
+
+			asset := &store.Asset{}
+			if err := store.DB.Where("id = ?", id).First(asset).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					continue
+				}
+				return nil, err
+			}
+			claimed, err := setAssetProcessing(asset, worker.WorkerID, uuid.New().String(), time.Now().Unix()+APPTranscodeLeaseTimeout)
+			if err != nil {
+				return nil, err
+			}
+			if claimed {
+				return asset, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// getLeasedAsset retrieves asset currently leased to worker
+func getLeasedAsset(worker *store.TranscodeWorker, leaseID string) (*store.Asset, int, error) {
+
+	asset := &store.Asset{}
+	if err := store.DB.Preload("Account").Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Preload("Channel.ChannelSlot").Preload("Topic.TopicSlot").
+		Where("transform_lease = ? AND transform_worker = ? AND status = ?", leaseID, worker.WorkerID, APPAssetProcessing).First(asset).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusNotFound, errors.New("lease not found")
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	return asset, http.StatusOK, nil
+}
+
+// endTranscodeLease clears lease and records outcome of remote transform
+func endTranscodeLease(asset *store.Asset, status string, crc uint32, size int64) error {
+	if err := store.DB.Model(&store.Asset{}).Where("id = ?", asset.ID).Updates(map[string]interface{}{
+		"transform_lease":   "",
+		"transform_expires": 0,
+	}).Error; err != nil {
+		return err
+	}
+	asset.TransformLease = ""
+	asset.TransformExpires = 0
+	if status == APPAssetReady {
+		asset.TransformProgress = 100
+		path := getStrConfigValue(CNFAssetPath, APPDefaultPath) + "/" + asset.Account.GUID + "/" + asset.AssetID
+		setAssetInfo(asset, path)
+		setAssetPreview(asset, path)
+	}
+	return updateAsset(asset, status, crc, size)
+}
@@ -0,0 +1,229 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"errors"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+type transcodeQueue struct {
+	name   string
+	config string
+	empty  int64
+	wake   chan bool
+}
+
+var transcodeQueues = []*transcodeQueue{
+	{name: APPQueueVideo, config: CNFVideoWorkers, empty: APPTranscodeVideoWorkers, wake: make(chan bool, 1)},
+	{name: APPQueueAudio, config: CNFAudioWorkers, empty: APPTranscodeAudioWorkers, wake: make(chan bool, 1)},
+	{name: APPQueuePhoto, config: CNFPhotoWorkers, empty: APPTranscodePhotoWorkers, wake: make(chan bool, 1)},
+	{name: APPQueueDefault, config: CNFDefaultWorkers, empty: APPTranscodeDefaultWorkers, wake: make(chan bool, 1)},
+}
+
+// scopes of transforms a claim may select
+const (
+	transcodeScopeAll = iota
+	transcodeScopeStream
+	transcodeScopeFile
+)
+
+var transcodeStart sync.Once
+
+// StartTranscode recovers interrupted transforms and starts worker pool
+func StartTranscode() {
+	transcodeStart.Do(func() {
+		recoverTranscode()
+		for _, queue := range transcodeQueues {
+			go dispatchTranscode(queue)
+		}
+	})
+}
+
+// transcode wakes worker pool to process newly waiting assets
+func transcode() {
+	StartTranscode()
+	for _, queue := range transcodeQueues {
+		select {
+		case queue.wake <- true:
+		default:
+		}
+	}
+}
+
+// getTranscodeWorkers returns configured concurrency of queue
+func getTranscodeWorkers(queue *transcodeQueue) int {
+	workers := getNumConfigValue(queue.config, queue.empty)
+	if workers <= 0 {
+		return int(queue.empty)
+	}
+	return int(workers)
+}
+
+// recoverTranscode requeues local jobs interrupted by restart, remote leases run until they expire
+func recoverTranscode() {
+
+	var assets []store.Asset
+	if err := store.DB.Preload("Account").Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Preload("Channel.ChannelSlot").Preload("Topic.TopicSlot").Where("status = ? AND transform_lease = ?", APPAssetProcessing, "").Find(&assets).Error; err != nil {
+		ErrMsg(err)
+		return
+	}
+	for i := range assets {
+		requeueTranscodeAsset(&assets[i], "transform interrupted")
+	}
+}
+
+// requeueTranscodeAsset returns processing asset to waiting, failing those that keep interrupting
+func requeueTranscodeAsset(asset *store.Asset, reason string) {
+	if err := store.DB.Model(&store.Asset{}).Where("id = ?", asset.ID).Updates(map[string]interface{}{
+		"transform_lease":   "",
+		"transform_expires": 0,
+	}).Error; err != nil {
+		ErrMsg(err)
+		return
+	}
+	if asset.TransformAttempts >= APPTranscodeMaxAttempts {
+		asset.TransformError = reason
+		if err := updateAsset(asset, APPAssetError, 0, 0); err != nil {
+			ErrMsg(err)
+		}
+	} else if err := store.DB.Model(&store.Asset{}).Where("id = ? AND status = ?", asset.ID, APPAssetProcessing).Update("status", APPAssetWaiting).Error; err != nil {
+		ErrMsg(err)
+	}
+}
+
+// setAssetProcessing claims waiting asset for local job or remote lease, false if already claimed
+func setAssetProcessing(asset *store.Asset, worker string, lease string, expires int64) (bool, error) {
+
+	// conditional update so an asset is only ever claimed once
+	res := store.DB.Model(&store.Asset{}).Where("id = ? AND status = ?", asset.ID, APPAssetWaiting).Updates(map[string]interface{}{
+		"status":             APPAssetProcessing,
+		"transform_started":  time.Now().Unix(),
+		"transform_progress": 0,
+		"transform_error":    "",
+		"transform_attempts": gorm.Expr("transform_attempts + 1"),
+		"transform_worker":   worker,
+		"transform_lease":    lease,
+		"transform_expires":  expires,
+	})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+	asset.Status = APPAssetProcessing
+	asset.TransformStarted = time.Now().Unix()
+	asset.TransformProgress = 0
+	asset.TransformError = ""
+	asset.TransformAttempts++
+	asset.TransformWorker = worker
+	asset.TransformLease = lease
+	asset.TransformExpires = expires
+	return true, nil
+}
+
+// dispatchTranscode keeps up to configured number of jobs of queue running
+func dispatchTranscode(queue *transcodeQueue) {
+
+	done := make(chan bool)
+	ticker := time.NewTicker(APPTranscodePollInterval * time.Second)
+	defer ticker.Stop()
+
+	running := 0
+	for {
+		// stream output is a directory remote workers cannot upload, so it always runs locally
+		scope := transcodeScopeAll
+		if getBoolConfigValue(CNFRemoteTranscode, false) {
+			scope = transcodeScopeStream
+		}
+		for running < getTranscodeWorkers(queue) {
+			asset, job, err := claimTranscodeAsset(queue.name, scope)
+			if err != nil {
+				ErrMsg(err)
+				break
+			}
+			if asset == nil {
+				break
+			}
+			running++
+			go func() {
+				transcodeAsset(asset, job)
+				done <- true
+			}()
+		}
+
+		select {
+		case <-queue.wake:
+		case <-done:
+			running--
+		case <-ticker.C:
+			expireTranscodeLeases(queue.name)
+		}
+	}
+}
+
+// claimTranscodeAsset marks next waiting asset of queue as processing and registers its job
+func claimTranscodeAsset(queue string, scope int) (*store.Asset, *transcodeJob, error) {
+	for {
+		id, err := nextTranscodeAsset(queue, scope, time.Now().Unix()-APPTranscodeAgeLimit)
+		if err != nil || id == 0 {
+			return nil, nil, err
+		}
+
+		asset := &store.Asset{}
+		if err := store.DB.Preload("Account").Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Preload("Channel.ChannelSlot").Preload("Topic.TopicSlot").Where("id = ?", id).First(asset).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, nil, err
+		}
+		if asset.Status != APPAssetWaiting {
+			continue
+		}
+
+		// job is cancellable as soon as asset shows as processing
+		job := startTranscodeJob(asset)
+		claimed, err := setAssetProcessing(asset, "", "", 0)
+		if err != nil || !claimed {
+			endTranscodeJob(job)
+			job.cancel()
+			if err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		return asset, job, nil
+	}
+}
+
+// nextTranscodeAsset selects waiting asset of scope with smallest source, assets waiting since aged go first
+func nextTranscodeAsset(queue string, scope int, aged int64) (uint, error) {
+
+	query := store.DB.Model(&store.Asset{}).Select("assets.id").
+		Joins("LEFT JOIN assets AS sources ON sources.asset_id = assets.transform_id AND sources.account_id = assets.account_id").
+		Where("assets.status = ?", APPAssetWaiting)
+	query = whereTranscodeQueue(query, queue)
+	if scope == transcodeScopeStream {
+		query = query.Where("assets.transform = ?", APPStreamTransform)
+	} else if scope == transcodeScopeFile {
+		query = query.Where("assets.transform != ?", APPStreamTransform)
+	}
+
+	var ids []uint
+	if err := query.Order(gorm.Expr("CASE WHEN assets.created < ? THEN 0 ELSE 1 END", aged)).
+		Order("COALESCE(sources.size, 0) asc").Order("assets.created asc").Order("assets.id asc").
+		Limit(1).Pluck("assets.id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return ids[0], nil
+}
+
+// whereTranscodeQueue restricts asset query to queue, default queue holds all others
+func whereTranscodeQueue(query *gorm.DB, queue string) *gorm.DB {
+	if queue == APPQueueDefault {
+		return query.Where("assets.transform_queue NOT IN ?", []string{APPQueueVideo, APPQueueAudio, APPQueuePhoto})
+	}
+	return query.Where("assets.transform_queue = ?", queue)
+}
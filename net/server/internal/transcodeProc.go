@@ -0,0 +1,16 @@
+//go:build !windows
+
+package databag
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setTranscodeGroup runs script in its own process group so cancel stops its children
+func setTranscodeGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
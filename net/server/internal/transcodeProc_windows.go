@@ -0,0 +1,9 @@
+package databag
+
+import (
+	"os/exec"
+)
+
+// setTranscodeGroup leaves default cancel which only stops the script process
+func setTranscodeGroup(cmd *exec.Cmd) {
+}
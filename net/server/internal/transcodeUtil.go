@@ -2,148 +2,158 @@ package databag
 
 import (
 	"bytes"
+	"context"
 	"databag/internal/store"
 	"errors"
+	"fmt"
 	"gorm.io/gorm"
 	"hash/crc32"
 	"io"
 	"os"
 	"os/exec"
 	"regexp"
-	"sync"
+	"time"
 )
 
-var audioSync sync.Mutex
-var videoSync sync.Mutex
-var photoSync sync.Mutex
-var defaultSync sync.Mutex
+func transcodeAsset(asset *store.Asset, job *transcodeJob) {
+	defer job.cancel()
 
-func transcode() {
-	go transcodeAudio()
-	go transcodeVideo()
-	go transcodePhoto()
-	go transcodeDefault()
-}
+	// prepare script path
+	data := getStrConfigValue(CNFAssetPath, APPDefaultPath)
+	script := getStrConfigValue(CNFScriptPath, ".")
+	re := regexp.MustCompile("^[a-zA-Z0-9_]*$")
 
-func transcodeVideo() {
-	videoSync.Lock()
-	defer videoSync.Unlock()
-	for {
-		var asset store.Asset
-		if err := store.DB.Order("created asc").Preload("Account").Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Preload("Channel.ChannelSlot").Preload("Topic.TopicSlot").Where("transform_queue = ? AND status = ?", APPQueueVideo, APPAssetWaiting).First(&asset).Error; err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				ErrMsg(err)
-			}
-			return
+	if !re.MatchString(asset.Transform) {
+		ErrMsg(errors.New("invalid transform"))
+		endTranscodeJob(job)
+		asset.TransformError = "invalid transform"
+		if err := updateAsset(asset, APPAssetError, 0, 0); err != nil {
+			ErrMsg(err)
 		}
-		transcodeAsset(&asset)
+		return
 	}
-}
 
-func transcodeAudio() {
-	audioSync.Lock()
-	defer audioSync.Unlock()
-	for {
-		var asset store.Asset
-		if err := store.DB.Order("created asc").Preload("Account").Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Preload("Channel.ChannelSlot").Preload("Topic.TopicSlot").Where("transform_queue = ? AND status = ?", APPQueueAudio, APPAssetWaiting).First(&asset).Error; err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				ErrMsg(err)
-			}
-			return
-		}
-		transcodeAsset(&asset)
-	}
-}
+	input := data + "/" + asset.Account.GUID + "/" + asset.TransformID
+	output := data + "/" + asset.Account.GUID + "/" + asset.AssetID
 
-func transcodePhoto() {
-	photoSync.Lock()
-	defer photoSync.Unlock()
-	for {
-		var asset store.Asset
-		if err := store.DB.Order("created asc").Preload("Account").Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Preload("Channel.ChannelSlot").Preload("Topic.TopicSlot").Where("transform_queue = ? AND status = ?", APPQueuePhoto, APPAssetWaiting).First(&asset).Error; err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				ErrMsg(err)
-			}
-			return
-		}
-		transcodeAsset(&asset)
-	}
-}
+	// stop if asset is removed while processing
+	go watchTranscodeJob(job)
 
-func transcodeDefault() {
-	defaultSync.Lock()
-	defer defaultSync.Unlock()
-	for {
-		var asset store.Asset
-		if err := store.DB.Order("created asc").Preload("Account").Preload("Channel.Members.Card").Preload("Channel.Groups.Cards").Preload("Channel.ChannelSlot").Preload("Topic.TopicSlot").Where("transform_queue != ? AND transform_queue != ? AND transform_queue != ? AND status = ?", APPQueueVideo, APPQueueAudio, APPQueuePhoto, APPAssetWaiting).First(&asset).Error; err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				ErrMsg(err)
-			}
+	err := runTransform(job.ctx, asset, script, input, output)
+	cancelled := endTranscodeJob(job)
+
+	if err != nil {
+		if cancelled {
+			err = errors.New("transform cancelled")
+		} else if errors.Is(job.ctx.Err(), context.DeadlineExceeded) {
+			err = errors.New("transform timed out")
+		}
+		ErrMsg(err)
+		if cancelled && !hasAsset(asset.ID) {
 			return
 		}
-		transcodeAsset(&asset)
-	}
-}
-
-func transcodeAsset(asset *store.Asset) {
-
-	// prepare script path
-	data := getStrConfigValue(CNFAssetPath, APPDefaultPath)
-	script := getStrConfigValue(CNFScriptPath, ".")
-	re := regexp.MustCompile("^[a-zA-Z0-9_]*$")
-
-	if !re.MatchString(asset.Transform) {
-		ErrMsg(errors.New("invalid transform"))
+		asset.TransformError = err.Error()
 		if err := updateAsset(asset, APPAssetError, 0, 0); err != nil {
 			ErrMsg(err)
 		}
 	} else {
-		input := data + "/" + asset.Account.GUID + "/" + asset.TransformID
-		output := data + "/" + asset.Account.GUID + "/" + asset.AssetID
-		cmd := exec.Command(script+"/transform_"+asset.Transform+".sh", input, output, asset.TransformParams)
-		var stdout bytes.Buffer
-		cmd.Stdout = &stdout
-		var stderr bytes.Buffer
-		cmd.Stderr = &stderr
-
-		if err := cmd.Run(); err != nil {
-			LogMsg(stdout.String())
-			LogMsg(stderr.String())
+		crc, size, err := scanAsset(output)
+		if err == nil && asset.Transform == APPStreamTransform {
+			var segments int64
+			segments, err = getStreamSize(output + APPStreamSuffix)
+			size += segments
+		}
+
+		if err != nil {
 			ErrMsg(err)
+			asset.TransformError = err.Error()
 			if err := updateAsset(asset, APPAssetError, 0, 0); err != nil {
 				ErrMsg(err)
 			}
 		} else {
-			if stdout.Len() > 0 {
-				LogMsg(stdout.String())
-			}
-			if stderr.Len() > 0 {
-				LogMsg(stderr.String())
-			}
-			crc, size, err := scanAsset(output)
-
-			if err != nil {
-				ErrMsg(err)
-				if err := updateAsset(asset, APPAssetError, 0, 0); err != nil {
-					ErrMsg(err)
-				}
-			} else if err := updateAsset(asset, APPAssetReady, crc, size); err != nil {
+			asset.TransformProgress = 100
+			setAssetInfo(asset, output)
+			setAssetPreview(asset, output)
+			if err := updateAsset(asset, APPAssetReady, crc, size); err != nil {
 				ErrMsg(err)
 			}
 		}
 	}
 }
 
+// runTransform applies transform, built-in transforms defer to script for unsupported input or params
+func runTransform(ctx context.Context, asset *store.Asset, script string, input string, output string) (err error) {
+
+	// a failing transform must not take down the worker pool
+	defer func() {
+		if res := recover(); res != nil {
+			err = fmt.Errorf("transform panic: %v", res)
+		}
+	}()
+
+	err = errNativeUnsupported
+	if transform, ok := getNativeTransform(asset.Transform); ok {
+		err = transform(ctx, input, output, asset.TransformParams)
+	}
+	if errors.Is(err, errNativeUnsupported) {
+		err = runTransformScript(ctx, asset.ID, script+"/transform_"+asset.Transform+".sh", input, output, asset.TransformParams)
+	}
+	return
+}
+
+func runTransformScript(ctx context.Context, id uint, path string, input string, output string, params string) error {
+
+	cmd := exec.CommandContext(ctx, path, input, output, params)
+	setTranscodeGroup(cmd)
+	cmd.WaitDelay = APPTranscodeCheckInterval * time.Second
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = io.MultiWriter(&stderr, &transcodeProgress{id: id})
+
+	if err := cmd.Run(); err != nil {
+		LogMsg(stdout.String())
+		LogMsg(stderr.String())
+		return err
+	}
+	if stdout.Len() > 0 {
+		LogMsg(stdout.String())
+	}
+	if stderr.Len() > 0 {
+		LogMsg(stderr.String())
+	}
+	return nil
+}
+
+func hasAsset(id uint) bool {
+	var count int64
+	if err := store.DB.Model(&store.Asset{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		ErrMsg(err)
+		return false
+	}
+	return count > 0
+}
+
 func updateAsset(asset *store.Asset, status string, crc uint32, size int64) (err error) {
 
 	topic := store.Topic{}
 	err = store.DB.Transaction(func(tx *gorm.DB) error {
+		var stored store.Asset
+		if res := tx.Select("size").Where("id = ?", asset.ID).First(&stored).Error; res != nil {
+			return res
+		}
 		asset.Crc = crc
 		asset.Size = size
 		asset.Status = status
 		if res := tx.Model(store.Asset{}).Where("id = ?", asset.ID).Updates(asset).Error; res != nil {
 			return res
 		}
+		if res := tx.Model(store.Asset{}).Where("id = ?", asset.ID).Update("size", size).Error; res != nil {
+			return res
+		}
+		if res := adjustStorage(tx, asset.AccountID, size-stored.Size); res != nil {
+			return res
+		}
 		if asset.Topic == nil {
 			return errors.New("asset not found")
 		}
@@ -227,3 +237,20 @@ func scanAsset(path string) (crc uint32, size int64, err error) {
 	size = info.Size()
 	return
 }
+
+// getStreamSize totals segment files of stream so they count against storage
+func getStreamSize(dir string) (int64, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil {
+			return 0, err
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
@@ -0,0 +1,104 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAccountRetention(t *testing.T) {
+	var params *TestAPIParams
+
+	// setup testing accounts
+	aGUID, aToken, err := addTestAccount("retentionA")
+	assert.NoError(t, err)
+	bGUID, bToken, err := addTestAccount("retentionB")
+	assert.NoError(t, err)
+
+	// default retention follows node
+	retention := &AccountRetention{}
+	params = &TestAPIParams{query: "/account/retention", tokenType: APPTokenAgent, token: aToken}
+	assert.NoError(t, TestAPIRequest(GetAccountRetention, params, &TestAPIResponse{data: retention}))
+	assert.Equal(t, int64(0), retention.TopicDays)
+	assert.Equal(t, int64(APPRetentionMinDays), retention.MinDays)
+	assert.Equal(t, int64(APPRetentionMaxDays), retention.MaxDays)
+
+	// retention must be within node bounds
+	params = &TestAPIParams{restType: "PUT", query: "/account/retention", tokenType: APPTokenAgent, token: aToken,
+		body: &AccountRetention{TopicDays: APPRetentionMaxDays + 1}}
+	assert.Error(t, TestAPIRequest(SetAccountRetention, params, nil))
+	params = &TestAPIParams{restType: "PUT", query: "/account/retention", tokenType: APPTokenAgent, token: aToken,
+		body: &AccountRetention{TopicDays: 10, AssetDays: 5}}
+	assert.NoError(t, TestAPIRequest(SetAccountRetention, params, nil))
+
+	// aged topics and assets in each account
+	now := time.Now().Unix()
+	addTopic := func(token string, guid string, age int64) *store.Topic {
+		channelID, err := addTestChannel(token)
+		assert.NoError(t, err)
+		params, err := addTestTopic(token, channelID, "topicdata")
+		assert.NoError(t, err)
+		var stored store.Topic
+		assert.NoError(t, store.DB.Joins("TopicSlot").Where("TopicSlot.topic_slot_id = ?", params["topicID"]).First(&stored).Error)
+		assert.NoError(t, store.DB.Model(&stored).UpdateColumn("created", now-age*86400).Error)
+		return &stored
+	}
+	expired := addTopic(aToken, aGUID, 11)
+	kept := addTopic(aToken, aGUID, 2)
+	asset := &store.Asset{AssetID: "retentionasset", AccountID: kept.AccountID, ChannelID: kept.ChannelID, TopicID: kept.ID, Status: APPAssetReady, Size: 64}
+	assert.NoError(t, store.DB.Create(asset).Error)
+	assert.NoError(t, store.DB.Model(asset).UpdateColumn("created", now-6*86400).Error)
+	other := addTopic(bToken, bGUID, 11)
+
+	// scheduler applies account retention without node wide cleanup
+	response, err := performCleanup(0, 0)
+	assert.NoError(t, err)
+	var result *CleanupAccount
+	for i, account := range response.Accounts {
+		assert.NotEqual(t, other.AccountID, account.AccountID)
+		if account.AccountID == expired.AccountID {
+			result = &response.Accounts[i]
+		}
+	}
+	assert.NotNil(t, result)
+	assert.Equal(t, int64(10), result.TopicDays)
+	assert.Equal(t, int64(5), result.AssetDays)
+	assert.Equal(t, int64(1), result.DeletedTopics)
+	assert.Equal(t, int64(1), result.DeletedAssets)
+	assert.Equal(t, int64(64), result.FreedBytes)
+
+	var count int64
+	assert.NoError(t, store.DB.Model(&store.Topic{}).Where("id IN ?", []uint{expired.ID, kept.ID, other.ID}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	// topic losing asset resyncs
+	var revised store.Topic
+	assert.NoError(t, store.DB.First(&revised, kept.ID).Error)
+	assert.Greater(t, revised.DetailRevision, kept.DetailRevision)
+
+	// removed topic leaves slot for members to sync removal
+	var slot store.TopicSlot
+	assert.NoError(t, store.DB.First(&slot, expired.TopicSlotID).Error)
+	var channel store.Channel
+	assert.NoError(t, store.DB.First(&channel, expired.ChannelID).Error)
+	assert.Equal(t, slot.Revision, channel.TopicRevision)
+	var account store.Account
+	assert.NoError(t, store.DB.First(&account, expired.AccountID).Error)
+	assert.GreaterOrEqual(t, account.ChannelRevision, slot.Revision)
+
+	// manual cleanup reports accounts as well
+	r, w, _ := NewRequest("PUT", "/admin/access?token=pass", nil)
+	SetAdminAccess(w, r)
+	var session string
+	assert.NoError(t, ReadResponse(w, &session))
+	accountID := other.AccountID
+	manual := &CleanupResponse{}
+	params = &TestAPIParams{restType: "POST", query: "/admin/cleanup?token=" + session,
+		body: &CleanupRequest{RetentionDays: 10, AccountID: &accountID}}
+	assert.NoError(t, TestAPIRequest(CleanupData, params, &TestAPIResponse{data: manual}))
+	assert.Equal(t, 1, len(manual.Accounts))
+	assert.Equal(t, other.AccountID, manual.Accounts[0].AccountID)
+	assert.Equal(t, int64(1), manual.Accounts[0].DeletedTopics)
+	assert.Equal(t, int64(1), manual.DeletedTopics)
+}
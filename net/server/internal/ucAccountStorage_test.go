@@ -0,0 +1,118 @@
+package databag
+
+import (
+	"bytes"
+	"databag/internal/store"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"strconv"
+	"testing"
+)
+
+func TestAccountStorage(t *testing.T) {
+
+	var encoded bytes.Buffer
+	assert.NoError(t, png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 8, 8))))
+	graphic := encoded.Bytes()
+	binary := []byte{0x00, 0x9f, 0x92, 0x96, 0x00, 0x01, 0xfe, 0xff}
+
+	_, token, err := addTestAccount("accountstorage")
+	assert.NoError(t, err)
+	account := &store.Account{}
+	assert.NoError(t, store.DB.Where("username = ?", "accountstorage").First(account).Error)
+	getStorage := func() *AccountStorage {
+		storage := &AccountStorage{}
+		assert.NoError(t, APITestMsg(GetAccountStorage, "GET", "/account/storage",
+			nil, nil, APPTokenAgent, token, storage, nil))
+		return storage
+	}
+	addTopic := func() map[string]string {
+		channelID, err := addTestChannel(token)
+		assert.NoError(t, err)
+		params, err := addTestTopic(token, channelID, "storage")
+		assert.NoError(t, err)
+		return params
+	}
+	upload := func(params map[string]string, data []byte) (string, error) {
+		assets := []Asset{}
+		if err := APITestUpload(AddChannelTopicAsset, "POST", "/content/channels/{channelID}/topics/{topicID}/assets",
+			&params, data, APPTokenAgent, token, &assets, nil); err != nil {
+			return "", err
+		}
+		return assets[0].AssetID, nil
+	}
+
+	// uploads counted by channel and media type
+	first := addTopic()
+	second := addTopic()
+	_, err = upload(first, graphic)
+	assert.NoError(t, err)
+	assetID, err := upload(first, binary)
+	assert.NoError(t, err)
+	_, err = upload(second, binary)
+	assert.NoError(t, err)
+	total := int64(len(graphic) + 2*len(binary))
+	storage := getStorage()
+	assert.Equal(t, total, storage.Used)
+	assert.Equal(t, getNumConfigValue(CNFStorage, 0), storage.Quota)
+	assert.Equal(t, []ChannelStorage{
+		{ChannelID: first["channelID"], Size: int64(len(graphic) + len(binary))},
+		{ChannelID: second["channelID"], Size: int64(len(binary))},
+	}, storage.Channels)
+	types := map[string]int64{}
+	for _, entry := range storage.Types {
+		types[entry.Type] = entry.Size
+	}
+	assert.Equal(t, map[string]int64{"image": int64(len(graphic)), "application": int64(2 * len(binary))}, types)
+
+	status := &AccountStatus{}
+	assert.NoError(t, APITestMsg(GetAccountStatus, "GET", "/account/status",
+		nil, nil, APPTokenAgent, token, status, nil))
+	assert.Equal(t, total, status.StorageUsed)
+
+	// admin override rejects uploads once used
+	r, w, _ := NewRequest("PUT", "/admin/access?token=pass", nil)
+	SetAdminAccess(w, r)
+	var session string
+	assert.NoError(t, ReadResponse(w, &session))
+	accountID := strconv.FormatUint(uint64(account.ID), 10)
+	setQuota := func(quota int64) error {
+		return APITestMsg(SetNodeAccountStorage, "PUT", "/admin/accounts/{accountID}/storage?token="+session,
+			&map[string]string{"accountID": accountID}, quota, "", "", nil, nil)
+	}
+	assert.Error(t, setQuota(-2))
+	assert.NoError(t, setQuota(total))
+	assert.Equal(t, total, getStorage().Quota)
+	_, err = upload(second, binary)
+	assert.Error(t, err)
+	profiles := []AccountProfile{}
+	assert.NoError(t, APITestMsg(GetNodeAccounts, "GET", "/admin/accounts?token="+session,
+		nil, nil, "", "", &profiles, nil))
+	for _, profile := range profiles {
+		if profile.Handle == "accountstorage" {
+			assert.Equal(t, total, profile.StorageUsed)
+			assert.Equal(t, total, profile.StorageQuota)
+		}
+	}
+
+	// deleting asset releases storage
+	first["assetID"] = assetID
+	assert.NoError(t, APITestMsg(RemoveChannelTopicAsset, "DELETE", "/content/channels/{channelID}/topics/{topicID}/assets/{assetID}",
+		&first, nil, APPTokenAgent, token, nil, nil))
+	total -= int64(len(binary))
+	assert.Equal(t, total, getStorage().Used)
+	_, err = upload(second, binary)
+	assert.NoError(t, err)
+	total += int64(len(binary))
+
+	// unlimited override and recount from records
+	assert.NoError(t, setQuota(-1))
+	_, err = upload(second, binary)
+	assert.NoError(t, err)
+	total += int64(len(binary))
+	assert.NoError(t, store.DB.Model(account).Update("storage_used", 0).Error)
+	recountStorage()
+	assert.Equal(t, total, getStorage().Used)
+	assert.NoError(t, setQuota(0))
+}
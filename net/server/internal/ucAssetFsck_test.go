@@ -0,0 +1,141 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAssetFsck(t *testing.T) {
+
+	guid, token, params, err := addTestChannelTopic("assetfsck", "fsck")
+	assert.NoError(t, err)
+	account := &store.Account{}
+	assert.NoError(t, store.DB.Where("guid = ?", guid).First(account).Error)
+	upload := func(data string) string {
+		assets := []Asset{}
+		assert.NoError(t, APITestUpload(AddChannelTopicAsset, "POST", "/content/channels/{channelID}/topics/{topicID}/assets",
+			&params, []byte(data), APPTokenAgent, token, &assets, nil))
+		return assets[0].AssetID
+	}
+	path := getStrConfigValue(CNFAssetPath, APPDefaultPath)
+	aged := time.Now().Add(-2 * APPFsckGracePeriod * time.Second)
+
+	// intact, corrupted and missing assets with stray files
+	intact := upload("intact asset")
+	corrupted := upload("corrupted asset")
+	missing := upload("missing asset")
+	assert.NoError(t, os.WriteFile(path+"/"+guid+"/"+corrupted, []byte("c0rrupted asset"), 0600))
+	assert.NoError(t, os.Remove(path+"/"+guid+"/"+missing))
+	assert.NoError(t, os.WriteFile(path+"/"+guid+"/stray", []byte("stray"), 0600))
+	assert.NoError(t, os.Chtimes(path+"/"+guid+"/stray", aged, aged))
+	assert.NoError(t, os.WriteFile(path+"/"+guid+"/recent", []byte("recent"), 0600))
+	removed := strings.Repeat("0f", 32)
+	for _, name := range []string{removed, "removedaccount"} {
+		assert.NoError(t, os.MkdirAll(path+"/"+name, 0700))
+		assert.NoError(t, os.Chtimes(path+"/"+name, aged, aged))
+		defer os.RemoveAll(path + "/" + name)
+	}
+	assert.NoError(t, store.DB.First(account, account.ID).Error)
+	used := account.StorageUsed
+
+	r, w, _ := NewRequest("PUT", "/admin/access?token=pass", nil)
+	SetAdminAccess(w, r)
+	var session string
+	assert.NoError(t, ReadResponse(w, &session))
+	fsck := func(request *FsckRequest) *FsckStatus {
+		assert.NoError(t, APITestMsg(FsckAssets, "POST", "/admin/cleanup/fsck?token="+session,
+			nil, request, "", "", nil, nil))
+		for getFsckStatus().Running {
+			time.Sleep(10 * time.Millisecond)
+		}
+		status := &CleanupStatus{}
+		assert.NoError(t, APITestMsg(GetCleanupStatus, "GET", "/admin/cleanup/status?token="+session,
+			nil, nil, "", "", status, nil))
+		assert.False(t, status.Fsck.Running)
+		assert.Empty(t, status.Fsck.Error)
+		return status.Fsck
+	}
+	findings := func(status *FsckStatus) map[string]FsckFinding {
+		found := make(map[string]FsckFinding)
+		for _, finding := range status.Findings {
+			if finding.GUID == guid || finding.GUID == removed || finding.GUID == "removedaccount" {
+				found[finding.Kind+":"+finding.AssetID+finding.File+finding.GUID] = finding
+			}
+		}
+		return found
+	}
+
+	// report only leaves everything in place
+	status := fsck(&FsckRequest{})
+	assert.False(t, status.Repair)
+	found := findings(status)
+	assert.Equal(t, 5, len(found))
+	assert.Equal(t, "checksum mismatch", found[APPFsckChecksum+":"+corrupted+guid].Detail)
+	assert.Equal(t, "asset file missing", found[APPFsckMissing+":"+missing+guid].Detail)
+	assert.Contains(t, found, APPFsckOrphan+":stray"+guid)
+	assert.Contains(t, found, APPFsckAccount+":"+removed)
+	assert.Equal(t, "not an account directory", found[APPFsckAccount+":removedaccount"].Detail)
+	for _, finding := range found {
+		assert.False(t, finding.Repaired)
+	}
+	_, err = os.Stat(path + "/" + guid + "/stray")
+	assert.NoError(t, err)
+
+	// assets checked without cleanup lock, second run while first is active is refused
+	garbageSync.Lock()
+	assert.True(t, startAssetFsck(false, &account.ID))
+	assert.Eventually(t, func() bool {
+		return getFsckStatus().Checked == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, getFsckStatus().Running)
+	assert.Error(t, APITestMsg(FsckAssets, "POST", "/admin/cleanup/fsck?token="+session,
+		nil, &FsckRequest{}, "", "", nil, nil))
+	garbageSync.Unlock()
+	for getFsckStatus().Running {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// repair limited to account
+	status = fsck(&FsckRequest{Repair: true, AccountID: &account.ID})
+	found = findings(status)
+	assert.Equal(t, 3, len(found))
+	for _, finding := range found {
+		assert.True(t, finding.Repaired)
+	}
+	_, err = os.Stat(path + "/" + guid + "/stray")
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(path + "/" + guid + "/recent")
+	assert.NoError(t, err)
+	_, err = os.Stat(path + "/" + removed)
+	assert.NoError(t, err)
+
+	assets := []Asset{}
+	assert.NoError(t, APITestMsg(GetChannelTopicAssets, "GET", "/content/channels/{channelID}/topics/{topicID}/assets",
+		&params, nil, APPTokenAgent, token, &assets, nil))
+	states := make(map[string]Asset)
+	for _, asset := range assets {
+		states[asset.AssetID] = asset
+	}
+	assert.Equal(t, APPAssetReady, states[intact].Status)
+	assert.Equal(t, APPAssetError, states[corrupted].Status)
+	assert.Equal(t, "checksum mismatch", states[corrupted].Error)
+	assert.Equal(t, APPAssetError, states[missing].Status)
+	assert.NoError(t, store.DB.First(account, account.ID).Error)
+	assert.Equal(t, used-int64(len("corrupted asset")+len("missing asset")), account.StorageUsed)
+
+	// repaired account is consistent, removed account directory cleared and unknown directory kept
+	status = fsck(&FsckRequest{Repair: true})
+	found = findings(status)
+	assert.Equal(t, 2, len(found))
+	assert.True(t, found[APPFsckAccount+":"+removed].Repaired)
+	assert.False(t, found[APPFsckAccount+":removedaccount"].Repaired)
+	_, err = os.Stat(path + "/" + removed)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(path + "/removedaccount")
+	assert.NoError(t, err)
+	assert.NotZero(t, getNumConfigValue(CNFFsckLastRun, 0))
+}
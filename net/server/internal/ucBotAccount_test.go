@@ -0,0 +1,151 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBotAccount(t *testing.T) {
+	var params *TestAPIParams
+	var response *TestAPIResponse
+
+	// setup testing accounts
+	_, humanToken, err := addTestAccount("botaccountA")
+	assert.NoError(t, err)
+	botGUID, botToken, err := addTestAccount("botaccountB")
+	assert.NoError(t, err)
+
+	// admin login
+	r, w, _ := NewRequest("PUT", "/admin/access?token=pass", nil)
+	SetAdminAccess(w, r)
+	var session string
+	assert.NoError(t, ReadResponse(w, &session))
+
+	// find account id of B
+	accounts := []AccountProfile{}
+	params = &TestAPIParams{query: "/admin/accounts?token=" + session}
+	response = &TestAPIResponse{data: &accounts}
+	assert.NoError(t, TestAPIRequest(GetNodeAccounts, params, response))
+	var accountID string
+	for _, account := range accounts {
+		if account.GUID == botGUID {
+			accountID = strconv.FormatUint(uint64(account.AccountID), 10)
+		}
+	}
+	assert.NotEmpty(t, accountID)
+
+	// keys only available to bot accounts
+	key := &ApiKey{}
+	params = &TestAPIParams{query: "/account/keys?name=ci", authorization: "botaccountB:pass"}
+	response = &TestAPIResponse{data: key}
+	assert.Error(t, TestAPIRequest(AddAccountKey, params, response))
+
+	// flag B as bot
+	flag := true
+	params = &TestAPIParams{query: "/admin/accounts/{accountID}/bot?token=" + session,
+		path: map[string]string{"accountID": accountID}, body: &flag}
+	assert.NoError(t, TestAPIRequest(SetNodeAccountBot, params, nil))
+
+	// generate api key, totp required like app login
+	assert.NoError(t, store.DB.Model(&store.Account{}).Where("guid = ?", botGUID).Updates(map[string]interface{}{
+		"mfa_enabled": true, "mfa_confirmed": true, "mfa_secret": "JBSWY3DPEHPK3PXP", "mfa_algorithm": APPMFASHA1}).Error)
+	params = &TestAPIParams{query: "/account/keys?name=ci", authorization: "botaccountB:pass"}
+	response = &TestAPIResponse{data: key}
+	assert.Error(t, TestAPIRequest(AddAccountKey, params, response))
+	code, err := totp.GenerateCodeCustom("JBSWY3DPEHPK3PXP", time.Now(), totp.ValidateOpts{Period: 30, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1})
+	assert.NoError(t, err)
+	params = &TestAPIParams{query: "/account/keys?name=ci&code=" + code, authorization: "botaccountB:pass"}
+	assert.NoError(t, TestAPIRequest(AddAccountKey, params, response))
+	assert.NotEmpty(t, key.Key)
+	assert.Equal(t, "ci", key.Name)
+
+	// key only stored hashed
+	var stored store.ApiKey
+	assert.NoError(t, store.DB.Where("api_key_id = ?", key.ID).First(&stored).Error)
+	assert.NotContains(t, key.Key, stored.Token)
+
+	// key limited to posting
+	keys := []ApiKey{}
+	params = &TestAPIParams{query: "/account/keys", tokenType: APPTokenAgent, token: key.Key}
+	response = &TestAPIResponse{data: &keys}
+	assert.Error(t, TestAPIRequest(GetAccountKeys, params, response))
+	params = &TestAPIParams{query: "/account/keys", tokenType: APPTokenAgent, token: botToken}
+	assert.NoError(t, TestAPIRequest(GetAccountKeys, params, response))
+	assert.Equal(t, 1, len(keys))
+	assert.Empty(t, keys[0].Key)
+	botChannelID, err := addTestChannel(botToken)
+	assert.NoError(t, err)
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": botChannelID}, &Subject{Data: "nightly", DataType: "topicdatatype"}, APPTokenAgent, key.Key, nil, nil))
+	assert.Error(t, APITestMsg(GetChannelTopics, "GET", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": botChannelID}, nil, APPTokenAgent, key.Key, nil, nil))
+	assert.NoError(t, TestAPIRequest(GetAccountKeys, params, response))
+	assert.NotZero(t, keys[0].LastUsed)
+
+	// bot excluded from listing even when searchable
+	searchable := true
+	params = &TestAPIParams{query: "/account/searchable", tokenType: APPTokenAgent, token: botToken, body: &searchable}
+	assert.NoError(t, TestAPIRequest(SetAccountSearchable, params, nil))
+	profiles := []CardProfile{}
+	params = &TestAPIParams{query: "/account/listing?filter=botaccount"}
+	response = &TestAPIResponse{data: &profiles}
+	assert.NoError(t, TestAPIRequest(GetAccountListing, params, response))
+	for _, profile := range profiles {
+		assert.NotEqual(t, botGUID, profile.GUID)
+	}
+
+	// profile carries bot badge
+	profile := &Profile{}
+	params = &TestAPIParams{query: "/profile", tokenType: APPTokenAgent, token: botToken}
+	response = &TestAPIResponse{data: profile}
+	assert.NoError(t, TestAPIRequest(GetProfile, params, response))
+	assert.True(t, profile.Bot)
+
+	// connect human and bot
+	humanCardID, err := addTestCard(humanToken, botToken)
+	assert.NoError(t, err)
+	assert.NoError(t, openTestCard(humanToken, humanCardID))
+	botCardID, err := addTestCard(botToken, humanToken)
+	assert.NoError(t, err)
+	assert.NoError(t, openTestCard(botToken, botCardID))
+
+	// card profile carries bot badge
+	cardProfile := &CardProfile{}
+	assert.NoError(t, APITestMsg(GetCardProfile, "GET", "/contact/cards/{cardID}/profile",
+		&map[string]string{"cardID": humanCardID}, nil, APPTokenAgent, humanToken, cardProfile, nil))
+	assert.True(t, cardProfile.Bot)
+
+	// human shares channel with bot
+	channelID, err := addTestChannel(humanToken)
+	assert.NoError(t, err)
+	humanChannelID, err := addTestChannel(humanToken)
+	assert.NoError(t, err)
+	assert.NoError(t, APITestMsg(SetChannelCard, "PUT", "/content/channels/{channelID}/cards/{cardID}",
+		&map[string]string{"channelID": channelID, "cardID": humanCardID}, nil, APPTokenAgent, humanToken, nil, nil))
+
+	// bot posts to shared channel with key through its card
+	topic := &Topic{}
+	subject := &Subject{Data: "build passed", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics?card="+botCardID,
+		&map[string]string{"channelID": channelID}, subject, APPTokenAgent, key.Key, topic, nil))
+	topics := []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopics, "GET", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, nil, APPTokenAgent, humanToken, &topics, nil))
+	assert.Equal(t, 1, len(topics))
+	assert.Equal(t, botGUID, topics[0].Data.TopicDetail.GUID)
+
+	// card only resolves channels bot was added to
+	assert.Error(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics?card="+botCardID,
+		&map[string]string{"channelID": humanChannelID}, subject, APPTokenAgent, key.Key, nil, nil))
+
+	// revoke key
+	assert.NoError(t, APITestMsg(RemoveAccountKey, "DELETE", "/account/keys/{keyID}",
+		&map[string]string{"keyID": key.ID}, nil, APPTokenAgent, botToken, nil, nil))
+	assert.Error(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": botChannelID}, &Subject{Data: "revoked", DataType: "topicdatatype"}, APPTokenAgent, key.Key, nil, nil))
+}
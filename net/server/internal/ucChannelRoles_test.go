@@ -0,0 +1,95 @@
+package databag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestChannelRoles(t *testing.T) {
+
+	// setup host with admin and reader contacts
+	_, hostToken, err := addTestAccount("channelrolesA")
+	assert.NoError(t, err)
+	adminGUID, adminToken, err := addTestAccount("channelrolesB")
+	assert.NoError(t, err)
+	readerGUID, readerToken, err := addTestAccount("channelrolesC")
+	assert.NoError(t, err)
+	hostAdminCardID, adminHostCardID, err := connectTestCards(hostToken, adminToken)
+	assert.NoError(t, err)
+	hostReaderCardID, readerHostCardID, err := connectTestCards(hostToken, readerToken)
+	assert.NoError(t, err)
+	adminContact, err := getCardToken(adminToken, adminHostCardID)
+	assert.NoError(t, err)
+	readerContact, err := getCardToken(readerToken, readerHostCardID)
+	assert.NoError(t, err)
+
+	// share channel with both contacts
+	channelID, err := addTestChannel(hostToken)
+	assert.NoError(t, err)
+	for _, cardID := range []string{hostAdminCardID, hostReaderCardID} {
+		assert.NoError(t, APITestMsg(SetChannelCard, "PUT", "/content/channels/{channelID}/cards/{cardID}",
+			&map[string]string{"channelID": channelID, "cardID": cardID}, nil, APPTokenAgent, hostToken, nil, nil))
+	}
+
+	// members post by default
+	topic := &Topic{}
+	subject := &Subject{Data: "readerdata", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, readerContact, topic, nil))
+
+	// only known roles assigned
+	role := "superuser"
+	assert.Error(t, APITestMsg(SetChannelCardRole, "PUT", "/content/channels/{channelID}/cards/{cardID}/role",
+		&map[string]string{"channelID": channelID, "cardID": hostReaderCardID}, &role, APPTokenAgent, hostToken, nil, nil))
+
+	// assign roles
+	role = APPChannelReader
+	assert.NoError(t, APITestMsg(SetChannelCardRole, "PUT", "/content/channels/{channelID}/cards/{cardID}/role",
+		&map[string]string{"channelID": channelID, "cardID": hostReaderCardID}, &role, APPTokenAgent, hostToken, nil, nil))
+	role = APPChannelAdmin
+	assert.NoError(t, APITestMsg(SetChannelCardRole, "PUT", "/content/channels/{channelID}/cards/{cardID}/role",
+		&map[string]string{"channelID": channelID, "cardID": hostAdminCardID}, &role, APPTokenAgent, hostToken, nil, nil))
+
+	// roles exposed in detail
+	detail := &ChannelDetail{}
+	assert.NoError(t, APITestMsg(GetChannelDetail, "GET", "/content/channels/{channelID}/detail",
+		&map[string]string{"channelID": channelID}, nil, APPTokenAgent, hostToken, detail, nil))
+	assert.Equal(t, APPChannelReader, detail.Roles[readerGUID])
+	assert.Equal(t, APPChannelAdmin, detail.Roles[adminGUID])
+
+	// reader can no longer post, tag or edit
+	subject = &Subject{Data: "readerdata", DataType: "topicdatatype"}
+	assert.Error(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, readerContact, nil, nil))
+	assert.Error(t, APITestMsg(SetChannelTopicSubject, "PUT", "/content/channels/{channelID}/topics/{topicID}/subject",
+		&map[string]string{"channelID": channelID, "topicID": topic.ID}, subject, APPTokenContact, readerContact, nil, nil))
+	subject = &Subject{Data: "tagdata", DataType: "tagdatatype"}
+	assert.Error(t, APITestMsg(AddChannelTopicTag, "POST", "/content/channels/{channelID}/topics/{topicID}/tags",
+		&map[string]string{"channelID": channelID, "topicID": topic.ID}, subject, APPTokenContact, readerContact, nil, nil))
+
+	// admin moderates reader topic and channel subject
+	subject = &Subject{Data: "moderated", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(SetChannelTopicSubject, "PUT", "/content/channels/{channelID}/topics/{topicID}/subject",
+		&map[string]string{"channelID": channelID, "topicID": topic.ID}, subject, APPTokenContact, adminContact, nil, nil))
+	subject = &Subject{Data: "renamed", DataType: "channeldatatype"}
+	assert.NoError(t, APITestMsg(SetChannelSubject, "PUT", "/content/channels/{channelID}/subject",
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, adminContact, nil, nil))
+	assert.Error(t, APITestMsg(SetChannelSubject, "PUT", "/content/channels/{channelID}/subject",
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, readerContact, nil, nil))
+	assert.NoError(t, APITestMsg(RemoveChannelTopic, "DELETE", "/content/channels/{channelID}/topics/{topicID}",
+		&map[string]string{"channelID": channelID, "topicID": topic.ID}, nil, APPTokenContact, adminContact, nil, nil))
+
+	// admin manages membership, reader cannot
+	cardParams := &map[string]string{"channelID": channelID, "cardID": hostReaderCardID}
+	assert.Error(t, APITestMsg(ClearChannelCard, "DELETE", "/content/channels/{channelID}/cards/{cardID}",
+		cardParams, nil, APPTokenContact, readerContact, nil, nil))
+	assert.NoError(t, APITestMsg(ClearChannelCard, "DELETE", "/content/channels/{channelID}/cards/{cardID}",
+		cardParams, nil, APPTokenContact, adminContact, nil, nil))
+	assert.Error(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, readerContact, nil, nil))
+	assert.NoError(t, APITestMsg(SetChannelCard, "PUT", "/content/channels/{channelID}/cards/{cardID}",
+		cardParams, nil, APPTokenContact, adminContact, nil, nil))
+	subject = &Subject{Data: "readmitted", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, readerContact, nil, nil))
+}
@@ -0,0 +1,146 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/clause"
+	"testing"
+	"time"
+)
+
+func TestContactBlock(t *testing.T) {
+
+	// setup testing accounts
+	_, aToken, err := addTestAccount("contactblockA")
+	assert.NoError(t, err)
+	_, bToken, err := addTestAccount("contactblockB")
+	assert.NoError(t, err)
+	aCardID, bCardID, err := connectTestCards(aToken, bToken)
+	assert.NoError(t, err)
+	contact, err := getCardToken(bToken, bCardID)
+	assert.NoError(t, err)
+
+	// B has contact access to A
+	channels := []Channel{}
+	assert.NoError(t, APITestMsg(GetChannels, "GET", "/content/channels",
+		nil, nil, APPTokenContact, contact, &channels, nil))
+
+	// A blocks B
+	detail := &CardDetail{}
+	flag := true
+	assert.NoError(t, APITestMsg(SetCardBlocked, "PUT", "/contact/cards/{cardID}/blocked",
+		&map[string]string{"cardID": aCardID}, &flag, APPTokenAgent, aToken, detail, nil))
+	assert.True(t, detail.Blocked)
+	token := detail.Token
+
+	// contact access rejected
+	assert.Error(t, APITestMsg(GetChannels, "GET", "/content/channels",
+		nil, nil, APPTokenContact, contact, &channels, nil))
+
+	// reconnect from B ignored
+	assert.NoError(t, openTestCard(bToken, bCardID))
+	detail = &CardDetail{}
+	assert.NoError(t, APITestMsg(GetCardDetail, "GET", "/contact/cards/{cardID}/detail",
+		&map[string]string{"cardID": aCardID}, nil, APPTokenAgent, aToken, detail, nil))
+	assert.True(t, detail.Blocked)
+	assert.Equal(t, token, detail.Token)
+
+	// unblock restores access
+	flag = false
+	detail = &CardDetail{}
+	assert.NoError(t, APITestMsg(SetCardBlocked, "PUT", "/contact/cards/{cardID}/blocked",
+		&map[string]string{"cardID": aCardID}, &flag, APPTokenAgent, aToken, detail, nil))
+	assert.False(t, detail.Blocked)
+	assert.NoError(t, APITestMsg(GetChannels, "GET", "/content/channels",
+		nil, nil, APPTokenContact, contact, &channels, nil))
+}
+
+func TestContactMute(t *testing.T) {
+
+	// setup testing accounts
+	_, aToken, err := addTestAccount("contactmuteA")
+	assert.NoError(t, err)
+	_, bToken, err := addTestAccount("contactmuteB")
+	assert.NoError(t, err)
+	aCardID, bCardID, err := connectTestCards(aToken, bToken)
+	assert.NoError(t, err)
+	contact, err := getCardToken(bToken, bCardID)
+	assert.NoError(t, err)
+
+	// observe push events of A through webhook log
+	assert.NoError(t, store.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "config_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"bool_value"}),
+	}).Create(&store.Config{ConfigID: CNFWebhookPrivate, BoolValue: true}).Error)
+	defer store.DB.Model(&store.Config{}).Where("config_id = ?", CNFWebhookPrivate).Update("bool_value", false)
+	record := func() {
+		for len(webhookEvents) > 0 {
+			event := <-webhookEvents
+			recordWebhookEvent(&event)
+		}
+	}
+	record()
+	webhook := &Webhook{}
+	params := &WebhookParams{URL: "https://127.0.0.1:1/push", Events: []string{"push.*"}}
+	assert.NoError(t, APITestMsg(AddAccountWebhook, "POST", "/account/webhooks", nil, params,
+		APPTokenAgent, aToken, webhook, nil))
+	pushed := func() int {
+		record()
+		deliveries := []WebhookDelivery{}
+		assert.NoError(t, APITestMsg(GetAccountWebhookDeliveries, "GET", "/account/webhooks/{webhookID}/deliveries",
+			&map[string]string{"webhookID": webhook.ID}, nil, APPTokenAgent, aToken, &deliveries, nil))
+		return len(deliveries)
+	}
+
+	// A shares channel with B
+	channelID, err := addTestChannel(aToken)
+	assert.NoError(t, err)
+	assert.NoError(t, APITestMsg(SetChannelCard, "PUT", "/content/channels/{channelID}/cards/{cardID}",
+		&map[string]string{"channelID": channelID, "cardID": aCardID}, nil, APPTokenAgent, aToken, nil, nil))
+
+	// ring pushed to A
+	ring := &Ring{CallID: "call", CalleeToken: "callee", Index: 0}
+	assert.NoError(t, APITestMsg(AddRing, "POST", "/talk/rings", nil, ring, APPTokenContact, contact, nil, nil))
+	assert.Equal(t, 1, pushed())
+
+	// A mutes B, ring still delivered without push
+	flag := true
+	detail := &CardDetail{}
+	assert.NoError(t, APITestMsg(SetCardMuted, "PUT", "/contact/cards/{cardID}/muted",
+		&map[string]string{"cardID": aCardID}, &flag, APPTokenAgent, aToken, detail, nil))
+	assert.True(t, detail.Muted)
+	assert.NoError(t, APITestMsg(AddRing, "POST", "/talk/rings", nil, ring, APPTokenContact, contact, nil, nil))
+	assert.Equal(t, 1, pushed())
+
+	// muted contact topics sync without push
+	topic := &Topic{}
+	subject := &Subject{Data: "muted", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, contact, topic, nil))
+	topics := []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopics, "GET", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, nil, APPTokenAgent, aToken, &topics, nil))
+	assert.Equal(t, 1, len(topics))
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, pushed())
+
+	// A mutes channel instead of contact
+	flag = false
+	assert.NoError(t, APITestMsg(SetCardMuted, "PUT", "/contact/cards/{cardID}/muted",
+		&map[string]string{"cardID": aCardID}, &flag, APPTokenAgent, aToken, detail, nil))
+	flag = true
+	assert.NoError(t, APITestMsg(SetChannelMuted, "PUT", "/content/channels/{channelID}/muted",
+		&map[string]string{"channelID": channelID}, &flag, APPTokenAgent, aToken, nil, nil))
+	channelDetail := &ChannelDetail{}
+	assert.NoError(t, APITestMsg(GetChannelDetail, "GET", "/content/channels/{channelID}/detail",
+		&map[string]string{"channelID": channelID}, nil, APPTokenAgent, aToken, channelDetail, nil))
+	assert.True(t, channelDetail.Muted)
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, contact, topic, nil))
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, pushed())
+
+	// unmuted contact ring pushed again
+	assert.NoError(t, APITestMsg(AddRing, "POST", "/talk/rings", nil, ring, APPTokenContact, contact, nil, nil))
+	assert.Equal(t, 2, pushed())
+}
@@ -0,0 +1,141 @@
+package databag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestContactPolicy(t *testing.T) {
+	var params *TestAPIParams
+	var response *TestAPIResponse
+
+	// setup testing accounts
+	aGUID, aToken, err := addTestAccount("contactpolicyA")
+	assert.NoError(t, err)
+	bGUID, bToken, err := addTestAccount("contactpolicyB")
+	assert.NoError(t, err)
+	_, cToken, err := addTestAccount("contactpolicyC")
+	assert.NoError(t, err)
+
+	// default policy accepts anyone
+	policy := &ContactPolicy{}
+	params = &TestAPIParams{query: "/account/contact/policy", tokenType: APPTokenAgent, token: aToken}
+	response = &TestAPIResponse{data: policy}
+	assert.NoError(t, TestAPIRequest(GetAccountContactPolicy, params, response))
+	assert.Equal(t, APPContactAnyone, policy.Policy)
+
+	// A blocks B
+	block := &ContactBlock{}
+	params = &TestAPIParams{query: "/account/contact/blocks", tokenType: APPTokenAgent, token: aToken, body: &ContactBlock{GUID: bGUID}}
+	response = &TestAPIResponse{data: block}
+	assert.NoError(t, TestAPIRequest(AddAccountContactBlock, params, response))
+	assert.Equal(t, bGUID, block.GUID)
+
+	// B request silently ignored
+	bCardID, err := addTestCard(bToken, aToken)
+	assert.NoError(t, err)
+	assert.NoError(t, openTestCard(bToken, bCardID))
+	_, err = getCardID(aToken, bGUID)
+	assert.Error(t, err)
+
+	// blocking node ignores all of its senders
+	assert.NoError(t, APITestMsg(RemoveAccountContactBlock, "DELETE", "/account/contact/blocks/{blockID}",
+		&map[string]string{"blockID": block.ID}, nil, APPTokenAgent, aToken, nil, nil))
+	params = &TestAPIParams{query: "/account/contact/blocks", tokenType: APPTokenAgent, token: aToken, body: &ContactBlock{Node: "Databag.CoreDB.org"}}
+	response = &TestAPIResponse{data: block}
+	assert.NoError(t, TestAPIRequest(AddAccountContactBlock, params, response))
+	assert.NoError(t, openTestCard(bToken, bCardID))
+	_, err = getCardID(aToken, bGUID)
+	assert.Error(t, err)
+	blocks := []ContactBlock{}
+	params = &TestAPIParams{query: "/account/contact/blocks", tokenType: APPTokenAgent, token: aToken}
+	response = &TestAPIResponse{data: &blocks}
+	assert.NoError(t, TestAPIRequest(GetAccountContactBlocks, params, response))
+	assert.Equal(t, 1, len(blocks))
+	assert.Equal(t, "databag.coredb.org", blocks[0].Node)
+	assert.NoError(t, APITestMsg(RemoveAccountContactBlock, "DELETE", "/account/contact/blocks/{blockID}",
+		&map[string]string{"blockID": block.ID}, nil, APPTokenAgent, aToken, nil, nil))
+
+	// A accepts nobody
+	params = &TestAPIParams{query: "/account/contact/policy", tokenType: APPTokenAgent, token: aToken, body: &ContactPolicy{Policy: APPContactNobody}}
+	assert.NoError(t, TestAPIRequest(SetAccountContactPolicy, params, nil))
+	assert.Error(t, openTestCard(bToken, bCardID))
+
+	// A accepts only listed nodes
+	params = &TestAPIParams{query: "/account/contact/policy", tokenType: APPTokenAgent, token: aToken,
+		body: &ContactPolicy{Policy: APPContactNodes, Nodes: []string{"other.org"}}}
+	assert.NoError(t, TestAPIRequest(SetAccountContactPolicy, params, nil))
+	assert.Error(t, openTestCard(bToken, bCardID))
+	params = &TestAPIParams{query: "/account/contact/policy", tokenType: APPTokenAgent, token: aToken,
+		body: &ContactPolicy{Policy: APPContactNodes, Nodes: []string{"other.org", "databag.coredb.org"}}}
+	assert.NoError(t, TestAPIRequest(SetAccountContactPolicy, params, nil))
+	assert.NoError(t, openTestCard(bToken, bCardID))
+	_, err = getCardID(aToken, bGUID)
+	assert.NoError(t, err)
+
+	// sender not hosted on claimed node ignored
+	privatePem, publicPem, keyType, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	hash := sha256.Sum256([]byte(publicPem))
+	spoofGUID := hex.EncodeToString(hash[:])
+	msg, err := WriteDataMessage(privatePem, publicPem, keyType, getSignType(keyType), spoofGUID, APPMsgConnect,
+		&Connect{Contact: aGUID, Token: "spooftoken", Node: "databag.coredb.org"})
+	assert.NoError(t, err)
+	status := &ContactStatus{}
+	assert.NoError(t, APITestMsg(SetOpenMessage, "PUT", "/contact/openMessage", nil, msg, "", "", status, nil))
+	assert.Equal(t, APPCardPending, status.Status)
+	_, err = getCardID(aToken, spoofGUID)
+	assert.Error(t, err)
+
+	// existing cards unaffected by policy
+	params = &TestAPIParams{query: "/account/contact/policy", tokenType: APPTokenAgent, token: aToken, body: &ContactPolicy{Policy: APPContactNobody}}
+	assert.NoError(t, TestAPIRequest(SetAccountContactPolicy, params, nil))
+	assert.NoError(t, openTestCard(bToken, bCardID))
+
+	// C accepts contacts of contacts
+	params = &TestAPIParams{query: "/account/contact/policy", tokenType: APPTokenAgent, token: cToken, body: &ContactPolicy{Policy: APPContactMutual}}
+	assert.NoError(t, TestAPIRequest(SetAccountContactPolicy, params, nil))
+	bCardID, err = addTestCard(bToken, cToken)
+	assert.NoError(t, err)
+	assert.Error(t, openTestCard(bToken, bCardID))
+
+	// B connected to A, A connected to C
+	aCardID, err := getCardID(aToken, bGUID)
+	assert.NoError(t, err)
+	assert.NoError(t, openTestCard(aToken, aCardID))
+	params = &TestAPIParams{query: "/account/contact/policy", tokenType: APPTokenAgent, token: aToken, body: &ContactPolicy{Policy: APPContactAnyone}}
+	assert.NoError(t, TestAPIRequest(SetAccountContactPolicy, params, nil))
+	_, _, err = connectTestCards(cToken, aToken)
+	assert.NoError(t, err)
+	assert.NoError(t, openTestCard(bToken, bCardID))
+	_, err = getCardID(cToken, bGUID)
+	assert.NoError(t, err)
+
+	// invalid policy rejected
+	params = &TestAPIParams{query: "/account/contact/policy", tokenType: APPTokenAgent, token: aToken, body: &ContactPolicy{Policy: "everyone"}}
+	assert.Error(t, TestAPIRequest(SetAccountContactPolicy, params, nil))
+}
+
+func TestContactRequestLimit(t *testing.T) {
+	t.Setenv("DATABAG_CONTACT_REQUEST_LIMIT", "1")
+
+	// setup testing accounts
+	_, aToken, err := addTestAccount("contactlimitA")
+	assert.NoError(t, err)
+	_, bToken, err := addTestAccount("contactlimitB")
+	assert.NoError(t, err)
+	_, cToken, err := addTestAccount("contactlimitC")
+	assert.NoError(t, err)
+
+	// first pending request accepted
+	cardID, err := addTestCard(aToken, bToken)
+	assert.NoError(t, err)
+	assert.NoError(t, openTestCard(aToken, cardID))
+
+	// further requests within period rejected
+	cardID, err = addTestCard(aToken, cToken)
+	assert.NoError(t, err)
+	assert.Error(t, openTestCard(aToken, cardID))
+}
@@ -0,0 +1,121 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestKeySuccession(t *testing.T) {
+	var params *TestAPIParams
+	var response *TestAPIResponse
+
+	// setup testing accounts
+	_, aToken, err := addTestAccount("keysuccessionA")
+	assert.NoError(t, err)
+	bGUID, bToken, err := addTestAccount("keysuccessionB")
+	assert.NoError(t, err)
+	aCardID, bCardID, err := connectTestCards(aToken, bToken)
+	assert.NoError(t, err)
+	priorContact, err := getCardToken(aToken, aCardID)
+	assert.NoError(t, err)
+
+	// A shares channel with B
+	channelID, err := addTestChannel(aToken)
+	assert.NoError(t, err)
+	assert.NoError(t, APITestMsg(SetChannelCard, "PUT", "/content/channels/{channelID}/cards/{cardID}",
+		&map[string]string{"channelID": channelID, "cardID": aCardID}, nil, APPTokenAgent, aToken, nil, nil))
+
+	// B posts to shared channel
+	contact, err := getCardToken(bToken, bCardID)
+	assert.NoError(t, err)
+	topic := &Topic{}
+	subject := &Subject{Data: "before rotation", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, contact, topic, nil))
+
+	// rotation requires login
+	profile := &Profile{}
+	params = &TestAPIParams{query: "/account/identity", authorization: "keysuccessionB:wrong"}
+	response = &TestAPIResponse{data: profile}
+	assert.Error(t, TestAPIRequest(SetAccountIdentity, params, response))
+
+	// rotation requires totp like app login
+	assert.NoError(t, store.DB.Model(&store.Account{}).Where("guid = ?", bGUID).Updates(map[string]interface{}{
+		"mfa_enabled": true, "mfa_confirmed": true, "mfa_secret": "JBSWY3DPEHPK3PXP", "mfa_algorithm": APPMFASHA1}).Error)
+	params = &TestAPIParams{query: "/account/identity", authorization: "keysuccessionB:pass"}
+	response = &TestAPIResponse{data: profile}
+	assert.Error(t, TestAPIRequest(SetAccountIdentity, params, response))
+
+	// B rotates identity key
+	code, err := totp.GenerateCodeCustom("JBSWY3DPEHPK3PXP", time.Now(), totp.ValidateOpts{Period: 30, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1})
+	assert.NoError(t, err)
+	params = &TestAPIParams{query: "/account/identity?code=" + code, authorization: "keysuccessionB:pass"}
+	response = &TestAPIResponse{data: profile}
+	assert.NoError(t, TestAPIRequest(SetAccountIdentity, params, response))
+	assert.NotEqual(t, bGUID, profile.GUID)
+	successor := profile.GUID
+
+	// prior agent token resolves to successor
+	profile = &Profile{}
+	assert.NoError(t, APITestMsg(GetProfile, "GET", "/profile", nil, nil, APPTokenAgent, bToken, profile, nil))
+	assert.Equal(t, successor, profile.GUID)
+
+	// A remaps card without reconnecting
+	cardProfile := &CardProfile{}
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, APITestMsg(GetCardProfile, "GET", "/contact/cards/{cardID}/profile",
+			&map[string]string{"cardID": aCardID}, nil, APPTokenAgent, aToken, cardProfile, nil))
+		if cardProfile.GUID == successor {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, successor, cardProfile.GUID)
+
+	// topic authored by B follows successor
+	authored := &Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopic, "GET", "/content/channels/{channelID}/topics/{topicID}/detail",
+		&map[string]string{"channelID": channelID, "topicID": topic.ID}, nil, APPTokenAgent, aToken, authored, nil))
+	assert.Equal(t, successor, authored.Data.TopicDetail.GUID)
+
+	// prior contact token replaced by successor token once delivered
+	channels := []Channel{}
+	assert.Eventually(t, func() bool {
+		return APITestMsg(GetChannels, "GET", "/content/channels",
+			nil, nil, APPTokenContact, priorContact, &channels, nil) != nil
+	}, 2*time.Second, 100*time.Millisecond)
+	contact, err = getCardToken(aToken, aCardID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, priorContact, contact)
+	assert.NoError(t, APITestMsg(GetChannels, "GET", "/content/channels",
+		nil, nil, APPTokenContact, contact, &channels, nil))
+
+	// B continues posting under successor
+	contact, err = getCardToken(bToken, bCardID)
+	assert.NoError(t, err)
+	subject = &Subject{Data: "after rotation", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, contact, &Topic{}, nil))
+
+	// undelivered succession is retried and blocks another rotation
+	card := store.Card{}
+	assert.NoError(t, store.DB.Where("account_id = ?", successor).First(&card).Error)
+	assert.Empty(t, card.PriorInToken)
+	notification := &store.Notification{Module: APPNotifySuccession, GUID: card.GUID, Token: card.OutToken, Event: "invalid"}
+	assert.NoError(t, store.DB.Save(notification).Error)
+	defer store.DB.Delete(notification)
+	notify <- notification
+	assert.Eventually(t, func() bool {
+		pending := store.Notification{}
+		return store.DB.Where("id = ?", notification.ID).First(&pending).Error == nil && pending.Attempts == 1
+	}, 2*time.Second, 100*time.Millisecond)
+	code, err = totp.GenerateCodeCustom("JBSWY3DPEHPK3PXP", time.Now(), totp.ValidateOpts{Period: 30, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1})
+	assert.NoError(t, err)
+	params = &TestAPIParams{query: "/account/identity?code=" + code, authorization: "keysuccessionB:pass"}
+	response = &TestAPIResponse{data: profile}
+	assert.Error(t, TestAPIRequest(SetAccountIdentity, params, response))
+}
@@ -0,0 +1,93 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTopicAssetStream(t *testing.T) {
+
+	// transform writing a single rendition stream
+	script := []byte("#!/bin/sh\nmkdir -p $2.hls\n" +
+		"printf '#EXTM3U\\n#EXT-X-STREAM-INF:BANDWIDTH=400000\\nlq.m3u8\\n' > $2.hls/index.m3u8\n" +
+		"printf '#EXTM3U\\n#EXT-X-MAP:URI=\"lq_init.ts\"\\n#EXTINF:6.0,\\nlq_000.ts\\n#EXT-X-ENDLIST\\n' > $2.hls/lq.m3u8\n" +
+		"cat $1 > $2.hls/lq_000.ts\ncp $2.hls/index.m3u8 $2\n")
+	assert.NoError(t, os.WriteFile("testscripts/transform_"+APPStreamTransform+".sh", script, 0555))
+	t.Cleanup(func() { os.Remove("testscripts/transform_" + APPStreamTransform + ".sh") })
+
+	guid, token, params, err := addTestChannelTopic("assetstream", "video")
+	assert.NoError(t, err)
+	_, other, err := addTestAccount("assetstreamother")
+	assert.NoError(t, err)
+	transforms, err := json.Marshal([]string{APPStreamTransform + ";video", "copy;video"})
+	assert.NoError(t, err)
+	assets := []Asset{}
+	assert.NoError(t, APITestUpload(AddChannelTopicAsset, "POST", "/content/channels/{channelID}/topics/{topicID}/assets?transforms="+url.QueryEscape(string(transforms)),
+		&params, []byte("segment"), APPTokenAgent, token, &assets, nil))
+	assert.Equal(t, 3, len(assets))
+	assetID := assets[1].AssetID
+	assert.Eventually(t, func() bool {
+		var asset store.Asset
+		assert.NoError(t, store.DB.Where("asset_id = ?", assetID).First(&asset).Error)
+		return asset.Status == APPAssetReady
+	}, 5*time.Second, 100*time.Millisecond)
+
+	stream := func(assetID string, file string, tokenType string, token string) (string, error) {
+		streamParams := map[string]string{"channelID": params["channelID"], "topicID": params["topicID"], "assetID": assetID, "file": file}
+		data, _, err := APITestData(GetChannelTopicAssetStream, "GET", "/content/channels/{channelID}/topics/{topicID}/assets/{assetID}/stream/{file}",
+			&streamParams, nil, tokenType, token, 0, 0)
+		return string(data), err
+	}
+
+	// playlists carry token to referenced files
+	data, err := stream(assetID, "index.m3u8", APPTokenAgent, token)
+	assert.NoError(t, err)
+	assert.Contains(t, data, "\nlq.m3u8?agent="+token+"\n")
+	data, err = stream(assetID, "lq.m3u8", APPTokenAgent, token)
+	assert.NoError(t, err)
+	assert.Contains(t, data, `URI="lq_init.ts?agent=`+token+`"`)
+	assert.Contains(t, data, "\nlq_000.ts?agent="+token+"\n")
+	data, err = stream(assetID, "lq_000.ts", APPTokenAgent, token)
+	assert.NoError(t, err)
+	assert.Equal(t, "segment", data)
+
+	// other accounts and non stream files are refused
+	_, err = stream(assetID, "lq_000.ts", APPTokenAgent, other)
+	assert.Error(t, err)
+	_, err = stream(assetID, "..", APPTokenAgent, token)
+	assert.Error(t, err)
+	_, err = stream(assets[2].AssetID, "index.m3u8", APPTokenAgent, token)
+	assert.Error(t, err)
+
+	// stream of scheduled topic hidden from member
+	hostCardID, memberCardID, err := connectTestCards(token, other)
+	assert.NoError(t, err)
+	contact, err := getCardToken(other, memberCardID)
+	assert.NoError(t, err)
+	assert.NoError(t, APITestMsg(SetChannelCard, "PUT", "/content/channels/{channelID}/cards/{cardID}",
+		&map[string]string{"channelID": params["channelID"], "cardID": hostCardID}, nil, APPTokenAgent, token, nil, nil))
+	_, err = stream(assetID, "lq_000.ts", APPTokenContact, contact)
+	assert.NoError(t, err)
+	var streamed store.Asset
+	assert.NoError(t, store.DB.Where("asset_id = ?", assetID).First(&streamed).Error)
+	assert.NoError(t, store.DB.Model(&store.Topic{}).Where("id = ?", streamed.TopicID).
+		Updates(map[string]interface{}{"publish": time.Now().Unix() + 60, "status": APPTopicScheduled}).Error)
+	_, err = stream(assetID, "lq_000.ts", APPTokenContact, contact)
+	assert.Error(t, err)
+	_, err = stream(assetID, "lq_000.ts", APPTokenAgent, token)
+	assert.NoError(t, err)
+
+	// removed asset segments are collected
+	assert.NoError(t, APITestMsg(RemoveChannelTopic, "DELETE", "/content/channels/{channelID}/topics/{topicID}",
+		&params, nil, APPTokenAgent, token, nil, nil))
+	var account store.Account
+	assert.NoError(t, store.DB.Where("guid = ?", guid).First(&account).Error)
+	garbageCollect(&account)
+	_, err = os.Stat(getStrConfigValue(CNFAssetPath, APPDefaultPath) + "/" + guid + "/" + assetID + APPStreamSuffix)
+	assert.True(t, os.IsNotExist(err))
+}
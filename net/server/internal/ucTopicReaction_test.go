@@ -0,0 +1,83 @@
+package databag
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTopicReaction(t *testing.T) {
+
+	// setup host and member
+	_, hostToken, err := addTestAccount("topicreactionA")
+	assert.NoError(t, err)
+	_, memberToken, err := addTestAccount("topicreactionB")
+	assert.NoError(t, err)
+	hostCardID, memberCardID, err := connectTestCards(hostToken, memberToken)
+	assert.NoError(t, err)
+	contact, err := getCardToken(memberToken, memberCardID)
+	assert.NoError(t, err)
+
+	channelID, err := addTestChannel(hostToken)
+	assert.NoError(t, err)
+	assert.NoError(t, APITestMsg(SetChannelCard, "PUT", "/content/channels/{channelID}/cards/{cardID}",
+		&map[string]string{"channelID": channelID, "cardID": hostCardID}, nil, APPTokenAgent, hostToken, nil, nil))
+	topic := &Topic{}
+	subject := &Subject{Data: "topicdata", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, subject, APPTokenAgent, hostToken, topic, nil))
+	params := &map[string]string{"channelID": channelID, "topicID": topic.ID}
+
+	// react from both sides, repeated reaction is counted once
+	thumbs := "👍"
+	party := "🎉"
+	assert.NoError(t, APITestMsg(AddChannelTopicReaction, "POST", "/content/channels/{channelID}/topics/{topicID}/reactions",
+		params, &thumbs, APPTokenAgent, hostToken, nil, nil))
+	assert.NoError(t, APITestMsg(AddChannelTopicReaction, "POST", "/content/channels/{channelID}/topics/{topicID}/reactions",
+		params, &thumbs, APPTokenContact, contact, nil, nil))
+	assert.NoError(t, APITestMsg(AddChannelTopicReaction, "POST", "/content/channels/{channelID}/topics/{topicID}/reactions",
+		params, &thumbs, APPTokenContact, contact, nil, nil))
+	assert.NoError(t, APITestMsg(AddChannelTopicReaction, "POST", "/content/channels/{channelID}/topics/{topicID}/reactions",
+		params, &party, APPTokenContact, contact, nil, nil))
+
+	// counts aggregated in detail, tag revision advanced
+	reacted := &Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopic, "GET", "/content/channels/{channelID}/topics/{topicID}/detail",
+		params, nil, APPTokenContact, contact, reacted, nil))
+	assert.Equal(t, int64(2), reacted.Data.TopicDetail.Reactions[thumbs])
+	assert.Equal(t, int64(1), reacted.Data.TopicDetail.Reactions[party])
+	assert.Greater(t, reacted.Data.TagRevision, topic.Data.TagRevision)
+
+	reactions := []Reaction{}
+	assert.NoError(t, APITestMsg(GetChannelTopicReactions, "GET", "/content/channels/{channelID}/topics/{topicID}/reactions",
+		params, nil, APPTokenAgent, hostToken, &reactions, nil))
+	assert.Equal(t, 3, len(reactions))
+
+	// remove own reaction
+	removeParams := &map[string]string{"channelID": channelID, "topicID": topic.ID, "emoji": party}
+	assert.Error(t, APITestMsg(RemoveChannelTopicReaction, "DELETE", "/content/channels/{channelID}/topics/{topicID}/reactions/{emoji}",
+		removeParams, nil, APPTokenAgent, hostToken, nil, nil))
+	assert.NoError(t, APITestMsg(RemoveChannelTopicReaction, "DELETE", "/content/channels/{channelID}/topics/{topicID}/reactions/{emoji}",
+		removeParams, nil, APPTokenContact, contact, nil, nil))
+	reacted = &Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopic, "GET", "/content/channels/{channelID}/topics/{topicID}/detail",
+		params, nil, APPTokenAgent, hostToken, reacted, nil))
+	assert.Equal(t, int64(2), reacted.Data.TopicDetail.Reactions[thumbs])
+	_, set := reacted.Data.TopicDetail.Reactions[party]
+	assert.False(t, set)
+
+	// reactions of scheduled topic hidden from member
+	scheduled := &Topic{}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics?confirm=true&schedule="+strconv.FormatInt(time.Now().Unix()+60, 10),
+		&map[string]string{"channelID": channelID}, subject, APPTokenAgent, hostToken, scheduled, nil))
+	scheduledParams := &map[string]string{"channelID": channelID, "topicID": scheduled.ID}
+	assert.NoError(t, APITestMsg(GetChannelTopicReactions, "GET", "/content/channels/{channelID}/topics/{topicID}/reactions",
+		scheduledParams, nil, APPTokenAgent, hostToken, &reactions, nil))
+	r, w, _ := NewRequest("GET", "/content/channels/{channelID}/topics/{topicID}/reactions?contact="+contact, nil)
+	r = mux.SetURLVars(r, *scheduledParams)
+	GetChannelTopicReactions(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,99 @@
+package databag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTopicSchedule(t *testing.T) {
+
+	// setup host and member
+	_, hostToken, err := addTestAccount("topicscheduleA")
+	assert.NoError(t, err)
+	_, memberToken, err := addTestAccount("topicscheduleB")
+	assert.NoError(t, err)
+	hostCardID, memberCardID, err := connectTestCards(hostToken, memberToken)
+	assert.NoError(t, err)
+	contact, err := getCardToken(memberToken, memberCardID)
+	assert.NoError(t, err)
+
+	channelID, err := addTestChannel(hostToken)
+	assert.NoError(t, err)
+	assert.NoError(t, APITestMsg(SetChannelCard, "PUT", "/content/channels/{channelID}/cards/{cardID}",
+		&map[string]string{"channelID": channelID, "cardID": hostCardID}, nil, APPTokenAgent, hostToken, nil, nil))
+
+	// disappearing timer on channel
+	expire := int64(3600)
+	assert.NoError(t, APITestMsg(SetChannelExpire, "PUT", "/content/channels/{channelID}/expire",
+		&map[string]string{"channelID": channelID}, &expire, APPTokenAgent, hostToken, nil, nil))
+	detail := &ChannelDetail{}
+	assert.NoError(t, APITestMsg(GetChannelDetail, "GET", "/content/channels/{channelID}/detail",
+		&map[string]string{"channelID": channelID}, nil, APPTokenContact, contact, detail, nil))
+	assert.Equal(t, expire, detail.Expire)
+
+	// scheduled topic hidden from member
+	now := time.Now().Unix()
+	schedule := strconv.FormatInt(now+60, 10)
+	scheduled := &Topic{}
+	subject := &Subject{Data: "scheduled", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics?confirm=true&schedule="+schedule,
+		&map[string]string{"channelID": channelID}, subject, APPTokenAgent, hostToken, scheduled, nil))
+	assert.Equal(t, APPTopicScheduled, scheduled.Data.TopicDetail.Status)
+	assert.Equal(t, now+60+expire, scheduled.Data.TopicDetail.Expires)
+	topics := []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopics, "GET", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, nil, APPTokenContact, contact, &topics, nil))
+	assert.Equal(t, 0, len(topics))
+	topics = []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopics, "GET", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, nil, APPTokenAgent, hostToken, &topics, nil))
+	assert.Equal(t, 1, len(topics))
+	thumbs := "👍"
+	assert.Error(t, APITestMsg(AddChannelTopicReaction, "POST", "/content/channels/{channelID}/topics/{topicID}/reactions",
+		&map[string]string{"channelID": channelID, "topicID": scheduled.ID}, &thumbs, APPTokenContact, contact, nil, nil))
+	assets := []Asset{}
+	assert.Error(t, APITestMsg(GetChannelTopicAssets, "GET", "/content/channels/{channelID}/topics/{topicID}/assets",
+		&map[string]string{"channelID": channelID, "topicID": scheduled.ID}, nil, APPTokenContact, contact, &assets, nil))
+
+	// only author confirms scheduled topic, which stays scheduled until publish time
+	confirmed := APPTopicConfirmed
+	assert.Error(t, APITestMsg(SetChannelTopicConfirmed, "PUT", "/content/channels/{channelID}/topics/{topicID}/confirmed",
+		&map[string]string{"channelID": channelID, "topicID": scheduled.ID}, &confirmed, APPTokenContact, contact, nil, nil))
+	confirmedTopic := &Topic{}
+	assert.NoError(t, APITestMsg(SetChannelTopicConfirmed, "PUT", "/content/channels/{channelID}/topics/{topicID}/confirmed",
+		&map[string]string{"channelID": channelID, "topicID": scheduled.ID}, &confirmed, APPTokenAgent, hostToken, confirmedTopic, nil))
+	assert.Equal(t, APPTopicScheduled, confirmedTopic.Data.TopicDetail.Status)
+
+	// topic expiring sooner than channel timer
+	expires := strconv.FormatInt(now+30, 10)
+	expiring := &Topic{}
+	subject = &Subject{Data: "expiring", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics?confirm=true&expires="+expires,
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, contact, expiring, nil))
+	assert.Equal(t, now+30, expiring.Data.TopicDetail.Expires)
+	assert.Error(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics?expires="+strconv.FormatInt(now-1, 10),
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, contact, nil, nil))
+
+	// published topics cannot be rescheduled
+	status := APPTopicScheduled
+	assert.Error(t, APITestMsg(SetChannelTopicConfirmed, "PUT", "/content/channels/{channelID}/topics/{topicID}/confirmed",
+		&map[string]string{"channelID": channelID, "topicID": expiring.ID}, &status, APPTokenContact, contact, nil, nil))
+
+	// sweep publishes scheduled topic and removes expired topic
+	sweepTopics(now + 61)
+	topics = []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopics, "GET", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, nil, APPTokenContact, contact, &topics, nil))
+	assert.Equal(t, 1, len(topics))
+	assert.Equal(t, scheduled.ID, topics[0].ID)
+	assert.Equal(t, APPTopicConfirmed, topics[0].Data.TopicDetail.Status)
+
+	// channel timer removes published topic
+	sweepTopics(now + 61 + expire)
+	topics = []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopics, "GET", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, nil, APPTokenAgent, hostToken, &topics, nil))
+	assert.Equal(t, 0, len(topics))
+}
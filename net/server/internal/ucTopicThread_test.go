@@ -0,0 +1,149 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTopicThread(t *testing.T) {
+
+	// setup host and member
+	_, hostToken, err := addTestAccount("topicthreadA")
+	assert.NoError(t, err)
+	_, memberToken, err := addTestAccount("topicthreadB")
+	assert.NoError(t, err)
+	hostCardID, memberCardID, err := connectTestCards(hostToken, memberToken)
+	assert.NoError(t, err)
+	contact, err := getCardToken(memberToken, memberCardID)
+	assert.NoError(t, err)
+
+	channelID, err := addTestChannel(hostToken)
+	assert.NoError(t, err)
+	assert.NoError(t, APITestMsg(SetChannelCard, "PUT", "/content/channels/{channelID}/cards/{cardID}",
+		&map[string]string{"channelID": channelID, "cardID": hostCardID}, nil, APPTokenAgent, hostToken, nil, nil))
+
+	// post root topic
+	root := &Topic{}
+	subject := &Subject{Data: "rootdata", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, subject, APPTokenAgent, hostToken, root, nil))
+	header := map[string][]string{}
+	topics := []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopics, "GET", "/content/channels/{channelID}/topics",
+		&map[string]string{"channelID": channelID}, nil, APPTokenAgent, hostToken, &topics, &header))
+	revision := header["Topic-Revision"][0]
+
+	// reply from member
+	reply := &Topic{}
+	subject = &Subject{Data: "replydata", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics?parent="+root.ID,
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, contact, reply, nil))
+	assert.Equal(t, root.ID, reply.Data.TopicDetail.Parent)
+
+	// replies cannot nest
+	assert.Error(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics?parent="+reply.ID,
+		&map[string]string{"channelID": channelID}, subject, APPTokenContact, contact, nil, nil))
+
+	// revision sync reports parent and reply
+	topics = []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopics, "GET", "/content/channels/{channelID}/topics?revision="+revision,
+		&map[string]string{"channelID": channelID}, nil, APPTokenAgent, hostToken, &topics, nil))
+	assert.Equal(t, 2, len(topics))
+	topics = []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopics, "GET", "/content/channels/{channelID}/topics?root=true&revision="+revision,
+		&map[string]string{"channelID": channelID}, nil, APPTokenAgent, hostToken, &topics, nil))
+	assert.Equal(t, 1, len(topics))
+	assert.Equal(t, root.ID, topics[0].ID)
+
+	// root listing carries reply count
+	topics = []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopics, "GET", "/content/channels/{channelID}/topics?root=true",
+		&map[string]string{"channelID": channelID}, nil, APPTokenAgent, hostToken, &topics, nil))
+	assert.Equal(t, 1, len(topics))
+	assert.Equal(t, int64(1), topics[0].Data.TopicDetail.ReplyCount)
+
+	// retrieve thread
+	replies := []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopicReplies, "GET", "/content/channels/{channelID}/topics/{topicID}/replies",
+		&map[string]string{"channelID": channelID, "topicID": root.ID}, nil, APPTokenContact, contact, &replies, &header))
+	assert.Equal(t, 1, len(replies))
+	assert.Equal(t, reply.ID, replies[0].ID)
+	threadRevision, _ := strconv.ParseInt(header["Topic-Revision"][0], 10, 64)
+
+	// detail endpoint carries thread fields
+	detail := &TopicDetail{}
+	assert.NoError(t, APITestMsg(GetChannelTopicDetail, "GET", "/content/channels/{channelID}/topics/{topicID}/detail",
+		&map[string]string{"channelID": channelID, "topicID": reply.ID}, nil, APPTokenAgent, hostToken, detail, nil))
+	assert.Equal(t, root.ID, detail.Parent)
+	assert.NoError(t, APITestMsg(GetChannelTopicDetail, "GET", "/content/channels/{channelID}/topics/{topicID}/detail",
+		&map[string]string{"channelID": channelID, "topicID": root.ID}, nil, APPTokenAgent, hostToken, detail, nil))
+	assert.Equal(t, int64(1), detail.ReplyCount)
+
+	// removing reply updates count and thread revision
+	assert.NoError(t, APITestMsg(RemoveChannelTopic, "DELETE", "/content/channels/{channelID}/topics/{topicID}",
+		&map[string]string{"channelID": channelID, "topicID": reply.ID}, nil, APPTokenContact, contact, nil, nil))
+	replies = []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopicReplies, "GET", "/content/channels/{channelID}/topics/{topicID}/replies?revision="+strconv.FormatInt(threadRevision, 10),
+		&map[string]string{"channelID": channelID, "topicID": root.ID}, nil, APPTokenAgent, hostToken, &replies, nil))
+	assert.Equal(t, 1, len(replies))
+	assert.Nil(t, replies[0].Data)
+	topic := &Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopic, "GET", "/content/channels/{channelID}/topics/{topicID}/detail",
+		&map[string]string{"channelID": channelID, "topicID": root.ID}, nil, APPTokenAgent, hostToken, topic, nil))
+	assert.Equal(t, int64(0), topic.Data.TopicDetail.ReplyCount)
+
+	// replies removed by retention release parent count
+	aged := &Topic{}
+	subject = &Subject{Data: "ageddata", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics?parent="+root.ID,
+		&map[string]string{"channelID": channelID}, subject, APPTokenAgent, hostToken, aged, nil))
+	assert.NoError(t, store.DB.Model(&store.Topic{}).Where("topic_slot_id = (?)",
+		store.DB.Model(&store.TopicSlot{}).Select("id").Where("topic_slot_id = ?", aged.ID)).UpdateColumn("created", 1).Error)
+	assert.NoError(t, APITestMsg(GetChannelTopic, "GET", "/content/channels/{channelID}/topics/{topicID}/detail",
+		&map[string]string{"channelID": channelID, "topicID": root.ID}, nil, APPTokenAgent, hostToken, topic, nil))
+	assert.Equal(t, int64(1), topic.Data.TopicDetail.ReplyCount)
+	detailRevision := topic.Data.DetailRevision
+	assert.Eventually(t, func() bool {
+		_, err := performCleanup(90, 180)
+		return err == nil
+	}, 5*time.Second, 100*time.Millisecond)
+	topic = &Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopic, "GET", "/content/channels/{channelID}/topics/{topicID}/detail",
+		&map[string]string{"channelID": channelID, "topicID": root.ID}, nil, APPTokenAgent, hostToken, topic, nil))
+	assert.Equal(t, int64(0), topic.Data.TopicDetail.ReplyCount)
+	assert.Greater(t, topic.Data.DetailRevision, detailRevision)
+
+	// scheduled reply hidden from member until published
+	scheduled := &Topic{}
+	subject = &Subject{Data: "scheduleddata", DataType: "topicdatatype"}
+	assert.NoError(t, APITestMsg(AddChannelTopic, "POST", "/content/channels/{channelID}/topics?confirm=true&parent="+root.ID+"&schedule="+strconv.FormatInt(time.Now().Unix()+60, 10),
+		&map[string]string{"channelID": channelID}, subject, APPTokenAgent, hostToken, scheduled, nil))
+	replies = []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopicReplies, "GET", "/content/channels/{channelID}/topics/{topicID}/replies",
+		&map[string]string{"channelID": channelID, "topicID": root.ID}, nil, APPTokenAgent, hostToken, &replies, nil))
+	assert.Equal(t, 1, len(replies))
+	replies = []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopicReplies, "GET", "/content/channels/{channelID}/topics/{topicID}/replies",
+		&map[string]string{"channelID": channelID, "topicID": root.ID}, nil, APPTokenContact, contact, &replies, nil))
+	assert.Equal(t, 0, len(replies))
+	replies = []Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopicReplies, "GET", "/content/channels/{channelID}/topics/{topicID}/replies?revision="+strconv.FormatInt(threadRevision, 10),
+		&map[string]string{"channelID": channelID, "topicID": root.ID}, nil, APPTokenContact, contact, &replies, nil))
+	for _, reply := range replies {
+		assert.NotEqual(t, scheduled.ID, reply.ID)
+	}
+
+	// scheduled reply counted once published
+	topic = &Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopic, "GET", "/content/channels/{channelID}/topics/{topicID}/detail",
+		&map[string]string{"channelID": channelID, "topicID": root.ID}, nil, APPTokenContact, contact, topic, nil))
+	assert.Equal(t, int64(0), topic.Data.TopicDetail.ReplyCount)
+	sweepTopics(time.Now().Unix() + 120)
+	topic = &Topic{}
+	assert.NoError(t, APITestMsg(GetChannelTopic, "GET", "/content/channels/{channelID}/topics/{topicID}/detail",
+		&map[string]string{"channelID": channelID, "topicID": root.ID}, nil, APPTokenContact, contact, topic, nil))
+	assert.Equal(t, int64(1), topic.Data.TopicDetail.ReplyCount)
+}
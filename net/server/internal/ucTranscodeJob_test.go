@@ -0,0 +1,79 @@
+package databag
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTranscodeJob(t *testing.T) {
+
+	// transform reporting ffmpeg style progress then stalling
+	script := []byte("#!/bin/sh\necho '  Duration: 00:00:10.00, start: 0.000000' >&2\necho 'frame=12 time=00:00:05.00 bitrate=1.0kbits/s' >&2\nsleep 60\n")
+	assert.NoError(t, os.WriteFile("testscripts/transform_stall.sh", script, 0555))
+	t.Cleanup(func() { os.Remove("testscripts/transform_stall.sh") })
+
+	_, token, err := addTestAccount("transcodejob")
+	assert.NoError(t, err)
+	channelID, err := addTestChannel(token)
+	assert.NoError(t, err)
+	var params map[string]string
+
+	stall := func() string {
+		params, err = addTestTopic(token, channelID, "video")
+		assert.NoError(t, err)
+		transforms, err := json.Marshal([]string{"stall;video"})
+		assert.NoError(t, err)
+		assets := []Asset{}
+		assert.NoError(t, APITestUpload(AddChannelTopicAsset, "POST", "/content/channels/{channelID}/topics/{topicID}/assets?transforms="+url.QueryEscape(string(transforms)),
+			&params, []byte("video"), APPTokenAgent, token, &assets, nil))
+		assert.Equal(t, 2, len(assets))
+		return assets[1].AssetID
+	}
+	getJob := func(assetID string) *TranscodeJob {
+		jobs := []TranscodeJob{}
+		assert.NoError(t, APITestMsg(GetTranscodeJobs, "GET", "/admin/transcode?token=pass",
+			nil, nil, "", "", &jobs, nil))
+		for _, job := range jobs {
+			if job.AssetID == assetID {
+				return &job
+			}
+		}
+		return nil
+	}
+
+	// running job reports progress
+	assetID := stall()
+	assert.Eventually(t, func() bool {
+		job := getJob(assetID)
+		return job != nil && job.Status == APPAssetProcessing && job.Progress == 50
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// admin cancel leaves failed job
+	assert.NoError(t, APITestMsg(CancelTranscodeJob, "DELETE", "/admin/transcode/{assetID}?token=pass",
+		&map[string]string{"assetID": assetID}, nil, "", "", nil, nil))
+	assert.Eventually(t, func() bool {
+		job := getJob(assetID)
+		return job != nil && job.Status == APPAssetError && job.Error == "transform cancelled"
+	}, 5*time.Second, 100*time.Millisecond)
+	assert.Error(t, APITestMsg(CancelTranscodeJob, "DELETE", "/admin/transcode/{assetID}?token=pass",
+		&map[string]string{"assetID": assetID}, nil, "", "", nil, nil))
+
+	// removing topic stops job
+	assetID = stall()
+	assert.Eventually(t, func() bool {
+		job := getJob(assetID)
+		return job != nil && job.Status == APPAssetProcessing
+	}, 5*time.Second, 100*time.Millisecond)
+	assert.NoError(t, APITestMsg(RemoveChannelTopic, "DELETE", "/content/channels/{channelID}/topics/{topicID}",
+		&params, nil, APPTokenAgent, token, nil, nil))
+	assert.Eventually(t, func() bool {
+		transcodeJobSync.Lock()
+		defer transcodeJobSync.Unlock()
+		return len(transcodeJobs) == 0
+	}, 5*time.Second, 100*time.Millisecond)
+	assert.Nil(t, getJob(assetID))
+}
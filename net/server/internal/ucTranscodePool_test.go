@@ -0,0 +1,89 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/clause"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTranscodePool(t *testing.T) {
+
+	script := []byte("#!/bin/sh\nsleep 60\n")
+	assert.NoError(t, os.WriteFile("testscripts/transform_hold.sh", script, 0555))
+	t.Cleanup(func() { os.Remove("testscripts/transform_hold.sh") })
+	setWorkers := func(count int64) {
+		assert.NoError(t, store.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"num_value"}),
+		}).Create(&store.Config{ConfigID: CNFVideoWorkers, NumValue: count}).Error)
+	}
+	defer setWorkers(APPTranscodeVideoWorkers)
+
+	_, token, params, err := addTestChannelTopic("transcodepool", "video")
+	assert.NoError(t, err)
+
+	upload := func(size int) string {
+		transforms, err := json.Marshal([]string{"hold;video"})
+		assert.NoError(t, err)
+		assets := []Asset{}
+		assert.NoError(t, APITestUpload(AddChannelTopicAsset, "POST", "/content/channels/{channelID}/topics/{topicID}/assets?transforms="+url.QueryEscape(string(transforms)),
+			&params, make([]byte, size), APPTokenAgent, token, &assets, nil))
+		return assets[1].AssetID
+	}
+	status := func(assetID string) string {
+		var asset store.Asset
+		assert.NoError(t, store.DB.Where("asset_id = ?", assetID).First(&asset).Error)
+		return asset.Status
+	}
+	cancel := func(assetID string) {
+		assert.NoError(t, APITestMsg(CancelTranscodeJob, "DELETE", "/admin/transcode/{assetID}?token=pass",
+			&map[string]string{"assetID": assetID}, nil, "", "", nil, nil))
+	}
+
+	// concurrency follows config
+	setWorkers(2)
+	first := upload(64)
+	second := upload(64)
+	assert.Eventually(t, func() bool {
+		return status(first) == APPAssetProcessing && status(second) == APPAssetProcessing
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// running transforms leave topic incomplete
+	detail := &TopicDetail{}
+	assert.NoError(t, APITestMsg(GetChannelTopicDetail, "GET", "/content/channels/{channelID}/topics/{topicID}/detail",
+		&params, nil, APPTokenAgent, token, detail, nil))
+	assert.Equal(t, APPTransformIncomplete, detail.Transform)
+	cancel(first)
+	cancel(second)
+
+	// small assets run ahead of earlier larger ones
+	setWorkers(1)
+	running := upload(64)
+	assert.Eventually(t, func() bool { return status(running) == APPAssetProcessing }, 5*time.Second, 100*time.Millisecond)
+	large := upload(2048)
+	small := upload(16)
+	assert.Equal(t, APPAssetWaiting, status(large))
+	cancel(running)
+	assert.Eventually(t, func() bool { return status(small) == APPAssetProcessing }, 5*time.Second, 100*time.Millisecond)
+	assert.Equal(t, APPAssetWaiting, status(large))
+	cancel(small)
+	assert.Eventually(t, func() bool { return status(large) == APPAssetProcessing }, 5*time.Second, 100*time.Millisecond)
+	cancel(large)
+	assert.Eventually(t, func() bool { return status(large) == APPAssetError }, 5*time.Second, 100*time.Millisecond)
+
+	// interrupted jobs are requeued until attempts are exhausted
+	assert.NoError(t, store.DB.Model(&store.Asset{}).Where("asset_id = ?", first).
+		Updates(map[string]interface{}{"status": APPAssetProcessing, "transform_attempts": APPTranscodeMaxAttempts}).Error)
+	assert.NoError(t, store.DB.Model(&store.Asset{}).Where("asset_id = ?", second).
+		Updates(map[string]interface{}{"status": APPAssetProcessing, "transform_attempts": 1}).Error)
+	recoverTranscode()
+	assert.Equal(t, APPAssetError, status(first))
+	assert.Equal(t, APPAssetWaiting, status(second))
+	assert.NoError(t, APITestMsg(RemoveChannelTopic, "DELETE", "/content/channels/{channelID}/topics/{topicID}",
+		&params, nil, APPTokenAgent, token, nil, nil))
+}
@@ -0,0 +1,148 @@
+package databag
+
+import (
+	"bytes"
+	"databag/internal/store"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestTranscodeWorker(t *testing.T) {
+
+	setRemote := func(remote bool) {
+		assert.NoError(t, store.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"bool_value"}),
+		}).Create(&store.Config{ConfigID: CNFRemoteTranscode, BoolValue: remote}).Error)
+	}
+	setRemote(true)
+	defer setRemote(false)
+
+	// register worker for default queue
+	assert.Error(t, APITestMsg(AddTranscodeWorker, "POST", "/admin/workers?token=pass",
+		nil, &TranscodeWorkerParams{Name: "lan", Queues: []string{"unknown"}}, "", "", nil, nil))
+	worker := &TranscodeWorker{}
+	assert.NoError(t, APITestMsg(AddTranscodeWorker, "POST", "/admin/workers?token=pass",
+		nil, &TranscodeWorkerParams{Name: "lan", Queues: []string{APPWorkerDefaultQueue}}, "", "", worker, nil))
+	assert.NotEmpty(t, worker.Token)
+	workers := []TranscodeWorker{}
+	assert.NoError(t, APITestMsg(GetTranscodeWorkers, "GET", "/admin/workers?token=pass",
+		nil, nil, "", "", &workers, nil))
+	assert.Equal(t, 1, len(workers))
+	assert.Empty(t, workers[0].Token)
+	auth := "?worker=" + worker.Token
+
+	_, token, params, err := addTestChannelTopic("transcodeworker", "remote")
+	assert.NoError(t, err)
+	upload := func(data string) string {
+		transforms, err := json.Marshal([]string{"rcopy;remote"})
+		assert.NoError(t, err)
+		assets := []Asset{}
+		assert.NoError(t, APITestUpload(AddChannelTopicAsset, "POST", "/content/channels/{channelID}/topics/{topicID}/assets?transforms="+url.QueryEscape(string(transforms)),
+			&params, []byte(data), APPTokenAgent, token, &assets, nil))
+		return assets[1].AssetID
+	}
+	lease := func() *TranscodeLease {
+		var lease *TranscodeLease
+		assert.NoError(t, APITestMsg(AddTranscodeLease, "POST", "/transcode/leases"+auth,
+			nil, nil, "", "", &lease, nil))
+		return lease
+	}
+	getAsset := func(assetID string) *store.Asset {
+		asset := &store.Asset{}
+		assert.NoError(t, store.DB.Where("asset_id = ?", assetID).First(asset).Error)
+		return asset
+	}
+
+	// fail assets other tests left waiting so worker only sees its own
+	for pending := lease(); pending != nil; pending = lease() {
+		assert.NoError(t, APITestMsg(RemoveTranscodeLease, "DELETE", "/transcode/leases/{leaseID}"+auth+"&error=drained",
+			&map[string]string{"leaseID": pending.LeaseID}, nil, "", "", nil, nil))
+	}
+
+	// lease source and report progress
+	assetID := upload("remote source")
+	leased := lease()
+	assert.NotNil(t, leased)
+	assert.Equal(t, "rcopy", leased.Transform)
+	assert.Nil(t, lease())
+	data, _, err := APITestData(GetTranscodeLeaseSource, "GET", "/transcode/leases/{leaseID}/source"+auth,
+		&map[string]string{"leaseID": leased.LeaseID}, nil, "", "", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "remote source", string(data))
+	assert.NoError(t, APITestMsg(SetTranscodeLeaseProgress, "PUT", "/transcode/leases/{leaseID}/progress"+auth,
+		&map[string]string{"leaseID": leased.LeaseID}, 40, "", "", nil, nil))
+	jobs := []TranscodeJob{}
+	assert.NoError(t, APITestMsg(GetTranscodeJobs, "GET", "/admin/transcode?token=pass",
+		nil, nil, "", "", &jobs, nil))
+	found := false
+	for _, job := range jobs {
+		if job.AssetID == assetID {
+			found = true
+			assert.Equal(t, 40, job.Progress)
+			assert.Equal(t, worker.ID, job.Worker)
+		}
+	}
+	assert.True(t, found)
+
+	// upload output completes asset
+	r := httptest.NewRequest("PUT", "/transcode/leases/"+leased.LeaseID+"/output"+auth, bytes.NewBufferString("remote output"))
+	r = mux.SetURLVars(r, map[string]string{"leaseID": leased.LeaseID})
+	w := httptest.NewRecorder()
+	SetTranscodeLeaseOutput(w, r)
+	assert.Equal(t, 200, w.Code)
+	asset := getAsset(assetID)
+	assert.Equal(t, APPAssetReady, asset.Status)
+	assert.Equal(t, int64(len("remote output")), asset.Size)
+	assert.Empty(t, asset.TransformLease)
+
+	// worker reports failure
+	assetID = upload("broken source")
+	leased = lease()
+	assert.NoError(t, APITestMsg(RemoveTranscodeLease, "DELETE", "/transcode/leases/{leaseID}"+auth+"&error=unsupported",
+		&map[string]string{"leaseID": leased.LeaseID}, nil, "", "", nil, nil))
+	asset = getAsset(assetID)
+	assert.Equal(t, APPAssetError, asset.Status)
+	assert.Equal(t, "unsupported", asset.TransformError)
+
+	// output limited by remaining storage
+	assetID = upload("quota source")
+	leased = lease()
+	assert.NoError(t, store.DB.Model(&store.Account{}).Where("username = ?", "transcodeworker").
+		Update("storage_quota", gorm.Expr("storage_used + ?", 8)).Error)
+	r = httptest.NewRequest("PUT", "/transcode/leases/"+leased.LeaseID+"/output"+auth, bytes.NewBufferString("oversized remote output"))
+	r = mux.SetURLVars(r, map[string]string{"leaseID": leased.LeaseID})
+	w = httptest.NewRecorder()
+	SetTranscodeLeaseOutput(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	asset = getAsset(assetID)
+	assert.Equal(t, APPAssetError, asset.Status)
+	assert.Equal(t, "storage quota exceeded", asset.TransformError)
+	assert.NoError(t, store.DB.Model(&store.Account{}).Where("username = ?", "transcodeworker").Update("storage_quota", 0).Error)
+
+	// expired lease is leased again
+	assetID = upload("slow source")
+	expired := lease()
+	assert.NoError(t, store.DB.Model(&store.Asset{}).Where("asset_id = ?", assetID).Update("transform_expires", 1).Error)
+	leased = lease()
+	assert.NotNil(t, leased)
+	assert.NotEqual(t, expired.LeaseID, leased.LeaseID)
+	assert.Error(t, APITestMsg(SetTranscodeLeaseProgress, "PUT", "/transcode/leases/{leaseID}/progress"+auth,
+		&map[string]string{"leaseID": expired.LeaseID}, 10, "", "", nil, nil))
+
+	// removing worker requeues its leases
+	assert.NoError(t, APITestMsg(RemoveTranscodeWorker, "DELETE", "/admin/workers/{workerID}?token=pass",
+		&map[string]string{"workerID": worker.ID}, nil, "", "", nil, nil))
+	assert.Equal(t, APPAssetWaiting, getAsset(assetID).Status)
+	assert.Error(t, APITestMsg(AddTranscodeLease, "POST", "/transcode/leases"+auth,
+		nil, nil, "", "", nil, nil))
+	assert.NoError(t, APITestMsg(RemoveChannelTopic, "DELETE", "/content/channels/{channelID}/topics/{topicID}",
+		&params, nil, APPTokenAgent, token, nil, nil))
+}
@@ -0,0 +1,340 @@
+package databag
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"databag/internal/store"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type webhookEvent struct {
+	accountID uint
+	guid      string
+	event     string
+	data      interface{}
+}
+
+var webhookEvents = make(chan webhookEvent, APPWebhookBuffer)
+var webhookQueue = make(chan uint, APPWebhookBuffer)
+var webhookExit = make(chan bool)
+var webhookSync sync.Mutex
+var webhookActive = make(map[uint]bool)
+var webhookClient *http.Client
+var webhookClientSync sync.Mutex
+
+// ExitWebhooks stop delivering webhook events
+func ExitWebhooks() {
+	webhookExit <- true
+}
+
+// SendWebhooks records webhook events and delivers them with a pool of workers, retrying failed deliveries
+func SendWebhooks() {
+
+	// deliveries are independent so a slow endpoint only occupies one worker
+	done := make(chan bool)
+	defer close(done)
+	for i := 0; i < APPWebhookWorkers; i++ {
+		go func() {
+			for {
+				select {
+				case id := <-webhookQueue:
+					deliverWebhook(id)
+					webhookSync.Lock()
+					delete(webhookActive, id)
+					webhookSync.Unlock()
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	// queue all pending deliveries
+	retryWebhooks()
+
+	ticker := time.NewTicker(APPWebhookRetryInterval * time.Second)
+	defer ticker.Stop()
+
+	// record events until exit
+	for {
+		select {
+		case event := <-webhookEvents:
+			recordWebhookEvent(&event)
+		case <-ticker.C:
+			retryWebhooks()
+		case <-webhookExit:
+			return
+		}
+	}
+}
+
+// SetWebhookEvent queues event for subscribed webhooks of the account without blocking the caller
+func SetWebhookEvent(account *store.Account, event string, data interface{}) {
+	select {
+	case webhookEvents <- webhookEvent{accountID: account.ID, guid: account.GUID, event: event, data: data}:
+	default:
+		LogMsg("webhook event dropped for " + account.GUID)
+	}
+}
+
+func recordWebhookEvent(hook *webhookEvent) {
+
+	var webhooks []store.Webhook
+	if err := store.DB.Where("account_id = ?", hook.accountID).Find(&webhooks).Error; err != nil {
+		ErrMsg(err)
+		return
+	}
+	event := hook.event
+
+	for _, webhook := range webhooks {
+		if !matchWebhookEvent(webhook.Events, event) {
+			continue
+		}
+
+		payload := &WebhookEvent{
+			ID:        uuid.New().String(),
+			Event:     event,
+			GUID:      hook.guid,
+			Timestamp: time.Now().Unix(),
+			Data:      hook.data,
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			ErrMsg(err)
+			continue
+		}
+
+		delivery := &store.WebhookDelivery{
+			DeliveryID: payload.ID,
+			WebhookID:  webhook.ID,
+			AccountID:  hook.accountID,
+			Event:      event,
+			Payload:    string(body),
+			Status:     APPWebhookPending,
+		}
+		if err := store.DB.Save(delivery).Error; err != nil {
+			ErrMsg(err)
+			continue
+		}
+
+		queueWebhook(delivery.ID)
+	}
+}
+
+// queueWebhook hands delivery to a worker, retry scan picks it up if queue is full
+func queueWebhook(id uint) {
+	webhookSync.Lock()
+	defer webhookSync.Unlock()
+	if webhookActive[id] {
+		return
+	}
+	select {
+	case webhookQueue <- id:
+		webhookActive[id] = true
+	default:
+	}
+}
+
+func retryWebhooks() {
+
+	// drop expired delivery log
+	expire := time.Now().Unix() - APPWebhookLogExpire
+	if err := store.DB.Where("created < ? AND status != ?", expire, APPWebhookPending).Delete(&store.WebhookDelivery{}).Error; err != nil {
+		ErrMsg(err)
+	}
+
+	var deliveries []store.WebhookDelivery
+	if err := store.DB.Where("status = ? AND next_attempt <= ?", APPWebhookPending, time.Now().Unix()).Find(&deliveries).Error; err != nil {
+		ErrMsg(err)
+		return
+	}
+	for _, delivery := range deliveries {
+		queueWebhook(delivery.ID)
+	}
+}
+
+func deliverWebhook(id uint) {
+
+	var delivery store.WebhookDelivery
+	if err := store.DB.Preload("Webhook").Where("id = ?", id).First(&delivery).Error; err != nil {
+		ErrMsg(err)
+		return
+	}
+	if delivery.Status != APPWebhookPending || delivery.NextAttempt > time.Now().Unix() {
+		return
+	}
+	if delivery.Webhook == nil || delivery.Webhook.ID == 0 {
+		delivery.Status = APPWebhookFailed
+		delivery.Error = "webhook removed"
+		if err := store.DB.Save(&delivery).Error; err != nil {
+			ErrMsg(err)
+		}
+		return
+	}
+
+	code, err := postWebhook(delivery.Webhook, &delivery)
+	delivery.Attempts += 1
+	delivery.ResponseCode = code
+	if err == nil {
+		delivery.Status = APPWebhookDelivered
+		delivery.Error = ""
+		delivery.NextAttempt = 0
+	} else {
+		delivery.Error = err.Error()
+		if delivery.Attempts >= APPWebhookMaxAttempts {
+			delivery.Status = APPWebhookFailed
+			delivery.NextAttempt = 0
+		} else {
+			delivery.NextAttempt = time.Now().Unix() + (APPWebhookRetryBase << (delivery.Attempts - 1))
+		}
+	}
+	if err := store.DB.Save(&delivery).Error; err != nil {
+		ErrMsg(err)
+	}
+}
+
+func postWebhook(webhook *store.Webhook, delivery *store.WebhookDelivery) (int, error) {
+
+	// endpoint is checked again as its address may have changed since subscribing
+	endpoint, err := url.Parse(webhook.URL)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkWebhookURL(endpoint); err != nil {
+		return 0, err
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewBuffer(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("X-Databag-Event", delivery.Event)
+	req.Header.Set("X-Databag-Delivery", delivery.DeliveryID)
+	req.Header.Set("X-Databag-Timestamp", timestamp)
+	req.Header.Set("X-Databag-Signature", "sha256="+signWebhookPayload(webhook.Secret, timestamp, body))
+
+	ctx, cancel := context.WithTimeout(context.Background(), APPWebhookTimeout*time.Second)
+	defer cancel()
+	req = req.WithContext(ctx)
+	resp, err := getWebhookClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, errors.New("webhook responded with " + resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// checkWebhookURL rejects plain http and hosts resolving to non public addresses unless node allows them
+func checkWebhookURL(endpoint *url.URL) error {
+	if getBoolConfigValue(CNFWebhookPrivate, false) {
+		return nil
+	}
+	if endpoint.Scheme != "https" {
+		return errors.New("webhook url must use https")
+	}
+	addrs, err := net.LookupIP(endpoint.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !isPublicAddress(addr) {
+			return errors.New("webhook url must resolve to a public address")
+		}
+	}
+	return nil
+}
+
+func isPublicAddress(addr net.IP) bool {
+	return !(addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsUnspecified() || addr.IsMulticast() || addr.IsInterfaceLocalMulticast())
+}
+
+// getWebhookClient returns client that only connects to checked addresses and does not follow redirects
+func getWebhookClient() *http.Client {
+	webhookClientSync.Lock()
+	defer webhookClientSync.Unlock()
+	if webhookClient == nil {
+
+		// proxies would connect on our behalf without the address check
+		transport := getFederationTransport()
+		transport.Proxy = nil
+		transport.DialContext = dialWebhook
+		webhookClient = &http.Client{
+			Transport: transport,
+			Timeout:   APPWebhookTimeout * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return webhookClient
+}
+
+// dialWebhook checks the addresses actually dialed so the name cannot be rebound after checkWebhookURL
+func dialWebhook(ctx context.Context, network string, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	private := getBoolConfigValue(CNFWebhookPrivate, false)
+	dialer := &net.Dialer{Timeout: APPWebhookTimeout * time.Second}
+	err = errors.New("webhook url must resolve to a public address")
+	for _, addr := range addrs {
+		if !private && !isPublicAddress(addr.IP) {
+			continue
+		}
+		conn, res := dialer.DialContext(ctx, network, net.JoinHostPort(addr.IP.String(), port))
+		if res == nil {
+			return conn, nil
+		}
+		err = res
+	}
+	return nil, err
+}
+
+// signWebhookPayload computes hex encoded hmac-sha256 of timestamp and body
+func signWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// matchWebhookEvent compares event with comma separated filter, empty filter matches all
+func matchWebhookEvent(filter string, event string) bool {
+	if filter == "" {
+		return true
+	}
+	for _, pattern := range strings.Split(filter, ",") {
+		if pattern == "*" || pattern == event {
+			return true
+		}
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(event, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
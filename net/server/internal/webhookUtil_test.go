@@ -0,0 +1,138 @@
+package databag
+
+import (
+	"databag/internal/store"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/clause"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestWebhookEventFilter(t *testing.T) {
+	assert.True(t, matchWebhookEvent("", APPWebhookRevision))
+	assert.True(t, matchWebhookEvent("*", APPWebhookRing))
+	assert.True(t, matchWebhookEvent("status.ring,push.*", APPWebhookPush+"contact.addCard"))
+	assert.True(t, matchWebhookEvent("push.content.*", APPWebhookPush+"content.addChannelTopic.superbasic"))
+	assert.False(t, matchWebhookEvent("push.content.*", APPWebhookPush+"contact.addCard"))
+	assert.False(t, matchWebhookEvent("status.ring", APPWebhookRevision))
+}
+
+func TestWebhookURL(t *testing.T) {
+	for _, target := range []string{"http://example.com/hook", "https://127.0.0.1/hook", "https://10.1.2.3/hook", "https://169.254.169.254/latest", "https://[::1]/hook"} {
+		endpoint, err := url.Parse(target)
+		assert.NoError(t, err)
+		assert.Error(t, checkWebhookURL(endpoint), target)
+	}
+	endpoint, err := url.Parse("https://93.184.215.14/hook")
+	assert.NoError(t, err)
+	assert.NoError(t, checkWebhookURL(endpoint))
+}
+
+func TestWebhookDelivery(t *testing.T) {
+
+	type received struct {
+		event     string
+		timestamp string
+		signature string
+		body      []byte
+	}
+	requests := make(chan received, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- received{
+			event:     r.Header.Get("X-Databag-Event"),
+			timestamp: r.Header.Get("X-Databag-Timestamp"),
+			signature: r.Header.Get("X-Databag-Signature"),
+			body:      body,
+		}
+	}))
+	defer server.Close()
+
+	go SendWebhooks()
+	defer ExitWebhooks()
+
+	_, token, err := addTestAccount("webhookA")
+	assert.NoError(t, err)
+
+	// local endpoints only accepted once node allows them
+	params := &WebhookParams{URL: server.URL, Events: []string{APPWebhookRevision}}
+	assert.Error(t, APITestMsg(AddAccountWebhook, "POST", "/account/webhooks", nil, params,
+		APPTokenAgent, token, nil, nil))
+	setPrivate := func(allow bool) {
+		assert.NoError(t, store.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"bool_value"}),
+		}).Create(&store.Config{ConfigID: CNFWebhookPrivate, BoolValue: allow}).Error)
+	}
+	setPrivate(true)
+	defer setPrivate(false)
+
+	// subscribe to revision events
+	webhook := &Webhook{}
+	assert.NoError(t, APITestMsg(AddAccountWebhook, "POST", "/account/webhooks", nil, params,
+		APPTokenAgent, token, webhook, nil))
+	assert.NotEmpty(t, webhook.Secret)
+
+	// trigger revision change
+	subject := &Subject{Data: "webhookgroup", DataType: "imagroup"}
+	assert.NoError(t, APITestMsg(AddGroup, "POST", "/alias/groups", nil, subject,
+		APPTokenAgent, token, nil, nil))
+
+	select {
+	case req := <-requests:
+		assert.Equal(t, APPWebhookRevision, req.event)
+		assert.Equal(t, "sha256="+signWebhookPayload(webhook.Secret, req.timestamp, req.body), req.signature)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "webhook not delivered")
+	}
+
+	// check delivery log
+	var deliveries []WebhookDelivery
+	assert.Eventually(t, func() bool {
+		deliveries = []WebhookDelivery{}
+		err := APITestMsg(GetAccountWebhookDeliveries, "GET", "/account/webhooks/{webhookID}/deliveries",
+			&map[string]string{"webhookID": webhook.ID}, nil, APPTokenAgent, token, &deliveries, nil)
+		return err == nil && len(deliveries) > 0 && deliveries[0].Status == APPWebhookDelivered
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// remove subscription
+	assert.NoError(t, APITestMsg(RemoveAccountWebhook, "DELETE", "/account/webhooks/{webhookID}",
+		&map[string]string{"webhookID": webhook.ID}, nil, APPTokenAgent, token, nil, nil))
+	webhooks := []Webhook{}
+	assert.NoError(t, APITestMsg(GetAccountWebhooks, "GET", "/account/webhooks", nil, nil,
+		APPTokenAgent, token, &webhooks, nil))
+	assert.Equal(t, 0, len(webhooks))
+}
+
+func TestWebhookClient(t *testing.T) {
+
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest", http.StatusFound)
+	}))
+	defer redirect.Close()
+	setPrivate := func(allow bool) {
+		assert.NoError(t, store.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "config_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"bool_value"}),
+		}).Create(&store.Config{ConfigID: CNFWebhookPrivate, BoolValue: allow}).Error)
+	}
+
+	// local address refused when dialing regardless of earlier checks
+	setPrivate(false)
+	_, err := getWebhookClient().Get(redirect.URL)
+	assert.Error(t, err)
+
+	// redirects returned instead of followed
+	setPrivate(true)
+	defer setPrivate(false)
+	resp, err := getWebhookClient().Get(redirect.URL)
+	assert.NoError(t, err)
+	if err == nil {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+	}
+}
@@ -0,0 +1,5 @@
+#!/bin/sh
+result=$(clamscan --no-summary --stdout $1)
+code=$?
+echo "$result" | sed -n 's/^.*: \(.*\) FOUND$/\1/p'
+exit $code
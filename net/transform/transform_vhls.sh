@@ -0,0 +1,23 @@
+#!/bin/sh
+# adaptive stream of lq, sd and hd renditions, segments are written beside the asset in $2.hls
+dir=$2.hls
+rm -rf $dir
+mkdir -p $dir || exit 1
+if ffprobe -v error -select_streams a -show_entries stream=index -of csv=p=0 $1 | grep -q .; then
+  audio="-map 0:a:0 -map 0:a:0 -map 0:a:0 -c:a aac -b:a 96k -ac 2"
+  streams="v:0,a:0,name:lq v:1,a:1,name:sd v:2,a:2,name:hd"
+else
+  audio=""
+  streams="v:0,name:lq v:1,name:sd v:2,name:hd"
+fi
+ffmpeg -i $1 -y -map_metadata -1 \
+  -filter_complex "[0:v]split=3[lq][sd][hd];[lq]scale=320:-2[vlq];[sd]scale=640:-2[vsd];[hd]scale=720:-2[vhd]" \
+  -map "[vlq]" -map "[vsd]" -map "[vhd]" $audio \
+  -c:v libx264 -preset veryfast -g 48 -sc_threshold 0 \
+  -crf:v:0 32 -maxrate:v:0 400k -bufsize:v:0 800k \
+  -crf:v:1 28 -maxrate:v:1 1200k -bufsize:v:1 2400k \
+  -crf:v:2 23 -maxrate:v:2 2500k -bufsize:v:2 5000k \
+  -f hls -hls_time 6 -hls_playlist_type vod -hls_flags independent_segments \
+  -hls_segment_filename "$dir/%v_%03d.ts" -master_pl_name index.m3u8 \
+  -var_stream_map "$streams" "$dir/%v.m3u8" || exit 1
+cp $dir/index.m3u8 $2
@@ -202,6 +202,8 @@ export function Dashboard() {
                   value={state.keyType} onChange={(o) => actions.setKeyType(o.value)}>
                 <Select.Option value="RSA2048">RSA 2048</Select.Option>
                 <Select.Option value="RSA4096">RSA 4096</Select.Option>
+                <Select.Option value="ED25519">Ed25519</Select.Option>
+                <Select.Option value="P256">ECDSA P-256</Select.Option>
               </Select>
             </div>
             <div className="field">
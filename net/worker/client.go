@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// ErrLeaseLost indicates the node revoked or expired the lease
+var ErrLeaseLost = errors.New("lease lost")
+
+// Lease asset transform leased from node
+type Lease struct {
+	LeaseID string `json:"leaseId"`
+
+	Transform string `json:"transform"`
+
+	Params string `json:"params,omitempty"`
+
+	Queue string `json:"queue"`
+
+	Expires int64 `json:"expires"`
+}
+
+// Client speaks the node transcode lease protocol
+type Client struct {
+	Server string
+	Token  string
+	HTTP   http.Client
+}
+
+func (c *Client) request(ctx context.Context, method string, path string, query url.Values, body io.Reader, size int64) (*http.Response, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("worker", c.Token)
+	req, err := http.NewRequestWithContext(ctx, method, c.Server+path+"?"+query.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrLeaseLost
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	return resp, nil
+}
+
+// Lease requests next waiting transform, nil when none are waiting
+func (c *Client) Lease() (*Lease, error) {
+	resp, err := c.request(context.Background(), http.MethodPost, "/transcode/leases", nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var lease *Lease
+	if err := json.NewDecoder(resp.Body).Decode(&lease); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// Source downloads source asset of lease into path
+func (c *Client) Source(ctx context.Context, lease *Lease, path string) error {
+	resp, err := c.request(ctx, http.MethodGet, "/transcode/leases/"+lease.LeaseID+"/source", nil, nil, -1)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Progress reports percent complete and renews lease
+func (c *Client) Progress(lease *Lease, percent int) error {
+	body, _ := json.Marshal(percent)
+	resp, err := c.request(context.Background(), http.MethodPut, "/transcode/leases/"+lease.LeaseID+"/progress", nil, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Output uploads transform result completing the lease
+func (c *Client) Output(ctx context.Context, lease *Lease, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	resp, err := c.request(ctx, http.MethodPut, "/transcode/leases/"+lease.LeaseID+"/output", nil, file, info.Size())
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Fail reports transform failure to node
+func (c *Client) Fail(lease *Lease, reason string) error {
+	query := url.Values{}
+	query.Set("error", reason)
+	resp, err := c.request(context.Background(), http.MethodDelete, "/transcode/leases/"+lease.LeaseID, query, nil, 0)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+func main() {
+	server := ""
+	token := ""
+	scripts := "/opt/databag/transform"
+	interval := 10
+	workers := 1
+
+	args := os.Args[1:]
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == "-s" {
+			server = strings.TrimSuffix(args[i+1], "/")
+		} else if args[i] == "-k" {
+			token = args[i+1]
+		} else if args[i] == "-t" {
+			scripts = args[i+1]
+		} else if args[i] == "-i" {
+			interval, _ = strconv.Atoi(args[i+1])
+		} else if args[i] == "-c" {
+			workers, _ = strconv.Atoi(args[i+1])
+		}
+	}
+	if token == "" {
+		token = os.Getenv("DATABAG_WORKER_TOKEN")
+	}
+	if server == "" || token == "" {
+		log.Fatal("usage: worker -s <node url> -k <worker token> [-t <script path>] [-i <poll seconds>] [-c <concurrent jobs>]")
+	}
+	if interval <= 0 {
+		interval = 10
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	log.Printf("using args: -s %s -t %s -i %d -c %d", server, scripts, interval, workers)
+	client := &Client{Server: server, Token: token}
+	var wait sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			Run(client, scripts, interval)
+		}()
+	}
+	wait.Wait()
+}
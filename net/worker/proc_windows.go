@@ -0,0 +1,9 @@
+package main
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup leaves default cancel which only stops the script process
+func setProcessGroup(cmd *exec.Cmd) {
+}
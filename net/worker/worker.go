@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// heartbeat between progress reports, well within node lease timeout
+var heartbeat = 30 * time.Second
+
+var transformName = regexp.MustCompile("^[a-zA-Z0-9_]*$")
+var durationPattern = regexp.MustCompile(`Duration: (\d+):(\d+):(\d+(?:\.\d+)?)`)
+var timePattern = regexp.MustCompile(`time=(\d+):(\d+):(\d+(?:\.\d+)?)`)
+
+// Run leases and processes transforms until the process exits
+func Run(client *Client, scripts string, interval int) {
+	for {
+		lease, err := client.Lease()
+		if err != nil {
+			log.Printf("lease: %v", err)
+		} else if lease != nil {
+			if err := process(client, scripts, lease); err != nil {
+				log.Printf("transform %s: %v", lease.LeaseID, err)
+			}
+			continue
+		}
+		time.Sleep(time.Duration(interval) * time.Second)
+	}
+}
+
+func process(client *Client, scripts string, lease *Lease) error {
+
+	if !transformName.MatchString(lease.Transform) {
+		return client.Fail(lease, "invalid transform")
+	}
+
+	dir, err := os.MkdirTemp("", "databag-worker-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	progress := &progress{}
+
+	// renew lease through download, script and upload, stopping if node revokes it
+	lost := make(chan bool, 1)
+	var wait sync.WaitGroup
+	wait.Add(1)
+	go func() {
+		defer wait.Done()
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := client.Progress(lease, progress.get()); errors.Is(err, ErrLeaseLost) {
+					lost <- true
+					cancel()
+					return
+				} else if err != nil {
+					log.Printf("progress %s: %v", lease.LeaseID, err)
+				}
+			}
+		}
+	}()
+
+	err = transform(ctx, client, scripts, lease, dir, progress)
+	cancel()
+	wait.Wait()
+
+	if err != nil {
+		select {
+		case <-lost:
+			return ErrLeaseLost
+		default:
+		}
+	}
+	return err
+}
+
+func transform(ctx context.Context, client *Client, scripts string, lease *Lease, dir string, progress *progress) error {
+
+	input := filepath.Join(dir, "input")
+	result := filepath.Join(dir, "output")
+	if err := client.Source(ctx, lease, input); err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, filepath.Join(scripts, "transform_"+lease.Transform+".sh"), input, result, lease.Params)
+	setProcessGroup(cmd)
+	cmd.WaitDelay = 5 * time.Second
+	var output bytes.Buffer
+	progress.writer = &output
+	cmd.Stdout = progress
+	cmd.Stderr = progress
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Print(output.String())
+		return client.Fail(lease, err.Error())
+	}
+	return client.Output(ctx, lease, result)
+}
+
+// progress parses ffmpeg output for percent complete
+type progress struct {
+	writer   *bytes.Buffer
+	sync     sync.Mutex
+	duration float64
+	percent  int
+	line     []byte
+}
+
+func (p *progress) Write(data []byte) (int, error) {
+	p.sync.Lock()
+	defer p.sync.Unlock()
+	p.writer.Write(data)
+	for _, b := range data {
+		if b == '\r' || b == '\n' {
+			p.parse(p.line)
+			p.line = p.line[:0]
+		} else {
+			p.line = append(p.line, b)
+		}
+	}
+	return len(data), nil
+}
+
+func (p *progress) parse(line []byte) {
+	if p.duration == 0 {
+		if match := durationPattern.FindSubmatch(line); match != nil {
+			p.duration = parseTime(match)
+		}
+		return
+	}
+	if match := timePattern.FindSubmatch(line); match != nil {
+		percent := int(parseTime(match) * 100 / p.duration)
+		if percent > p.percent && percent < 100 {
+			p.percent = percent
+		}
+	}
+}
+
+func (p *progress) get() int {
+	p.sync.Lock()
+	defer p.sync.Unlock()
+	return p.percent
+}
+
+func parseTime(match [][]byte) float64 {
+	hours, _ := strconv.ParseFloat(string(match[1]), 64)
+	minutes, _ := strconv.ParseFloat(string(match[2]), 64)
+	seconds, _ := strconv.ParseFloat(string(match[3]), 64)
+	return hours*3600 + minutes*60 + seconds
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testNode fakes the node lease endpoints for a single lease
+type testNode struct {
+	sync     sync.Mutex
+	source   []byte
+	output   []byte
+	failed   string
+	renewals chan bool
+	revoked  bool
+	hold     bool
+}
+
+func (n *testNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("worker") != "token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodPut && r.URL.Path == "/transcode/leases/lease/progress":
+		n.sync.Lock()
+		revoked := n.revoked
+		n.sync.Unlock()
+		if revoked {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		select {
+		case n.renewals <- true:
+		default:
+		}
+	case r.Method == http.MethodGet && r.URL.Path == "/transcode/leases/lease/source":
+		// hold transfer until lease is renewed or request is cancelled
+		if !n.renewed(r) {
+			return
+		}
+		w.Write(n.source)
+	case r.Method == http.MethodPut && r.URL.Path == "/transcode/leases/lease/output":
+		if !n.renewed(r) {
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		n.sync.Lock()
+		n.output = data
+		n.sync.Unlock()
+	case r.Method == http.MethodDelete && r.URL.Path == "/transcode/leases/lease":
+		n.sync.Lock()
+		n.failed = r.URL.Query().Get("error")
+		n.sync.Unlock()
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (n *testNode) renewed(r *http.Request) bool {
+	if !n.hold {
+		return true
+	}
+	select {
+	case <-n.renewals:
+		return true
+	case <-r.Context().Done():
+		return false
+	case <-time.After(5 * time.Second):
+		return false
+	}
+}
+
+func testWorker(t *testing.T, node *testNode) (*Client, string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("transform scripts require sh")
+	}
+	heartbeat = 50 * time.Millisecond
+	t.Cleanup(func() { heartbeat = 30 * time.Second })
+	node.renewals = make(chan bool, 1)
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	scripts := t.TempDir()
+	script := []byte("#!/bin/sh\ncp \"$1\" \"$2\"\n")
+	if err := os.WriteFile(filepath.Join(scripts, "transform_copy.sh"), script, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return &Client{Server: server.URL, Token: "token"}, scripts
+}
+
+func TestProcessRenewsTransfers(t *testing.T) {
+	node := &testNode{source: []byte("source"), hold: true}
+	client, scripts := testWorker(t, node)
+
+	// download and upload each wait on a renewal
+	if err := process(client, scripts, &Lease{LeaseID: "lease", Transform: "copy"}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(node.output, node.source) {
+		t.Fatalf("unexpected output %q", node.output)
+	}
+}
+
+func TestProcessLeaseLost(t *testing.T) {
+	node := &testNode{source: []byte("source"), hold: true, revoked: true}
+	client, scripts := testWorker(t, node)
+
+	// revoked lease cancels download
+	if err := process(client, scripts, &Lease{LeaseID: "lease", Transform: "copy"}); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("expected lost lease, got %v", err)
+	}
+	if node.output != nil || node.failed != "" {
+		t.Fatal("lost lease completed")
+	}
+}
+
+func TestProcessFailure(t *testing.T) {
+	node := &testNode{source: []byte("source")}
+	client, scripts := testWorker(t, node)
+
+	// invalid and failing transforms reported to node
+	if err := process(client, scripts, &Lease{LeaseID: "lease", Transform: "../copy"}); err != nil {
+		t.Fatal(err)
+	}
+	if node.failed != "invalid transform" {
+		t.Fatalf("unexpected failure %q", node.failed)
+	}
+	node.failed = ""
+	if err := process(client, scripts, &Lease{LeaseID: "lease", Transform: "missing"}); err != nil {
+		t.Fatal(err)
+	}
+	if node.failed == "" || node.output != nil {
+		t.Fatal("missing transform not failed")
+	}
+}
+
+func TestProgress(t *testing.T) {
+	p := &progress{writer: &bytes.Buffer{}}
+	p.Write([]byte("  Duration: 00:01:40.00, start: 0.000000\n"))
+	p.Write([]byte("frame=1 time=00:00:25.00 bitrate=1\rframe=2 time=00:00"))
+	if p.get() != 25 {
+		t.Fatalf("unexpected progress %d", p.get())
+	}
+	p.Write([]byte(":50.00 bitrate=1\r"))
+	if p.get() != 50 {
+		t.Fatalf("unexpected progress %d", p.get())
+	}
+
+	// never reports complete or moves backwards
+	p.Write([]byte("time=00:01:40.00\rtime=00:00:10.00\r"))
+	if p.get() != 50 {
+		t.Fatalf("unexpected progress %d", p.get())
+	}
+}